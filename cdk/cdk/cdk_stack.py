@@ -12,6 +12,7 @@ from aws_cdk import (
     aws_route53_targets as targets,
     aws_certificatemanager as acm,
     CfnOutput,
+    Duration,
 )
 from constructs import Construct
 
@@ -93,7 +94,16 @@ class CdkStack(Stack):
             image=ecs.ContainerImage.from_registry(f"{repo.repository_uri}:latest"),
             logging=ecs.LogDriver.aws_logs(stream_prefix="weather"),
             secrets=secrets,
-            environment={}
+            # Only the ALB inside the VPC may set X-Forwarded-For
+            environment={"TRUSTED_PROXIES": vpc.vpc_cidr_block},
+            # Liveness: restart the task if the scheduler loops stop ticking
+            health_check=ecs.HealthCheck(
+                command=["CMD-SHELL", "wget -q -O /dev/null http://localhost:8080/healthz || exit 1"],
+                interval=Duration.seconds(30),
+                timeout=Duration.seconds(5),
+                retries=3,
+                start_period=Duration.seconds(60)
+            )
         )
         container.add_port_mappings(ecs.PortMapping(container_port=8080))
 
@@ -138,8 +148,10 @@ class CdkStack(Stack):
         https_listener.add_targets("WeatherApiTargets",
             port=8080,
             targets=[service],
+            # Readiness fails only without a database; provider or mailer outages
+            # must not take every task out of the target group
             health_check=elbv2.HealthCheck(
-                path="/health",
+                path="/readyz",
                 port="8080",
                 healthy_http_codes="200"
             )
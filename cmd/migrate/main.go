@@ -0,0 +1,97 @@
+// Command migrate applies, rolls back and lists the versioned database migrations.
+//
+//	migrate up          apply all pending migrations
+//	migrate down [n]    roll back the last n applied migrations (default 1)
+//	migrate status      list migrations and when they were applied
+//	migrate backfill-locations
+//	                    link subscriptions created before canonical locations existed
+//
+// It uses the same DB_TYPE and DB_URL settings as the server.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"weatherApi/config"
+	"weatherApi/internal/db"
+	"weatherApi/pkg/logging"
+	"weatherApi/pkg/weatherapi"
+)
+
+const usage = "usage: migrate up | down [n] | status | backfill-locations"
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	config.LoadConfig()
+	logging.Setup(config.C.LogLevel)
+
+	gdb, err := db.Open(config.C.DBType, config.C.DBUrl)
+	if err != nil {
+		slog.Error("failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "up":
+		applied, err := db.Migrate(gdb)
+		if err != nil {
+			slog.Error("migration failed", "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("applied %d migration(s)\n", applied)
+
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			steps, err = strconv.Atoi(os.Args[2])
+			if err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, usage)
+				os.Exit(2)
+			}
+		}
+		rolledBack, err := db.Rollback(gdb, steps)
+		if err != nil {
+			slog.Error("rollback failed", "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("rolled back %d migration(s)\n", rolledBack)
+
+	case "status":
+		statuses, err := db.Status(gdb)
+		if err != nil {
+			slog.Error("failed to read migration status", "error", err)
+			os.Exit(1)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.UTC().Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		w.Flush()
+
+	case "backfill-locations":
+		updated, err := db.BackfillLocations(context.Background(), gdb, weatherapi.ResolveLocation)
+		if err != nil {
+			slog.Error("location backfill failed", "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("linked %d subscription(s) to locations\n", updated)
+
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -11,55 +11,94 @@ import (
 	"weatherApi/config"
 	"weatherApi/internal/api"
 	"weatherApi/internal/db"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/logging"
+	"weatherApi/pkg/ratelimit"
 	"weatherApi/pkg/scheduler"
+	"weatherApi/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// Load application configuration (from environment or .env file)
+	config.LoadConfig()
+
+	// JSON logs to stdout; the level comes from LOG_LEVEL
+	logging.Setup(config.C.LogLevel)
+
+	// OpenTelemetry tracing (disabled unless TRACING_EXPORTER is set)
+	shutdownTracing, err := tracing.Setup(context.Background(), config.C.TracingExporter, config.C.TracingSampleRatio)
+	if err != nil {
+		slog.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
 	// ─── GIN Mode Setup ─────────────────────────────────────
 	mode := os.Getenv("GIN_MODE")
 	if mode == "" {
 		mode = gin.DebugMode // ← default DEBUG
 	}
 	gin.SetMode(mode)
-	log.Printf("🚀 Starting in %s mode\n", gin.Mode())
-
-	// Load application configuration (from environment or .env file)
-	config.LoadConfig()
+	slog.Info("starting server", "mode", gin.Mode())
 
 	// Initialize and connect to the database
 	db.ConnectDefaultDB()
 	dbInstance := db.DB
 
-	// Inject DB instance into API and scheduler layers
-	api.SetDB(dbInstance)
-	scheduler.SetDB(dbInstance)
+	// Wire the subscription repository into the scheduler and HTTP handlers
+	subs := repository.NewGormSubscriptionRepository(dbInstance)
+	sched := scheduler.New(subs, dbInstance)
+	sched.TrackEngagement = config.C.EngagementTracking
+	handler := api.NewHandler(subs, dbInstance, sched)
+
+	// Share anti-abuse rate limits across replicas when configured
+	if config.C.RateLimitStore == "db" {
+		handler.SetRateLimitStore(ratelimit.NewGormStore(dbInstance))
+	}
+	if err := handler.SetSendGridWebhookKey(config.C.SendGridWebhookPublicKey); err != nil {
+		slog.Error("invalid SENDGRID_WEBHOOK_PUBLIC_KEY", "error", err)
+		os.Exit(1)
+	}
 
 	// Set up graceful shutdown context
 	_, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Start background weather update scheduler in a separate goroutine
-	go scheduler.StartWeatherScheduler()
+	go sched.Start()
 
 	// Listen for termination signals (e.g., Ctrl+C, SIGTERM from Docker/K8s)
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigs
-		log.Println("Shutting down gracefully...")
+		slog.Info("shutting down gracefully")
 		cancel()
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), time.Second)
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Warn("failed to flush traces", "error", err)
+		}
+		flushCancel()
 		time.Sleep(2 * time.Second) // Give time for background tasks to finish
 		os.Exit(0)
 	}()
 
-	// Initialize Gin HTTP server and register all routes
-	r := gin.Default()
-	api.RegisterRoutes(r)
+	// Initialize Gin HTTP server and register all routes.
+	// Access logs are written by the API's structured request logger.
+	r := gin.New()
+	r.Use(gin.Recovery())
+	// Client IPs key the per-IP rate limits, so X-Forwarded-For is only honoured from
+	// the load balancer; without TRUSTED_PROXIES the peer address is used
+	if err := r.SetTrustedProxies(config.C.TrustedProxies); err != nil {
+		slog.Error("invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+	api.RegisterRoutes(r, handler)
 
 	// Start HTTP server on the configured port
 	if err := r.Run(":" + config.C.Port); err != nil {
-		log.Fatalf("failed to start server: %v", err)
+		slog.Error("failed to start server", "error", err)
+		os.Exit(1)
 	}
 }
@@ -1,21 +1,85 @@
 package config
 
 import (
-	"github.com/joho/godotenv"
-	"log"
+	"log/slog"
 	"os"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port          string
-	DBType        string
-	DBUrl         string
-	JWTSecret     string
-	SendGridKey   string
-	EmailFrom     string
-	WeatherAPIKey string
-	BaseURL       string
+	Port      string
+	DBType    string
+	DBUrl     string
+	JWTSecret string
+	// Secret keying the email hashes in logs and suppression records; changing it
+	// makes existing suppressions unmatchable
+	EmailHashKey string
+	SendGridKey  string
+	// Base64 DER public key that signs SendGrid event webhooks; empty rejects all events
+	SendGridWebhookPublicKey string
+	EmailFrom                string
+	WeatherAPIKey            string
+	BaseURL                  string
+
+	LogLevel string // Minimum log level: debug, info, warn or error
+
+	TracingExporter    string  // "none", "stdout" or "otlp" (endpoint via OTEL_EXPORTER_OTLP_ENDPOINT)
+	TracingSampleRatio float64 // Fraction of new traces to sample, 0–1
+
+	AlertPollInterval time.Duration // How often alert subscriptions are checked for new warnings
+
+	OutboxPollInterval time.Duration // How often queued background jobs (e.g. welcome forecasts) are executed
+	OutboxMaxAttempts  int           // Executions per job before it is given up
+
+	MaintenanceInterval       time.Duration // How often reminders, expiry and retention are applied
+	ConfirmationReminderAfter time.Duration // Delay after the confirmation email before one reminder is sent
+	PendingExpiryDays         int           // Unconfirmed subscriptions expire this many days after their confirmation email (0 disables)
+	UnsubscribedRetentionDays int           // Unsubscribed subscriptions are cleaned up after this many days (0 disables)
+	RetentionAction           string        // RetentionAnonymize or RetentionDelete
+
+	HourlyChangeOnly        bool          // Send hourly updates only when the weather changed significantly
+	HourlyTempDelta         float64       // Temperature change in °C since the last email that triggers one (0 ignores temperature)
+	HourlyOnConditionChange bool          // A changed weather description triggers an email
+	HourlyOnPrecipitation   bool          // Precipitation starting triggers an email
+	HourlyHeartbeat         time.Duration // Longest time without an hourly email when nothing changes (0 disables)
+
+	EngagementTracking       bool // Add an open pixel and click redirects to forecast emails
+	EngagementDowngradeAfter int  // Hourly subscribers who opened none of this many tracked emails are moved to daily (0 disables)
+
+	RateLimitStore             string        // "memory" (per replica) or "db" (shared across replicas)
+	SubscribeLimitPerIP        int           // Max /api/subscribe requests per client IP per hour (0 disables)
+	SubscribeLimitPerEmail     int           // Max /api/subscribe requests per target email per hour (0 disables)
+	CitySearchLimitPerIP       int           // Max /api/cities/search requests per client IP per hour (0 disables)
+	ConfirmationResendCooldown time.Duration // Minimum delay between confirmation emails to one address
+	PrivacyLinkTTL             time.Duration // How long a data export/erasure magic link stays valid
+	TrustedProxies             []string      // CIDRs whose X-Forwarded-For is trusted for client IPs; none when not behind a proxy
+
+	AdminKeys []AdminKey // API keys accepted by the admin API
+}
+
+// Retention actions for long-unsubscribed subscriptions.
+const (
+	RetentionAnonymize = "anonymize" // Keep the row for statistics without personal data
+	RetentionDelete    = "delete"    // Remove the row and its history
+)
+
+// Admin roles. Viewers can only read; admins can also modify subscriptions.
+const (
+	RoleViewer = "viewer"
+	RoleAdmin  = "admin"
+)
+
+// AdminKey is a named admin API key with a role.
+// Configured via ADMIN_API_KEYS as comma-separated "name:role:key" entries,
+// e.g. "alice:admin:3f9c...,support:viewer:a71b...".
+type AdminKey struct {
+	Name string // Actor name recorded in the audit log
+	Role string // RoleViewer or RoleAdmin
+	Key  string // Secret sent as a bearer token or X-API-Key header
 }
 
 var C *Config
@@ -24,15 +88,54 @@ func LoadConfig() {
 	_ = godotenv.Load()
 
 	C = &Config{
-		Port:          getEnv("PORT", "8080"),
-		DBType:        getEnv("DB_TYPE", "postgres"),
-		DBUrl:         getEnv("DB_URL", "host=your-host user=your-user password=your-password dbname=your-db port=5432 sslmode=require"),
-		BaseURL:       strings.TrimRight(getEnv("BASE_URL", "http://localhost:8080"), "/"),
-		JWTSecret:     getEnv("JWT_SECRET", "default_secret"),
-		SendGridKey:   mustGet("SENDGRID_API_KEY"),
-		EmailFrom:     mustGet("EMAIL_FROM"),
-		WeatherAPIKey: mustGet("WEATHER_API_KEY"),
+		Port:        getEnv("PORT", "8080"),
+		DBType:      getEnv("DB_TYPE", "postgres"),
+		DBUrl:       getEnv("DB_URL", "host=your-host user=your-user password=your-password dbname=your-db port=5432 sslmode=require"),
+		BaseURL:     strings.TrimRight(getEnv("BASE_URL", "http://localhost:8080"), "/"),
+		JWTSecret:   getEnv("JWT_SECRET", "default_secret"),
+		SendGridKey: mustGet("SENDGRID_API_KEY"),
+
+		SendGridWebhookPublicKey: getEnv("SENDGRID_WEBHOOK_PUBLIC_KEY", ""),
+		EmailFrom:                mustGet("EMAIL_FROM"),
+		WeatherAPIKey:            mustGet("WEATHER_API_KEY"),
+
+		LogLevel: getEnv("LOG_LEVEL", "info"),
+
+		TracingExporter:    getEnv("TRACING_EXPORTER", "none"),
+		TracingSampleRatio: getRatio("TRACING_SAMPLE_RATIO", 1),
+
+		AlertPollInterval: getDuration("ALERT_POLL_INTERVAL", 15*time.Minute),
+
+		OutboxPollInterval: getDuration("OUTBOX_POLL_INTERVAL", 10*time.Second),
+		OutboxMaxAttempts:  getInt("OUTBOX_MAX_ATTEMPTS", 5),
+
+		MaintenanceInterval:       getDuration("MAINTENANCE_INTERVAL", time.Hour),
+		ConfirmationReminderAfter: getDuration("CONFIRMATION_REMINDER_AFTER", 24*time.Hour),
+		PendingExpiryDays:         getInt("PENDING_EXPIRY_DAYS", 7),
+		UnsubscribedRetentionDays: getInt("UNSUBSCRIBED_RETENTION_DAYS", 180),
+		RetentionAction:           getRetentionAction("RETENTION_ACTION"),
+
+		HourlyChangeOnly:        getBool("HOURLY_CHANGE_ONLY", true),
+		HourlyTempDelta:         getFloat("HOURLY_TEMP_DELTA", 2),
+		HourlyOnConditionChange: getBool("HOURLY_ON_CONDITION_CHANGE", true),
+		HourlyOnPrecipitation:   getBool("HOURLY_ON_PRECIPITATION", true),
+		HourlyHeartbeat:         getOptionalDuration("HOURLY_HEARTBEAT", 6*time.Hour),
+
+		EngagementTracking:       getBool("ENGAGEMENT_TRACKING", false),
+		EngagementDowngradeAfter: getInt("ENGAGEMENT_DOWNGRADE_AFTER", 0),
+
+		RateLimitStore:             getEnv("RATE_LIMIT_STORE", "memory"),
+		SubscribeLimitPerIP:        getInt("SUBSCRIBE_LIMIT_PER_IP", 10),
+		SubscribeLimitPerEmail:     getInt("SUBSCRIBE_LIMIT_PER_EMAIL", 3),
+		CitySearchLimitPerIP:       getInt("CITY_SEARCH_LIMIT_PER_IP", 300),
+		ConfirmationResendCooldown: getDuration("CONFIRMATION_RESEND_COOLDOWN", 2*time.Minute),
+		PrivacyLinkTTL:             getDuration("PRIVACY_LINK_TTL", time.Hour),
+		TrustedProxies:             getList("TRUSTED_PROXIES"),
+
+		AdminKeys: parseAdminKeys(getEnv("ADMIN_API_KEYS", "")),
 	}
+	// Defaults to JWT_SECRET, so existing deployments keep working without a new secret
+	C.EmailHashKey = getEnv("EMAIL_HASH_KEY", C.JWTSecret)
 }
 
 func Reload() {
@@ -43,7 +146,8 @@ func Reload() {
 func mustGet(key string) string {
 	val := os.Getenv(key)
 	if val == "" {
-		log.Fatalf("Missing required environment variable: %s", key)
+		slog.Error("missing required environment variable", "key", key)
+		os.Exit(1)
 	}
 	return val
 }
@@ -55,3 +159,115 @@ func getEnv(key, fallback string) string {
 	}
 	return val
 }
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		slog.Warn("invalid duration, using default", "key", key, "value", val, "default", fallback.String())
+		return fallback
+	}
+	return d
+}
+
+func getInt(key string, fallback int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		slog.Warn("invalid integer, using default", "key", key, "value", val, "default", fallback)
+		return fallback
+	}
+	return n
+}
+
+func getBool(key string, fallback bool) bool {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		slog.Warn("invalid boolean, using default", "key", key, "value", val, "default", fallback)
+		return fallback
+	}
+	return b
+}
+
+func getFloat(key string, fallback float64) float64 {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil || f < 0 {
+		slog.Warn("invalid number, using default", "key", key, "value", val, "default", fallback)
+		return fallback
+	}
+	return f
+}
+
+// getOptionalDuration is getDuration that also accepts "0" to disable a feature.
+func getOptionalDuration(key string, fallback time.Duration) time.Duration {
+	if os.Getenv(key) == "0" {
+		return 0
+	}
+	return getDuration(key, fallback)
+}
+
+// getList splits a comma-separated value, dropping empty entries. Unset returns nil.
+func getList(key string) []string {
+	var list []string
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+func getRatio(key string, fallback float64) float64 {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil || f < 0 || f > 1 {
+		slog.Warn("invalid ratio, using default", "key", key, "value", val, "default", fallback)
+		return fallback
+	}
+	return f
+}
+
+func getRetentionAction(key string) string {
+	val := getEnv(key, RetentionAnonymize)
+	if val != RetentionAnonymize && val != RetentionDelete {
+		slog.Warn("invalid retention action, using default", "key", key, "value", val, "default", RetentionAnonymize)
+		return RetentionAnonymize
+	}
+	return val
+}
+
+// parseAdminKeys parses "name:role:key" entries, skipping malformed ones.
+func parseAdminKeys(val string) []AdminKey {
+	var keys []AdminKey
+	for i, entry := range strings.Split(val, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" || (parts[1] != RoleViewer && parts[1] != RoleAdmin) {
+			// Log only the position: a malformed entry may be nothing but the secret key
+			slog.Warn("ignoring malformed ADMIN_API_KEYS entry, expected name:role:key", "entry", i)
+			continue
+		}
+		keys = append(keys, AdminKey{Name: parts[0], Role: parts[1], Key: parts[2]})
+	}
+	return keys
+}
@@ -0,0 +1,406 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Pagination defaults for admin listings.
+const (
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 200
+)
+
+// registerAdminRoutes wires the authenticated admin API under /admin/api.
+// Viewers can list and look up subscriptions; admins can also modify them.
+func registerAdminRoutes(r *gin.Engine, h *Handler) {
+	admin := r.Group("/admin/api", adminAuth())
+	{
+		read := admin.Group("", requireRole(config.RoleViewer))
+		read.GET("/subscriptions", h.adminListSubscriptionsHandler)
+		read.GET("/subscriptions/by-email/:email", h.adminGetSubscriptionByEmailHandler)
+		read.GET("/subscriptions/:id", h.adminGetSubscriptionHandler)
+		read.GET("/subscriptions/:id/events", h.adminListSubscriptionEventsHandler)
+		read.GET("/subscriptions/:id/engagement", h.adminEngagementHandler)
+
+		write := admin.Group("", requireRole(config.RoleAdmin))
+		write.POST("/subscriptions/:id/confirm", h.adminConfirmHandler)
+		write.POST("/subscriptions/:id/unsubscribe", h.adminUnsubscribeHandler)
+		write.POST("/subscriptions/:id/resend-confirmation", h.adminResendConfirmationHandler)
+		write.DELETE("/subscriptions/:id", h.adminDeleteSubscriptionHandler)
+		write.GET("/audit-log", h.adminListAuditLogHandler)
+	}
+}
+
+// adminListSubscriptionsHandler returns a page of subscriptions.
+// Supported query parameters: page, page_size, city, status (one of model.Statuses),
+// frequency, created_from and created_to (RFC 3339 or YYYY-MM-DD, inclusive).
+func (h *Handler) adminListSubscriptionsHandler(c *gin.Context) {
+	page, pageSize, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := parseSubscriptionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subs, total, err := h.subs.List(c.Request.Context(), filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve subscriptions"})
+		return
+	}
+
+	if !h.recordAudit(c, "subscription.list", "", c.Request.URL.RawQuery) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":     subs,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+	})
+}
+
+// adminGetSubscriptionByEmailHandler looks up a subscription by email address.
+func (h *Handler) adminGetSubscriptionByEmailHandler(c *gin.Context) {
+	email := strings.TrimSpace(c.Param("email"))
+
+	sub, err := h.subs.GetByEmail(c.Request.Context(), email)
+	if err != nil {
+		respondLookupError(c, err)
+		return
+	}
+
+	if !h.recordAudit(c, "subscription.lookup_email", sub.ID, email) {
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// adminGetSubscriptionHandler returns a single subscription by ID.
+func (h *Handler) adminGetSubscriptionHandler(c *gin.Context) {
+	sub, ok := h.loadAdminSubscription(c)
+	if !ok {
+		return
+	}
+
+	if !h.recordAudit(c, "subscription.view", sub.ID, "") {
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// adminListSubscriptionEventsHandler returns the status history of a subscription, oldest first.
+func (h *Handler) adminListSubscriptionEventsHandler(c *gin.Context) {
+	sub, ok := h.loadAdminSubscription(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.subs.Events(c.Request.Context(), sub.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve status history"})
+		return
+	}
+
+	if !h.recordAudit(c, "subscription.view_events", sub.ID, "") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": events})
+}
+
+// adminConfirmHandler manually activates a pending or paused subscription (no welcome email is sent).
+func (h *Handler) adminConfirmHandler(c *gin.Context) {
+	sub, ok := h.loadAdminSubscription(c)
+	if !ok {
+		return
+	}
+
+	err := h.transitionAsAdmin(c, sub, model.StatusActive, "subscription.confirm", "confirmed by admin")
+	if errors.Is(err, model.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": transitionConflict(sub, "confirm")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm subscription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// adminUnsubscribeHandler manually unsubscribes a subscription.
+func (h *Handler) adminUnsubscribeHandler(c *gin.Context) {
+	sub, ok := h.loadAdminSubscription(c)
+	if !ok {
+		return
+	}
+
+	err := h.transitionAsAdmin(c, sub, model.StatusUnsubscribed, "subscription.unsubscribe", "unsubscribed by admin")
+	if errors.Is(err, model.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{"error": transitionConflict(sub, "unsubscribe")})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsubscribe"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+// adminResendConfirmationHandler re-sends the confirmation email of an unconfirmed subscription.
+func (h *Handler) adminResendConfirmationHandler(c *gin.Context) {
+	sub, ok := h.loadAdminSubscription(c)
+	if !ok {
+		return
+	}
+
+	err := h.resendConfirmationAsAdmin(c, sub)
+	if errors.Is(err, errAlreadyConfirmed) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Subscription already confirmed"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resend confirmation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Confirmation email sent"})
+}
+
+// errAlreadyConfirmed is returned when a confirmation email is requested for an active subscription.
+var errAlreadyConfirmed = errors.New("subscription already confirmed")
+
+// transitionAsAdmin moves the subscription to status to and records the audit action,
+// both in one transaction. Shared by the admin API and the dashboard.
+func (h *Handler) transitionAsAdmin(c *gin.Context, sub *model.Subscription, to, action, reason string) error {
+	err := h.subs.Transaction(c.Request.Context(), func(ctx context.Context) error {
+		if err := h.subs.Transition(ctx, sub.ID, to, adminStatusChange(c, reason)); err != nil {
+			return err
+		}
+		return h.writeAudit(ctx, c, action, sub.ID, "")
+	})
+	if err != nil {
+		return err
+	}
+
+	sub.Status = to
+	return nil
+}
+
+// adminStatusChange attributes a status change to the current admin key.
+func adminStatusChange(c *gin.Context, reason string) repository.StatusChange {
+	return repository.StatusChange{Actor: model.AdminActor(currentAdmin(c).Name), Reason: reason}
+}
+
+// transitionConflict describes a status change the state machine rejected.
+func transitionConflict(sub *model.Subscription, action string) string {
+	return fmt.Sprintf("Cannot %s a subscription that is %s", action, sub.Status)
+}
+
+// resendConfirmationAsAdmin moves an unconfirmed subscription back to pending, re-sends
+// its confirmation email and records the action. The admin resend bypasses the public
+// cooldown but still records the send time. Shared by the admin API and the dashboard.
+func (h *Handler) resendConfirmationAsAdmin(c *gin.Context, sub *model.Subscription) error {
+	if sub.Status == model.StatusActive || sub.Status == model.StatusPaused {
+		return errAlreadyConfirmed
+	}
+
+	err := h.subs.Transaction(c.Request.Context(), func(ctx context.Context) error {
+		if err := h.subs.Transition(ctx, sub.ID, model.StatusPending, adminStatusChange(c, "confirmation resent by admin")); err != nil {
+			return err
+		}
+		if err := h.subs.MarkConfirmationSent(ctx, sub.ID, time.Now()); err != nil {
+			return err
+		}
+		return h.writeAudit(ctx, c, "subscription.resend_confirmation", sub.ID, "")
+	})
+	if err != nil {
+		return err
+	}
+
+	h.sendConfirmationEmailAsync(logging.WithSubscriptionID(c.Request.Context(), sub.ID), sub.Email, sub.Token)
+	return nil
+}
+
+// adminDeleteSubscriptionHandler permanently deletes a subscription and its dependent rows.
+func (h *Handler) adminDeleteSubscriptionHandler(c *gin.Context) {
+	sub, ok := h.loadAdminSubscription(c)
+	if !ok {
+		return
+	}
+
+	err := h.subs.Transaction(c.Request.Context(), func(ctx context.Context) error {
+		if err := h.subs.Delete(ctx, sub.ID); err != nil {
+			return err
+		}
+		return h.writeAudit(ctx, c, "subscription.delete", sub.ID, sub.Email)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete subscription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subscription deleted"})
+}
+
+// adminListAuditLogHandler returns a page of audit entries, newest first.
+// Optional filters: actor, action, target_id.
+func (h *Handler) adminListAuditLogHandler(c *gin.Context) {
+	page, pageSize, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.db.WithContext(c.Request.Context()).Model(&model.AuditLog{})
+	for _, field := range []string{"actor", "action", "target_id"} {
+		if v := c.Query(field); v != "" {
+			query = query.Where(field+" = ?", v)
+		}
+	}
+
+	var total int64
+	var entries []model.AuditLog
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit log"})
+		return
+	}
+	if err := query.Order("created_at DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": entries, "page": page, "page_size": pageSize, "total": total})
+}
+
+// loadAdminSubscription loads the subscription identified by the :id path parameter,
+// writing a 404/500 response if it cannot be loaded.
+func (h *Handler) loadAdminSubscription(c *gin.Context) (*model.Subscription, bool) {
+	sub, err := h.subs.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondLookupError(c, err)
+		return nil, false
+	}
+	return sub, true
+}
+
+// respondLookupError maps a lookup error to 404 (not found) or 500 (anything else).
+func respondLookupError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve subscription"})
+}
+
+// parsePagination reads page (1-based) and page_size query parameters.
+func parsePagination(c *gin.Context) (int, int, error) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, fmt.Errorf("Invalid page")
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultAdminPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxAdminPageSize {
+		return 0, 0, fmt.Errorf("page_size must be between 1 and %d", maxAdminPageSize)
+	}
+
+	return page, pageSize, nil
+}
+
+// parseSubscriptionFilter reads the listing filters from the query string.
+func parseSubscriptionFilter(c *gin.Context) (repository.SubscriptionFilter, error) {
+	f := repository.SubscriptionFilter{
+		City:      strings.TrimSpace(c.Query("city")),
+		Frequency: c.Query("frequency"),
+		Status:    c.Query("status"),
+	}
+
+	if f.Status != "" && !model.ValidStatus(f.Status) {
+		return f, fmt.Errorf("Invalid status")
+	}
+
+	if v := c.Query("created_from"); v != "" {
+		from, err := parseAdminTime(v, false)
+		if err != nil {
+			return f, fmt.Errorf("Invalid created_from")
+		}
+		f.CreatedFrom = from
+	}
+
+	if v := c.Query("created_to"); v != "" {
+		to, err := parseAdminTime(v, true)
+		if err != nil {
+			return f, fmt.Errorf("Invalid created_to")
+		}
+		f.CreatedTo = to
+	}
+
+	return f, nil
+}
+
+// parseAdminTime parses an RFC 3339 timestamp or a YYYY-MM-DD date.
+// For dates, endOfDay selects the last instant of the day so ranges are inclusive.
+func parseAdminTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		return day.Add(24*time.Hour - time.Nanosecond), nil
+	}
+	return day, nil
+}
+
+// writeAudit appends an audit entry for the current admin. Inside h.subs.Transaction
+// the entry joins the transaction, so a mutation and its audit entry are stored together.
+func (h *Handler) writeAudit(ctx context.Context, c *gin.Context, action, targetID, details string) error {
+	key := currentAdmin(c)
+	return repository.Conn(ctx, h.db).Create(&model.AuditLog{
+		ID:        uuid.New().String(),
+		Actor:     key.Name,
+		Role:      key.Role,
+		Action:    action,
+		TargetID:  targetID,
+		Details:   details,
+		IP:        c.ClientIP(),
+		CreatedAt: time.Now(),
+	}).Error
+}
+
+// recordAudit writes an audit entry for a read-only action.
+// If the entry cannot be written the request fails, so no access goes unrecorded.
+func (h *Handler) recordAudit(c *gin.Context, action, targetID, details string) bool {
+	if err := h.writeAudit(c.Request.Context(), c, action, targetID, details); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write audit log"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"weatherApi/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminKeyContextKey is the gin context key holding the authenticated config.AdminKey.
+const adminKeyContextKey = "adminKey"
+
+// adminAuth authenticates admin API requests using a key from config.C.AdminKeys,
+// sent either as "Authorization: Bearer <key>" or as "X-API-Key: <key>".
+// With no keys configured, the admin API rejects every request.
+func adminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := findAdminKey(presentedAdminKey(c.Request))
+		if !ok {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		c.Set(adminKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// requireRole allows the request only if the authenticated key has the given role.
+// Admins implicitly have every role.
+func requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := currentAdmin(c)
+		if key.Role != role && key.Role != config.RoleAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// presentedAdminKey extracts the key from the bearer token or X-API-Key header.
+func presentedAdminKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// findAdminKey looks up a configured key, comparing in constant time.
+func findAdminKey(presented string) (config.AdminKey, bool) {
+	if presented == "" {
+		return config.AdminKey{}, false
+	}
+
+	var found config.AdminKey
+	ok := false
+	for _, k := range config.C.AdminKeys {
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(presented)) == 1 {
+			found, ok = k, true
+		}
+	}
+	return found, ok
+}
+
+// currentAdmin returns the key authenticated by adminAuth.
+func currentAdmin(c *gin.Context) config.AdminKey {
+	if v, ok := c.Get(adminKeyContextKey); ok {
+		if key, ok := v.(config.AdminKey); ok {
+			return key
+		}
+	}
+	return config.AdminKey{}
+}
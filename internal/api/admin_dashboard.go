@@ -0,0 +1,286 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/scheduler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Dashboard list sizes.
+const (
+	dashboardRecentLimit  = 10
+	dashboardPendingLimit = 20
+	dashboardRunsLimit    = 10
+	dashboardHistoryLimit = 50
+)
+
+// registerDashboardRoutes wires the server-rendered admin dashboard under /admin.
+// Browsers authenticate with HTTP Basic auth, using an admin API key as the password.
+// State-changing actions require the admin role and a CSRF token.
+func registerDashboardRoutes(r *gin.Engine, h *Handler) {
+	dash := r.Group("/admin", dashboardAuth(), requireRole(config.RoleViewer))
+	{
+		dash.GET("", h.dashboardHandler)
+		dash.GET("/search", h.dashboardSearchHandler)
+		dash.GET("/subscriptions/:id", h.dashboardSubscriptionHandler)
+
+		actions := dash.Group("/subscriptions/:id", requireRole(config.RoleAdmin), dashboardCSRF())
+		actions.POST("/resend", h.dashboardResendHandler)
+		actions.POST("/send-now", h.dashboardSendNowHandler)
+		actions.POST("/unsubscribe", h.dashboardUnsubscribeHandler)
+	}
+}
+
+// dashboardAuth authenticates dashboard requests via HTTP Basic auth.
+// The username is ignored; the password must be a configured admin API key.
+func dashboardAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, password, _ := c.Request.BasicAuth()
+		key, ok := findAdminKey(password)
+		if !ok {
+			c.Header("WWW-Authenticate", `Basic realm="weatherApi admin", charset="UTF-8"`)
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		c.Set(adminKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// dashboardCSRF rejects form posts without a valid CSRF token.
+// Browsers resend Basic credentials automatically, so forms must prove they were rendered by us.
+func dashboardCSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := csrfToken(currentAdmin(c))
+		if !hmac.Equal([]byte(c.PostForm("csrf_token")), []byte(expected)) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
+// csrfToken derives a per-key CSRF token from the JWT secret.
+func csrfToken(key config.AdminKey) string {
+	mac := hmac.New(sha256.New, []byte(config.C.JWTSecret))
+	mac.Write([]byte("admin-csrf:" + key.Key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// dashboardHandler renders subscriber counts, recent signups, pending confirmations
+// and the latest scheduler runs.
+func (h *Handler) dashboardHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	byCity, err := h.subs.CountActiveByCity(ctx)
+	if err != nil {
+		dashboardError(c, err)
+		return
+	}
+
+	totals, err := h.subs.CountByStatus(ctx)
+	if err != nil {
+		dashboardError(c, err)
+		return
+	}
+
+	recent, _, err := h.subs.List(ctx, repository.SubscriptionFilter{}, 0, dashboardRecentLimit)
+	if err != nil {
+		dashboardError(c, err)
+		return
+	}
+	pending, _, err := h.subs.List(ctx, repository.SubscriptionFilter{Status: model.StatusPending, OldestFirst: true}, 0, dashboardPendingLimit)
+	if err != nil {
+		dashboardError(c, err)
+		return
+	}
+
+	var runs []model.SchedulerRun
+	if err := h.db.WithContext(ctx).Order("started_at DESC").Limit(dashboardRunsLimit).Find(&runs).Error; err != nil {
+		dashboardError(c, err)
+		return
+	}
+
+	c.HTML(http.StatusOK, "admin_dashboard.html", gin.H{
+		"Admin":   currentAdmin(c),
+		"ByCity":  byCity,
+		"Totals":  totals,
+		"Recent":  recent,
+		"Pending": pending,
+		"Runs":    runs,
+		"Message": c.Query("msg"),
+	})
+}
+
+// dashboardSearchHandler redirects to the detail page of the subscription with the given email.
+func (h *Handler) dashboardSearchHandler(c *gin.Context) {
+	email := strings.TrimSpace(c.Query("email"))
+
+	sub, err := h.subs.GetByEmail(c.Request.Context(), email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.Redirect(http.StatusSeeOther, "/admin?msg="+url.QueryEscape("No subscription for "+email))
+			return
+		}
+		dashboardError(c, err)
+		return
+	}
+
+	if !h.recordAudit(c, "subscription.lookup_email", sub.ID, email) {
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, "/admin/subscriptions/"+sub.ID)
+}
+
+// dashboardSubscriptionHandler renders a single subscriber with delivery history and audit trail.
+func (h *Handler) dashboardSubscriptionHandler(c *gin.Context) {
+	sub, ok := h.loadDashboardSubscription(c)
+	if !ok {
+		return
+	}
+
+	conn := h.db.WithContext(c.Request.Context())
+	var deliveries []model.Delivery
+	if err := conn.Where("subscription_id = ?", sub.ID).Order("created_at DESC").
+		Limit(dashboardHistoryLimit).Find(&deliveries).Error; err != nil {
+		dashboardError(c, err)
+		return
+	}
+
+	var audit []model.AuditLog
+	if err := conn.Where("target_id = ?", sub.ID).Order("created_at DESC").
+		Limit(dashboardHistoryLimit).Find(&audit).Error; err != nil {
+		dashboardError(c, err)
+		return
+	}
+
+	events, err := h.subs.Events(c.Request.Context(), sub.ID)
+	if err != nil {
+		dashboardError(c, err)
+		return
+	}
+
+	if !h.recordAudit(c, "subscription.view", sub.ID, "") {
+		return
+	}
+
+	admin := currentAdmin(c)
+	c.HTML(http.StatusOK, "admin_subscription.html", gin.H{
+		"Admin":      admin,
+		"CanModify":  admin.Role == config.RoleAdmin,
+		"CSRFToken":  csrfToken(admin),
+		"Sub":        sub,
+		"Events":     events,
+		"Deliveries": deliveries,
+		"Audit":      audit,
+		"Message":    c.Query("msg"),
+	})
+}
+
+// dashboardResendHandler re-sends the confirmation email from the detail page.
+func (h *Handler) dashboardResendHandler(c *gin.Context) {
+	sub, ok := h.loadDashboardSubscription(c)
+	if !ok {
+		return
+	}
+
+	err := h.resendConfirmationAsAdmin(c, sub)
+	switch {
+	case errors.Is(err, errAlreadyConfirmed):
+		redirectToSubscription(c, sub.ID, "Subscription is already confirmed")
+	case err != nil:
+		dashboardError(c, err)
+	default:
+		redirectToSubscription(c, sub.ID, "Confirmation email sent")
+	}
+}
+
+// dashboardSendNowHandler immediately delivers the subscriber's update,
+// bypassing the schedule. The outcome shows up in the delivery history.
+func (h *Handler) dashboardSendNowHandler(c *gin.Context) {
+	sub, ok := h.loadDashboardSubscription(c)
+	if !ok {
+		return
+	}
+
+	if sub.Status != model.StatusActive {
+		redirectToSubscription(c, sub.ID, "Only active subscriptions can be sent")
+		return
+	}
+
+	sendErr := h.scheduler.ProcessSubscription(c.Request.Context(), *sub)
+
+	details := "ok"
+	if sendErr != nil {
+		details = sendErr.Error()
+	}
+	if !h.recordAudit(c, "subscription.send_now", sub.ID, details) {
+		return
+	}
+
+	switch {
+	case errors.Is(sendErr, scheduler.ErrNoConditionMatched):
+		redirectToSubscription(c, sub.ID, "No condition matched, nothing sent")
+	case sendErr != nil:
+		redirectToSubscription(c, sub.ID, "Send failed: "+sendErr.Error())
+	default:
+		redirectToSubscription(c, sub.ID, "Update sent")
+	}
+}
+
+// dashboardUnsubscribeHandler unsubscribes the subscriber from the detail page.
+func (h *Handler) dashboardUnsubscribeHandler(c *gin.Context) {
+	sub, ok := h.loadDashboardSubscription(c)
+	if !ok {
+		return
+	}
+
+	err := h.transitionAsAdmin(c, sub, model.StatusUnsubscribed, "subscription.unsubscribe", "unsubscribed by admin")
+	if errors.Is(err, model.ErrInvalidTransition) {
+		redirectToSubscription(c, sub.ID, transitionConflict(sub, "unsubscribe"))
+		return
+	}
+	if err != nil {
+		dashboardError(c, err)
+		return
+	}
+
+	redirectToSubscription(c, sub.ID, "Unsubscribed")
+}
+
+// loadDashboardSubscription loads the subscription from the :id path parameter for dashboard pages.
+func (h *Handler) loadDashboardSubscription(c *gin.Context) (*model.Subscription, bool) {
+	sub, err := h.subs.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.String(http.StatusNotFound, "Subscription not found")
+			return nil, false
+		}
+		dashboardError(c, err)
+		return nil, false
+	}
+	return sub, true
+}
+
+// redirectToSubscription sends the browser back to the detail page with a flash message (POST-redirect-GET).
+func redirectToSubscription(c *gin.Context, id, message string) {
+	c.Redirect(http.StatusSeeOther, "/admin/subscriptions/"+id+"?msg="+url.QueryEscape(message))
+}
+
+// dashboardError renders a plain-text 500 page.
+func dashboardError(c *gin.Context, err error) {
+	c.String(http.StatusInternalServerError, "Internal error: %v", err)
+}
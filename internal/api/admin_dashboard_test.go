@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDashboardRouter creates the admin test router used by the dashboard tests.
+func setupDashboardRouter(t *testing.T) (*gin.Engine, *Handler) {
+	return setupAdminRouter(t)
+}
+
+// dashboardRequest performs a dashboard request with Basic auth using the given key as password.
+func dashboardRequest(router *gin.Engine, method, target, key string, form url.Values) *httptest.ResponseRecorder {
+	var req *http.Request
+	if form != nil {
+		req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	if key != "" {
+		req.SetBasicAuth("staff", key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestDashboard_RequiresBasicAuth verifies that the dashboard asks browsers for credentials.
+func TestDashboard_RequiresBasicAuth(t *testing.T) {
+	router, _ := setupDashboardRouter(t)
+
+	w := dashboardRequest(router, http.MethodGet, "/admin", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Basic")
+}
+
+// TestDashboard_Overview verifies counts by city/frequency, pending confirmations and scheduler runs.
+func TestDashboard_Overview(t *testing.T) {
+	router, h := setupDashboardRouter(t)
+
+	finished := time.Now()
+	require.NoError(t, h.db.Create(&model.SchedulerRun{
+		ID: "run-1", Kind: "hourly", StartedAt: finished.Add(-time.Minute), FinishedAt: &finished, Processed: 5, Failed: 2,
+	}).Error)
+
+	w := dashboardRequest(router, http.MethodGet, "/admin", testViewerKey, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, "<td>Paris</td><td>daily</td><td class=\"text-end\">1</td>")
+	assert.Contains(t, body, "<td>Kyiv</td><td>hourly</td><td class=\"text-end\">1</td>")
+	assert.Contains(t, body, "pending@example.com")
+	assert.Contains(t, body, `<td class="text-end">2</td>`, "failure count of the run should be shown")
+}
+
+// TestDashboard_SubscriberDetail verifies the detail page shows delivery history
+// and hides actions from viewers.
+func TestDashboard_SubscriberDetail(t *testing.T) {
+	router, h := setupDashboardRouter(t)
+
+	require.NoError(t, h.db.Create(&model.Delivery{
+		ID: "d-1", SubscriptionID: "active-1", Kind: "weather.update", Status: model.DeliveryFailed,
+		Error: "SendGrid failed with status 500", CreatedAt: time.Now(),
+	}).Error)
+
+	w := dashboardRequest(router, http.MethodGet, "/admin/subscriptions/active-1", testViewerKey, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "SendGrid failed with status 500")
+	assert.NotContains(t, w.Body.String(), "csrf_token", "viewers get no action forms")
+
+	w = dashboardRequest(router, http.MethodGet, "/admin/subscriptions/active-1", testAdminKey, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Send now")
+}
+
+// TestDashboard_ActionsRequireCSRF verifies that dashboard actions need the CSRF token
+// and the admin role, and redirect back to the detail page on success.
+func TestDashboard_ActionsRequireCSRF(t *testing.T) {
+	router, h := setupDashboardRouter(t)
+	admin := config.AdminKey{Name: "alice", Role: config.RoleAdmin, Key: testAdminKey}
+	viewer := config.AdminKey{Name: "support", Role: config.RoleViewer, Key: testViewerKey}
+
+	w := dashboardRequest(router, http.MethodPost, "/admin/subscriptions/active-1/unsubscribe", testAdminKey, url.Values{})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = dashboardRequest(router, http.MethodPost, "/admin/subscriptions/active-1/unsubscribe", testViewerKey,
+		url.Values{"csrf_token": {csrfToken(viewer)}})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = dashboardRequest(router, http.MethodPost, "/admin/subscriptions/active-1/unsubscribe", testAdminKey,
+		url.Values{"csrf_token": {csrfToken(admin)}})
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Location"), "/admin/subscriptions/active-1?msg="))
+
+	var sub model.Subscription
+	require.NoError(t, h.db.First(&sub, "id = ?", "active-1").Error)
+	assert.Equal(t, model.StatusUnsubscribed, sub.Status)
+}
+
+// TestDashboard_SendNow verifies that force-send delivers immediately and is
+// recorded in the delivery history and audit log.
+func TestDashboard_SendNow(t *testing.T) {
+	router, h := setupDashboardRouter(t)
+	admin := config.AdminKey{Name: "alice", Role: config.RoleAdmin, Key: testAdminKey}
+
+	w := dashboardRequest(router, http.MethodPost, "/admin/subscriptions/active-2/send-now", testAdminKey,
+		url.Values{"csrf_token": {csrfToken(admin)}})
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Contains(t, w.Header().Get("Location"), "msg=Update+sent")
+
+	var deliveries []model.Delivery
+	require.NoError(t, h.db.Where("subscription_id = ?", "active-2").Find(&deliveries).Error)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, model.DeliverySent, deliveries[0].Status)
+
+	var audits int64
+	require.NoError(t, h.db.Model(&model.AuditLog{}).Where("action = ? AND target_id = ?", "subscription.send_now", "active-2").Count(&audits).Error)
+	assert.Equal(t, int64(1), audits)
+}
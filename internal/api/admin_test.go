@@ -0,0 +1,222 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAdminKey  = "admin-secret"
+	testViewerKey = "viewer-secret"
+)
+
+// setupAdminRouter creates a test router with two admin API keys configured
+// (one admin, one viewer) and a few subscriptions in different states.
+func setupAdminRouter(t *testing.T) (*gin.Engine, *Handler) {
+	router, h := setupTestRouterWithDB(t)
+	withConfig(t, func(c *config.Config) {
+		c.AdminKeys = []config.AdminKey{
+			{Name: "alice", Role: config.RoleAdmin, Key: testAdminKey},
+			{Name: "support", Role: config.RoleViewer, Key: testViewerKey},
+		}
+	})
+
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for _, sub := range []model.Subscription{
+		{ID: "pending-1", Email: "pending@example.com", City: "Kyiv", Frequency: "daily", CreatedAt: base},
+		{ID: "active-1", Email: "active@example.com", City: "Kyiv", Frequency: "hourly", Status: model.StatusActive, CreatedAt: base.AddDate(0, 0, 1)},
+		{ID: "active-2", Email: "paris@example.com", City: "Paris", Frequency: "daily", Status: model.StatusActive, CreatedAt: base.AddDate(0, 0, 2)},
+		{ID: "gone-1", Email: "gone@example.com", City: "Kyiv", Frequency: "daily", Status: model.StatusUnsubscribed, CreatedAt: base.AddDate(0, 0, 3)},
+	} {
+		sub.Token = "token-" + sub.ID
+		require.NoError(t, h.db.Create(&sub).Error)
+	}
+
+	return router, h
+}
+
+// adminRequest performs an admin API request authenticated with the given bearer key.
+func adminRequest(router *gin.Engine, method, target, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// adminPage is the decoded paginated listing response.
+type adminPage struct {
+	Items []struct {
+		ID     string `json:"id"`
+		Email  string `json:"email"`
+		Status string `json:"status"`
+	} `json:"items"`
+	Total int `json:"total"`
+}
+
+// TestAdminAPI_RequiresAuth verifies that requests without a valid key are rejected
+// and that the old unauthenticated /subscriptions dump is gone.
+func TestAdminAPI_RequiresAuth(t *testing.T) {
+	router, _ := setupAdminRouter(t)
+
+	assert.Equal(t, http.StatusUnauthorized, adminRequest(router, http.MethodGet, "/admin/api/subscriptions", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, adminRequest(router, http.MethodGet, "/admin/api/subscriptions", "wrong").Code)
+	assert.Equal(t, http.StatusNotFound, adminRequest(router, http.MethodGet, "/subscriptions", "").Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/api/subscriptions", nil)
+	req.Header.Set("X-API-Key", testViewerKey)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestAdminAPI_ViewerCannotModify verifies role enforcement for mutating endpoints.
+func TestAdminAPI_ViewerCannotModify(t *testing.T) {
+	router, h := setupAdminRouter(t)
+
+	w := adminRequest(router, http.MethodPost, "/admin/api/subscriptions/pending-1/confirm", testViewerKey)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var sub model.Subscription
+	require.NoError(t, h.db.First(&sub, "id = ?", "pending-1").Error)
+	assert.Equal(t, model.StatusPending, sub.Status)
+}
+
+// TestAdminAPI_ListFiltersAndPagination verifies filtering by city, status,
+// frequency and creation range, and that results are paginated.
+func TestAdminAPI_ListFiltersAndPagination(t *testing.T) {
+	router, _ := setupAdminRouter(t)
+
+	cases := []struct {
+		query string
+		ids   []string
+	}{
+		{"city=kyiv&status=active", []string{"active-1"}},
+		{"status=pending", []string{"pending-1"}},
+		{"status=unsubscribed", []string{"gone-1"}},
+		{"frequency=daily&created_from=2026-10-02&created_to=2026-10-03", []string{"active-2"}},
+	}
+	for _, tc := range cases {
+		w := adminRequest(router, http.MethodGet, "/admin/api/subscriptions?"+tc.query, testViewerKey)
+		require.Equal(t, http.StatusOK, w.Code, tc.query)
+
+		var page adminPage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		var ids []string
+		for _, item := range page.Items {
+			ids = append(ids, item.ID)
+		}
+		assert.Equal(t, tc.ids, ids, tc.query)
+	}
+
+	w := adminRequest(router, http.MethodGet, "/admin/api/subscriptions?page=2&page_size=3", testViewerKey)
+	require.Equal(t, http.StatusOK, w.Code)
+	var page adminPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, 4, page.Total)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "pending-1", page.Items[0].ID, "oldest subscription is on the last page")
+
+	assert.Equal(t, http.StatusBadRequest, adminRequest(router, http.MethodGet, "/admin/api/subscriptions?status=weird", testViewerKey).Code)
+}
+
+// TestAdminAPI_LookupByEmail verifies lookup by email and the 404 for unknown addresses.
+func TestAdminAPI_LookupByEmail(t *testing.T) {
+	router, _ := setupAdminRouter(t)
+
+	w := adminRequest(router, http.MethodGet, "/admin/api/subscriptions/by-email/active@example.com", testViewerKey)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"active"`)
+	assert.NotContains(t, w.Body.String(), "token-active-1", "token must never be exposed")
+
+	w = adminRequest(router, http.MethodGet, "/admin/api/subscriptions/by-email/nobody@example.com", testViewerKey)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestAdminAPI_ActionsAreAudited verifies manual confirm, unsubscribe and delete,
+// and that each action is recorded in the audit log with the acting key's name.
+func TestAdminAPI_ActionsAreAudited(t *testing.T) {
+	router, h := setupAdminRouter(t)
+
+	w := adminRequest(router, http.MethodPost, "/admin/api/subscriptions/pending-1/confirm", testAdminKey)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"active"`)
+
+	w = adminRequest(router, http.MethodPost, "/admin/api/subscriptions/active-1/unsubscribe", testAdminKey)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"unsubscribed"`)
+
+	w = adminRequest(router, http.MethodDelete, "/admin/api/subscriptions/gone-1", testAdminKey)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var count int64
+	require.NoError(t, h.db.Model(&model.Subscription{}).Where("id = ?", "gone-1").Count(&count).Error)
+	assert.Zero(t, count)
+
+	w = adminRequest(router, http.MethodPost, "/admin/api/subscriptions/missing/confirm", testAdminKey)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var entries []model.AuditLog
+	require.NoError(t, h.db.Order("created_at").Find(&entries).Error)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "subscription.confirm", entries[0].Action)
+	assert.Equal(t, "pending-1", entries[0].TargetID)
+	assert.Equal(t, "alice", entries[0].Actor)
+	assert.Equal(t, "subscription.unsubscribe", entries[1].Action)
+	assert.Equal(t, "subscription.delete", entries[2].Action)
+}
+
+// TestAdminAPI_StatusHistory verifies that admin status changes are validated by the
+// state machine and show up in the subscription's status history.
+func TestAdminAPI_StatusHistory(t *testing.T) {
+	router, _ := setupAdminRouter(t)
+
+	w := adminRequest(router, http.MethodPost, "/admin/api/subscriptions/gone-1/confirm", testAdminKey)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "Cannot confirm a subscription that is unsubscribed")
+
+	w = adminRequest(router, http.MethodPost, "/admin/api/subscriptions/gone-1/unsubscribe", testAdminKey)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = adminRequest(router, http.MethodPost, "/admin/api/subscriptions/pending-1/confirm", testAdminKey)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = adminRequest(router, http.MethodGet, "/admin/api/subscriptions/pending-1/events", testViewerKey)
+	require.Equal(t, http.StatusOK, w.Code)
+	var history struct {
+		Items []model.SubscriptionEvent `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	require.Len(t, history.Items, 1)
+	assert.Equal(t, model.StatusPending, history.Items[0].FromStatus)
+	assert.Equal(t, model.StatusActive, history.Items[0].ToStatus)
+	assert.Equal(t, "admin:alice", history.Items[0].Actor)
+	assert.Equal(t, "confirmed by admin", history.Items[0].Reason)
+}
+
+// TestAdminAPI_ResendConfirmation verifies that only pending subscriptions can be re-sent.
+func TestAdminAPI_ResendConfirmation(t *testing.T) {
+	router, h := setupAdminRouter(t)
+
+	w := adminRequest(router, http.MethodPost, "/admin/api/subscriptions/active-1/resend-confirmation", testAdminKey)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = adminRequest(router, http.MethodPost, "/admin/api/subscriptions/pending-1/resend-confirmation", testAdminKey)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var sub model.Subscription
+	require.NoError(t, h.db.First(&sub, "id = ?", "pending-1").Error)
+	assert.NotNil(t, sub.ConfirmationSentAt)
+}
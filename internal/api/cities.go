@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// minCitySearchLength is the shortest query forwarded to the provider.
+// Shorter inputs match too many places to be useful for autocomplete.
+const minCitySearchLength = 2
+
+// searchCitiesHandler returns city candidates matching the "q" query parameter.
+// Used by the subscribe form for autocomplete, so users can pick a disambiguated
+// location (name, region, country, coordinates) instead of typing free text.
+func (h *Handler) searchCitiesHandler(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query is required"})
+		return
+	}
+
+	if len([]rune(query)) < minCitySearchLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query is too short"})
+		return
+	}
+
+	cities, err := h.searchCities(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to search cities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, cities)
+}
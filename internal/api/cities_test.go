@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"weatherApi/internal/model"
+	"weatherApi/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupTestRouterForCities creates a Gin router with only the city search endpoint
+// and injects the given search function in place of the provider call.
+func setupTestRouterForCities(search func(query string) ([]model.City, error)) *gin.Engine {
+	h := &Handler{
+		searchCities: func(_ context.Context, query string) ([]model.City, error) {
+			return search(query)
+		},
+	}
+
+	router := gin.Default()
+	router.GET("/api/cities/search", h.searchCitiesHandler)
+	return router
+}
+
+// TestSearchCities_Success verifies that matching candidates are returned
+// with name, region, country and coordinates.
+func TestSearchCities_Success(t *testing.T) {
+	t.Parallel()
+	router := setupTestRouterForCities(func(query string) ([]model.City, error) {
+		assert.Equal(t, "Paris", query)
+		return []model.City{
+			{ID: 1, Name: "Paris", Region: "Ile-de-France", Country: "France", Lat: 48.87, Lon: 2.33},
+			{ID: 2, Name: "Paris", Region: "Texas", Country: "United States of America", Lat: 33.66, Lon: -95.56},
+		}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/cities/search?q=Paris", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[
+		{"id":1,"name":"Paris","region":"Ile-de-France","country":"France","lat":48.87,"lon":2.33},
+		{"id":2,"name":"Paris","region":"Texas","country":"United States of America","lat":33.66,"lon":-95.56}
+	]`, w.Body.String())
+}
+
+// TestSearchCities_NoMatches verifies that an empty JSON array is returned when nothing matches.
+func TestSearchCities_NoMatches(t *testing.T) {
+	t.Parallel()
+	router := setupTestRouterForCities(func(query string) ([]model.City, error) {
+		return []model.City{}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/cities/search?q=Nowhere", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+}
+
+// TestSearchCities_MissingQuery verifies that the endpoint returns HTTP 400
+// when the "q" parameter is missing or too short.
+func TestSearchCities_MissingQuery(t *testing.T) {
+	t.Parallel()
+	router := setupTestRouterForCities(func(query string) ([]model.City, error) {
+		t.Fatal("provider must not be called for invalid queries")
+		return nil, nil
+	})
+
+	for _, target := range []string{"/api/cities/search", "/api/cities/search?q=+", "/api/cities/search?q=K"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
+
+// TestSearchCities_ProviderError verifies that provider failures are reported as HTTP 502.
+func TestSearchCities_ProviderError(t *testing.T) {
+	t.Parallel()
+	router := setupTestRouterForCities(func(query string) ([]model.City, error) {
+		return nil, errors.New("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/cities/search?q=Kyiv", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.JSONEq(t, `{"error":"Failed to search cities"}`, w.Body.String())
+}
+
+// TestSearchCities_RateLimitPerIP verifies that one client IP is throttled with HTTP 429
+// once its search budget is used up, so it cannot run up provider costs.
+func TestSearchCities_RateLimitPerIP(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	h.limits.CitySearchPerIP = ratelimit.PerHour(2)
+	calls := 0
+	h.searchCities = func(context.Context, string) ([]model.City, error) {
+		calls++
+		return []model.City{}, nil
+	}
+
+	search := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/cities/search?q=Kyiv", nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusOK, search("198.51.100.1").Code)
+	assert.Equal(t, http.StatusOK, search("198.51.100.1").Code)
+
+	w := search("198.51.100.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, 2, calls, "throttled requests don't reach the provider")
+
+	assert.Equal(t, http.StatusOK, search("198.51.100.2").Code)
+}
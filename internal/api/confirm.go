@@ -1,43 +1,83 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
-	"weatherApi/pkg/scheduler"
 
 	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
 	"weatherApi/pkg/jwtutil"
 
 	"github.com/gin-gonic/gin"
 )
 
-// confirmHandler validates the token and marks the subscription as confirmed
-func confirmHandler(c *gin.Context) {
-	token := c.Param("token")
-
-	email, err := jwtutil.Parse(token)
+// confirmHandler validates the token and moves the pending subscription to active.
+// The first forecast is queued in the same transaction and sent by the scheduler's
+// outbox worker, so provider or email failures don't affect the confirmation.
+// Browsers get an HTML page; clients that prefer application/json get JSON.
+func (h *Handler) confirmHandler(c *gin.Context) {
+	email, err := jwtutil.Parse(c.Param("token"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token"})
+		respondConfirm(c, http.StatusBadRequest, "Invalid token")
 		return
 	}
 
-	var sub model.Subscription
-	if err := DB.Where("email = ?", email).First(&sub).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found / Subscription not found"})
-		return
-	}
+	ctx := c.Request.Context()
 
-	if sub.IsConfirmed {
-		c.JSON(http.StatusOK, gin.H{"message": "Subscription already confirmed"})
-		return
+	alreadyConfirmed := false
+	err = h.subs.Transaction(ctx, func(ctx context.Context) error {
+		// Locked, so concurrent clicks on the link queue a single welcome forecast
+		sub, err := h.subs.GetByEmailForUpdate(ctx, email)
+		if err != nil {
+			return err
+		}
+		if sub.Status == model.StatusActive || sub.Status == model.StatusPaused {
+			alreadyConfirmed = true
+			return nil
+		}
+		if err := h.subs.Transition(ctx, sub.ID, model.StatusActive, repository.StatusChange{
+			Actor: model.ActorSubscriber, Reason: "confirmed via email link",
+		}); err != nil {
+			return err
+		}
+		return h.scheduler.EnqueueWelcome(ctx, sub.ID)
+	})
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondConfirm(c, http.StatusNotFound, "Token not found / Subscription not found")
+	case errors.Is(err, model.ErrInvalidTransition):
+		// Unsubscribed, bounced or expired: an old link must not reactivate the subscription
+		respondConfirm(c, http.StatusConflict, "Subscription can no longer be confirmed, please subscribe again")
+	case err != nil:
+		slog.ErrorContext(ctx, "failed to confirm subscription", "error", err)
+		respondConfirm(c, http.StatusInternalServerError, "Failed to confirm subscription")
+	case alreadyConfirmed:
+		respondConfirm(c, http.StatusOK, "Subscription already confirmed")
+	default:
+		respondConfirm(c, http.StatusOK, "Subscription confirmed successfully")
 	}
+}
 
-	sub.IsConfirmed = true
-	DB.Save(&sub)
+// respondConfirm renders the confirmation page, or JSON via negotiate.
+func respondConfirm(c *gin.Context, status int, text string) {
+	negotiate(c, status, text, func() {
+		c.HTML(status, "confirm.html", gin.H{"Success": status < http.StatusBadRequest, "Message": text})
+	})
+}
 
-	if err := scheduler.ProcessSubscription(sub); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send weather forecast email"})
+// negotiate answers with JSON ({"message": ...} on success, {"error": ...} otherwise)
+// when the client asks for it via the Accept header, and calls render for browsers.
+func negotiate(c *gin.Context, status int, text string, render func()) {
+	if c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+		key := "message"
+		if status >= http.StatusBadRequest {
+			key = "error"
+		}
+		c.JSON(status, gin.H{key: text})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Subscription confirmed successfully"})
+	render()
 }
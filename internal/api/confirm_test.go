@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -10,47 +12,41 @@ import (
 	"time"
 	"weatherApi/internal/model"
 	"weatherApi/pkg/jwtutil"
-	"weatherApi/pkg/scheduler"
+	"weatherApi/pkg/notify"
 )
 
-func init() {
-	scheduler.FetchWeather = func(city string) (*model.Weather, int, error) {
-		return &model.Weather{
-			Temperature: 22.5,
-			Humidity:    60,
-			Description: "Clear skies",
-		}, 200, nil
-	}
-
-	scheduler.SendWeatherEmail = func(to string, weather *model.Weather, city string, token string) error {
-		return nil // simulate success
-	}
+// noopSender accepts every notification without delivering it.
+type noopSender struct{}
+
+func (noopSender) Send(ctx context.Context, sub model.Subscription, n notify.Notification) error {
+	return nil
 }
 
 // TestConfirmHandler_Success verifies that confirming a valid, unconfirmed subscription:
 // - Returns HTTP 200 with success message
-// - Sets IsConfirmed=true in the database
+// - Moves the subscription to active in the database
 // - Does not modify other subscription fields
 func TestConfirmHandler_Success(t *testing.T) {
-	router := setupTestRouterWithDB(t)
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
 
 	email := "confirmtest@example.com"
 	token, err := jwtutil.Generate(email)
 	require.NoError(t, err)
 
-	err = DB.Create(&model.Subscription{
-		ID:             "test-id",
-		Email:          email,
-		City:           "Kyiv",
-		Frequency:      "daily",
-		IsConfirmed:    false,
-		IsUnsubscribed: false,
-		Token:          token,
-		CreatedAt:      time.Now(),
+	err = h.db.Create(&model.Subscription{
+		ID:        "test-id",
+		Email:     email,
+		City:      "Kyiv",
+		Frequency: "daily",
+		Status:    model.StatusPending,
+		Token:     token,
+		CreatedAt: time.Now(),
 	}).Error
 	require.NoError(t, err)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/confirm/"+token, nil)
+	req.Header.Set("Accept", "application/json")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -58,9 +54,15 @@ func TestConfirmHandler_Success(t *testing.T) {
 	assert.JSONEq(t, `{"message":"Subscription confirmed successfully"}`, w.Body.String())
 
 	var sub model.Subscription
-	err = DB.Where("email = ?", email).First(&sub).Error
+	err = h.db.Where("email = ?", email).First(&sub).Error
 	require.NoError(t, err)
-	assert.True(t, sub.IsConfirmed)
+	assert.Equal(t, model.StatusActive, sub.Status)
+
+	var jobs []model.OutboxJob
+	require.NoError(t, h.db.Find(&jobs).Error)
+	require.Len(t, jobs, 1, "welcome forecast queued")
+	assert.Equal(t, model.JobWelcome, jobs[0].Kind)
+	assert.Equal(t, "test-id", jobs[0].SubscriptionID)
 }
 
 // TestConfirmHandler_InvalidToken verifies that the confirmation endpoint:
@@ -68,11 +70,13 @@ func TestConfirmHandler_Success(t *testing.T) {
 // - Returns HTTP 400 Bad Request
 // - Includes "Invalid token" in the error message
 func TestConfirmHandler_InvalidToken(t *testing.T) {
-	router := setupTestRouterWithDB(t)
+	t.Parallel()
+	router, _ := setupTestRouterWithDB(t)
 
 	invalidToken := "not-a-valid-jwt"
 
 	req := httptest.NewRequest(http.MethodGet, "/api/confirm/"+invalidToken, nil)
+	req.Header.Set("Accept", "application/json")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -84,12 +88,14 @@ func TestConfirmHandler_InvalidToken(t *testing.T) {
 // - Returns HTTP 404 when token is valid but no matching subscription exists
 // - This prevents enumeration of emails via the confirmation endpoint
 func TestConfirmHandler_TokenButNoSubscription(t *testing.T) {
-	router := setupTestRouterWithDB(t)
+	t.Parallel()
+	router, _ := setupTestRouterWithDB(t)
 
 	token, err := jwtutil.Generate("ghost@example.com")
 	require.NoError(t, err)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/confirm/"+token, nil)
+	req.Header.Set("Accept", "application/json")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -103,28 +109,119 @@ func TestConfirmHandler_TokenButNoSubscription(t *testing.T) {
 // - Returns appropriate message indicating subscription was already confirmed
 // - Does not modify the existing confirmed subscription
 func TestConfirmHandler_AlreadyConfirmed(t *testing.T) {
-	router := setupTestRouterWithDB(t)
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
 
 	email := "already@confirmed.com"
 	token, err := jwtutil.Generate(email)
 	require.NoError(t, err)
 
-	err = DB.Create(&model.Subscription{
-		ID:             uuid.New().String(),
-		Email:          email,
-		City:           "Kyiv",
-		Frequency:      "daily",
-		IsConfirmed:    true,
-		IsUnsubscribed: false,
-		Token:          token,
-		CreatedAt:      time.Now(),
+	err = h.db.Create(&model.Subscription{
+		ID:        uuid.New().String(),
+		Email:     email,
+		City:      "Kyiv",
+		Frequency: "daily",
+		Status:    model.StatusActive,
+		Token:     token,
+		CreatedAt: time.Now(),
 	}).Error
 	require.NoError(t, err)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/confirm/"+token, nil)
+	req.Header.Set("Accept", "application/json")
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "already confirmed")
 }
+
+// TestConfirmHandler_HTMLPage verifies that browsers clicking the email link
+// get the confirmation page instead of JSON.
+func TestConfirmHandler_HTMLPage(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+
+	email := "browser@example.com"
+	token, err := jwtutil.Generate(email)
+	require.NoError(t, err)
+	require.NoError(t, h.db.Create(&model.Subscription{
+		ID: "browser-1", Email: email, City: "Kyiv", Frequency: "daily", Token: token, CreatedAt: time.Now(),
+	}).Error)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/confirm/"+token, nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "Subscription confirmed successfully")
+
+	req = httptest.NewRequest(http.MethodGet, "/api/confirm/not-a-valid-jwt", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "Invalid token")
+}
+
+// TestConfirmHandler_DeliveryFailureDoesNotFail verifies that a broken notifier
+// cannot fail the confirmation, since the first forecast is only queued.
+func TestConfirmHandler_DeliveryFailureDoesNotFail(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	h.scheduler.Notifier = failingSender{}
+
+	email := "flaky@example.com"
+	token, err := jwtutil.Generate(email)
+	require.NoError(t, err)
+	require.NoError(t, h.db.Create(&model.Subscription{
+		ID: "flaky-1", Email: email, City: "Kyiv", Frequency: "daily", Token: token, CreatedAt: time.Now(),
+	}).Error)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/confirm/"+token, nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message":"Subscription confirmed successfully"}`, w.Body.String())
+}
+
+// failingSender rejects every notification like an unavailable email provider.
+type failingSender struct{}
+
+func (failingSender) Send(ctx context.Context, sub model.Subscription, n notify.Notification) error {
+	return errors.New("sendgrid: 503 Service Unavailable")
+}
+
+// TestConfirmHandler_UnsubscribedIsConflict verifies that an old confirmation link
+// doesn't reactivate a subscription that was unsubscribed in the meantime.
+func TestConfirmHandler_UnsubscribedIsConflict(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+
+	email := "left@example.com"
+	token, err := jwtutil.Generate(email)
+	require.NoError(t, err)
+	require.NoError(t, h.db.Create(&model.Subscription{
+		ID: "sub-1", Email: email, City: "Kyiv", Frequency: "daily",
+		Status: model.StatusUnsubscribed, Token: token, CreatedAt: time.Now(),
+	}).Error)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/confirm/"+token, nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var sub model.Subscription
+	require.NoError(t, h.db.First(&sub, "id = ?", "sub-1").Error)
+	assert.Equal(t, model.StatusUnsubscribed, sub.Status)
+	var jobs int64
+	require.NoError(t, h.db.Model(&model.OutboxJob{}).Count(&jobs).Error)
+	assert.Zero(t, jobs)
+}
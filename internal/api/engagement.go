@@ -0,0 +1,103 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+	"weatherApi/pkg/engagement"
+	"weatherApi/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// engagementDefaultDays is the period of the admin engagement score without ?days.
+const engagementDefaultDays = 30
+
+// trackingPixel is a transparent 1x1 GIF.
+var trackingPixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// trackOpenHandler serves the open pixel of tracked emails and records the open.
+// The pixel is served even for invalid signatures, so mail clients never show a broken image.
+func (h *Handler) trackOpenHandler(c *gin.Context) {
+	deliveryID := c.Param("id")
+	if engagement.Valid(deliveryID, "", c.Query("sig")) {
+		h.recordEngagement(c, deliveryID, model.EngagementOpen, "")
+	}
+
+	c.Header("Cache-Control", "no-store, max-age=0")
+	c.Data(http.StatusOK, "image/gif", trackingPixel)
+}
+
+// trackClickHandler records a click of a tracked link and redirects to its target.
+// Only targets signed for the delivery are followed, so it is not an open redirect.
+func (h *Handler) trackClickHandler(c *gin.Context) {
+	deliveryID, target := c.Param("id"), c.Query("url")
+	if !engagement.Valid(deliveryID, target, c.Query("sig")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link"})
+		return
+	}
+	if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link"})
+		return
+	}
+
+	h.recordEngagement(c, deliveryID, model.EngagementClick, target)
+	c.Redirect(http.StatusFound, target)
+}
+
+// recordEngagement stores an open or click while tracking is enabled. Failures are
+// only logged; the subscriber still gets the pixel or the redirect.
+func (h *Handler) recordEngagement(c *gin.Context, deliveryID, kind, target string) {
+	if !config.C.EngagementTracking {
+		return
+	}
+	ctx := c.Request.Context()
+	recorded, err := h.engagement.Record(ctx, deliveryID, kind, target, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to record engagement", "delivery_id", deliveryID, "kind", kind, "error", err)
+		return
+	}
+	if recorded {
+		metrics.EngagementEvents.WithLabelValues(kind).Inc()
+	}
+}
+
+// adminEngagementHandler returns the engagement score of a subscription over the
+// tracked emails of the last ?days (default 30).
+func (h *Handler) adminEngagementHandler(c *gin.Context) {
+	days := engagementDefaultDays
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 365 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+			return
+		}
+		days = n
+	}
+
+	sub, ok := h.loadAdminSubscription(c)
+	if !ok {
+		return
+	}
+
+	score, err := h.engagement.Score(c.Request.Context(), sub.ID, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve engagement"})
+		return
+	}
+
+	if !h.recordAudit(c, "subscription.view_engagement", sub.ID, "") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"days": days, "engagement": score})
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/gif"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+	"weatherApi/pkg/engagement"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTrackedDelivery stores a tracked delivery to subscription "active-1".
+func createTrackedDelivery(t *testing.T, h *Handler, id string) {
+	require.NoError(t, h.db.Create(&model.Delivery{
+		ID: id, SubscriptionID: "active-1", Kind: "weather.update", Status: model.DeliverySent, Tracked: true, CreatedAt: time.Now(),
+	}).Error)
+}
+
+// get performs a plain GET request.
+func get(router *gin.Engine, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+// engagementCount returns the number of recorded engagement events of the given kind.
+func engagementCount(t *testing.T, h *Handler, kind string) int64 {
+	var count int64
+	require.NoError(t, h.db.Model(&model.EngagementEvent{}).Where("kind = ?", kind).Count(&count).Error)
+	return count
+}
+
+// TestTracking_OpenAndClick verifies that signed pixels and links are recorded, that a
+// click redirects only to the signed target, and that nothing is recorded while
+// tracking is disabled.
+func TestTracking_OpenAndClick(t *testing.T) {
+	router, h := setupAdminRouter(t)
+	withConfig(t, func(c *config.Config) { c.EngagementTracking = true })
+	createTrackedDelivery(t, h, "d-1")
+	target := "https://weather.example.com/pause/token"
+	clickPath := "/track/click/d-1?url=" + url.QueryEscape(target) + "&sig=" + engagement.Sign("d-1", target)
+
+	w := get(router, "/track/open/d-1?sig="+engagement.Sign("d-1", ""))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/gif", w.Header().Get("Content-Type"))
+	_, err := gif.Decode(bytes.NewReader(w.Body.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, get(router, "/track/open/d-1?sig=forged").Code, "forged pixels still load")
+	assert.EqualValues(t, 1, engagementCount(t, h, model.EngagementOpen))
+
+	w = get(router, clickPath)
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, target, w.Header().Get("Location"))
+	assert.EqualValues(t, 1, engagementCount(t, h, model.EngagementClick))
+
+	evil := "/track/click/d-1?url=" + url.QueryEscape("https://evil.example.com") + "&sig=" + engagement.Sign("d-1", target)
+	assert.Equal(t, http.StatusBadRequest, get(router, evil).Code)
+
+	withConfig(t, func(c *config.Config) { c.EngagementTracking = false })
+	assert.Equal(t, http.StatusFound, get(router, clickPath).Code, "old links keep working")
+	assert.EqualValues(t, 1, engagementCount(t, h, model.EngagementClick))
+}
+
+// TestAdminAPI_Engagement verifies the per-subscriber engagement score.
+func TestAdminAPI_Engagement(t *testing.T) {
+	router, h := setupAdminRouter(t)
+	createTrackedDelivery(t, h, "d-1")
+	createTrackedDelivery(t, h, "d-2")
+	_, err := h.engagement.Record(t.Context(), "d-1", model.EngagementOpen, "", time.Now())
+	require.NoError(t, err)
+
+	w := adminRequest(router, http.MethodGet, "/admin/api/subscriptions/active-1/engagement", testViewerKey)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Days       int                   `json:"days"`
+		Engagement model.EngagementScore `json:"engagement"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 30, resp.Days)
+	assert.Equal(t, 2, resp.Engagement.Tracked)
+	assert.Equal(t, 1, resp.Engagement.Opened)
+	assert.InDelta(t, 0.5, resp.Engagement.Score, 0.001)
+
+	assert.Equal(t, http.StatusBadRequest, adminRequest(router, http.MethodGet, "/admin/api/subscriptions/active-1/engagement?days=0", testViewerKey).Code)
+	assert.Equal(t, http.StatusNotFound, adminRequest(router, http.MethodGet, "/admin/api/subscriptions/missing/engagement", testViewerKey).Code)
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+	emailutil "weatherApi/pkg/email"
+	"weatherApi/pkg/ratelimit"
+	"weatherApi/pkg/scheduler"
+	"weatherApi/pkg/weatherapi"
+
+	"gorm.io/gorm"
+)
+
+// Handler serves the HTTP API. All dependencies are held per instance,
+// so tests can build isolated handlers and run them in parallel.
+type Handler struct {
+	subs         repository.SubscriptionRepository
+	suppressions *repository.Suppressions
+	engagement   *repository.Engagement
+	db           *gorm.DB // Locations, audit log, delivery history and scheduler runs
+	scheduler    *scheduler.Scheduler
+	limiter      ratelimit.Store
+	limits       Limits
+	webhookKey   *ecdsa.PublicKey // Verifies SendGrid event webhooks; nil disables them
+
+	// Weather provider calls; replaced by fakes in tests
+	resolveLocation func(ctx context.Context, query string) (*model.Location, error)
+	searchCities    func(ctx context.Context, query string) ([]model.City, error)
+	fetchWeather    func(ctx context.Context, city string) (*model.Weather, int, error)
+	providerPing    func(ctx context.Context) error
+
+	// Transactional emails sent by handlers; replaced by fakes in tests
+	sendConfirmation func(ctx context.Context, toEmail, token string) error
+	sendPrivacyLink  func(ctx context.Context, toEmail, token string, ttl time.Duration) error
+
+	schedulerTicks func() map[string]time.Time
+	providerCheck  *cachedCheck
+}
+
+// Limits are the abuse limits of the public endpoints.
+type Limits struct {
+	SubscribePerIP    ratelimit.Limit // /api/subscribe and privacy link requests per client IP
+	SubscribePerEmail ratelimit.Limit // Subscribe and privacy link requests per target address
+	CitySearchPerIP   ratelimit.Limit // /api/cities/search requests per client IP, each a paid provider call
+	ResendCooldown    time.Duration   // Minimum time between confirmation emails for one subscription
+}
+
+// LimitsFromConfig reads the limits from config.C.
+func LimitsFromConfig() Limits {
+	return Limits{
+		SubscribePerIP:    ratelimit.PerHour(config.C.SubscribeLimitPerIP),
+		SubscribePerEmail: ratelimit.PerHour(config.C.SubscribeLimitPerEmail),
+		CitySearchPerIP:   ratelimit.PerHour(config.C.CitySearchLimitPerIP),
+		ResendCooldown:    config.C.ConfirmationResendCooldown,
+	}
+}
+
+// NewHandler returns a handler storing subscriptions in subs and other records in db.
+// Confirmations and admin "send now" deliver through sched. Limits are read from
+// config.C, and rate limits are kept in memory until SetRateLimitStore is called.
+// Emails are never sent to addresses on the suppression list in db.
+func NewHandler(subs repository.SubscriptionRepository, db *gorm.DB, sched *scheduler.Scheduler) *Handler {
+	suppressions := repository.NewSuppressions(db)
+	sendConfirmation := func(ctx context.Context, toEmail, token string) error {
+		if err := emailutil.CheckSuppression(ctx, suppressions.Blocked, toEmail); err != nil {
+			return err
+		}
+		return emailutil.SendConfirmationEmail(ctx, toEmail, token)
+	}
+	sendPrivacyLink := func(ctx context.Context, toEmail, token string, ttl time.Duration) error {
+		if err := emailutil.CheckSuppression(ctx, suppressions.Blocked, toEmail); err != nil {
+			return err
+		}
+		return emailutil.SendPrivacyLinkEmail(ctx, toEmail, token, ttl)
+	}
+
+	return &Handler{
+		subs:             subs,
+		suppressions:     suppressions,
+		engagement:       repository.NewEngagement(db),
+		db:               db,
+		scheduler:        sched,
+		limiter:          ratelimit.NewMemoryStore(),
+		limits:           LimitsFromConfig(),
+		resolveLocation:  weatherapi.ResolveLocation,
+		searchCities:     weatherapi.SearchCities,
+		fetchWeather:     weatherapi.FetchWithStatus,
+		providerPing:     weatherapi.Ping,
+		sendConfirmation: sendConfirmation,
+		sendPrivacyLink:  sendPrivacyLink,
+		schedulerTicks:   sched.LastTicks,
+		providerCheck:    &cachedCheck{ttl: providerCheckTTL},
+	}
+}
+
+// SetRateLimitStore replaces the rate limit store, e.g. to share limits across replicas.
+func (h *Handler) SetRateLimitStore(store ratelimit.Store) {
+	h.limiter = store
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/pkg/scheduler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Check outcomes reported by the probes. A probe is degraded when only
+// non-critical checks failed; it still answers 200.
+const (
+	checkOK       = "ok"
+	checkFail     = "fail"
+	checkDegraded = "degraded"
+)
+
+const (
+	// checkTimeout bounds each dependency check, so a hanging dependency fails the probe
+	// instead of timing out the load balancer request.
+	checkTimeout = 3 * time.Second
+
+	// providerCheckTTL is how long a provider ping result is reused,
+	// so frequent probes do not spend weather API quota.
+	providerCheckTTL = time.Minute
+)
+
+// checkResult is the outcome of one dependency check.
+type checkResult struct {
+	Status     string            `json:"status"`
+	Error      string            `json:"error,omitempty"`
+	DurationMs int64             `json:"duration_ms"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+// livenessHandler reports whether the process should be restarted: the HTTP server
+// answers, and the scheduler loops are still ticking.
+func (h *Handler) livenessHandler(c *gin.Context) {
+	respondHealth(c, map[string]checkResult{
+		"scheduler": h.checkScheduler(time.Now()),
+	}, "scheduler")
+}
+
+// readinessHandler reports whether the task should receive traffic. Only the database
+// is critical: the API cannot serve anything without it. The scheduler, the weather
+// provider (cached) and the mailer are reported as details, so an outage of one of
+// them does not take every task out of the load balancer.
+func (h *Handler) readinessHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), checkTimeout)
+	defer cancel()
+
+	respondHealth(c, map[string]checkResult{
+		"database":  timed(ctx, "database", func() error { return h.pingDatabase(ctx) }),
+		"scheduler": h.checkScheduler(time.Now()),
+		"provider":  timed(ctx, "provider", func() error { return h.providerCheck.run(ctx, h.providerPing) }),
+		"mailer":    timed(ctx, "mailer", checkMailer),
+	}, "database")
+}
+
+// respondHealth writes the per-check JSON. It answers 503 if a critical check failed,
+// and 200 otherwise, with status degraded when another check failed.
+func respondHealth(c *gin.Context, checks map[string]checkResult, critical ...string) {
+	status, code := checkOK, http.StatusOK
+	for _, r := range checks {
+		if r.Status != checkOK {
+			status = checkDegraded
+		}
+	}
+	for _, name := range critical {
+		if checks[name].Status != checkOK {
+			status, code = checkFail, http.StatusServiceUnavailable
+		}
+	}
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
+
+// timed runs fn and converts its error into a check result. Probes are public, so
+// the error is only logged and the result says which check failed.
+func timed(ctx context.Context, name string, fn func() error) checkResult {
+	start := time.Now()
+	err := fn()
+	r := checkResult{Status: checkOK, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		slog.WarnContext(ctx, "health check failed", "check", name, "error", err)
+		r.Status = checkFail
+		r.Error = name + " unavailable"
+	}
+	return r
+}
+
+// pingDatabase checks connectivity of the shared DB pool.
+func (h *Handler) pingDatabase(ctx context.Context) error {
+	if h.db == nil {
+		return errors.New("database not initialized")
+	}
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// checkScheduler verifies that every scheduler loop ticked within twice its interval.
+func (h *Handler) checkScheduler(now time.Time) checkResult {
+	intervals := map[string]time.Duration{
+		scheduler.LoopHourly:      time.Hour,
+		scheduler.LoopAlerts:      config.C.AlertPollInterval,
+		scheduler.LoopOutbox:      config.C.OutboxPollInterval,
+		scheduler.LoopMaintenance: config.C.MaintenanceInterval,
+	}
+	last := h.schedulerTicks()
+
+	r := checkResult{Status: checkOK, Details: map[string]string{}}
+	var problems []string
+	for loop, interval := range intervals {
+		t, ok := last[loop]
+		if !ok {
+			problems = append(problems, loop+" loop not started")
+			continue
+		}
+		r.Details[loop] = t.UTC().Format(time.RFC3339)
+		if age := now.Sub(t); age > 2*interval {
+			problems = append(problems, fmt.Sprintf("%s loop last ticked %s ago", loop, age.Round(time.Second)))
+		}
+	}
+	if len(problems) > 0 {
+		r.Status = checkFail
+		r.Error = strings.Join(problems, "; ")
+	}
+	return r
+}
+
+// checkMailer verifies that SendGrid credentials and the sender address are configured.
+func checkMailer() error {
+	if config.C.SendGridKey == "" {
+		return errors.New("SENDGRID_API_KEY not set")
+	}
+	if !strings.Contains(config.C.EmailFrom, "@") {
+		return errors.New("EMAIL_FROM is not a valid address")
+	}
+	return nil
+}
+
+// cachedCheck reuses the result of an expensive check for ttl.
+// Concurrent callers wait for a single in-flight check.
+type cachedCheck struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+func (c *cachedCheck) run(ctx context.Context, fn func(context.Context) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < c.ttl {
+		return c.err
+	}
+	c.err = fn(ctx)
+	c.checkedAt = time.Now()
+	return c.err
+}
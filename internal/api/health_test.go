@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/pkg/scheduler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// healthResponse mirrors the probe JSON.
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+// setupHealthRouter wires a test router with healthy fakes for the scheduler and provider.
+// The returned counter reports how often the provider was pinged.
+func setupHealthRouter(t *testing.T, pingErr error) (*gin.Engine, *Handler, *int) {
+	router, h := setupTestRouterWithDB(t)
+
+	pings := 0
+	h.providerPing = func(context.Context) error {
+		pings++
+		return pingErr
+	}
+	now := time.Now()
+	h.schedulerTicks = func() map[string]time.Time {
+		return map[string]time.Time{
+			scheduler.LoopHourly: now, scheduler.LoopAlerts: now, scheduler.LoopOutbox: now, scheduler.LoopMaintenance: now,
+		}
+	}
+	return router, h, &pings
+}
+
+func getHealth(t *testing.T, router *gin.Engine, path string) (int, healthResponse) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var resp healthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+// TestReadiness_AllHealthy verifies per-check JSON when every dependency is fine,
+// and that the provider ping is cached between probes.
+func TestReadiness_AllHealthy(t *testing.T) {
+	router, _, pings := setupHealthRouter(t, nil)
+
+	code, resp := getHealth(t, router, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, checkOK, resp.Status)
+	for _, name := range []string{"database", "scheduler", "provider", "mailer"} {
+		assert.Equal(t, checkOK, resp.Checks[name].Status, name)
+	}
+
+	getHealth(t, router, "/readyz")
+	assert.Equal(t, 1, *pings, "provider ping should be cached")
+}
+
+// TestReadiness_FailingDependencies verifies that provider and mailer failures only
+// degrade readiness without leaking their errors, and that a broken database returns 503.
+func TestReadiness_FailingDependencies(t *testing.T) {
+	router, h, _ := setupHealthRouter(t, errors.New("Get https://api.weatherapi.com/v1/search.json?key=secret: timeout"))
+	withConfig(t, func(c *config.Config) { c.SendGridKey = "" })
+
+	code, resp := getHealth(t, router, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, checkDegraded, resp.Status)
+	assert.Equal(t, checkOK, resp.Checks["database"].Status)
+	assert.Equal(t, checkFail, resp.Checks["provider"].Status)
+	assert.Equal(t, "provider unavailable", resp.Checks["provider"].Error)
+	assert.Equal(t, checkFail, resp.Checks["mailer"].Status)
+	assert.Equal(t, checkOK, resp.Checks["scheduler"].Status)
+
+	sqlDB, err := h.db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	code, resp = getHealth(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, checkFail, resp.Status)
+	assert.Equal(t, checkFail, resp.Checks["database"].Status)
+}
+
+// TestLiveness_Scheduler verifies that liveness fails when a scheduler loop stopped
+// ticking or never started.
+func TestLiveness_Scheduler(t *testing.T) {
+	router, h, _ := setupHealthRouter(t, nil)
+
+	code, resp := getHealth(t, router, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, checkOK, resp.Status)
+
+	h.schedulerTicks = func() map[string]time.Time {
+		return map[string]time.Time{scheduler.LoopHourly: time.Now().Add(-3 * time.Hour)}
+	}
+	code, resp = getHealth(t, router, "/healthz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, resp.Checks["scheduler"].Error, "hourly loop last ticked")
+	assert.Contains(t, resp.Checks["scheduler"].Error, "alerts loop not started")
+}
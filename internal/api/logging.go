@@ -0,0 +1,67 @@
+package api
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"weatherApi/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// HeaderRequestID carries the request ID in both directions, so callers and
+// load balancers can correlate their logs with ours.
+const HeaderRequestID = "X-Request-ID"
+
+// validRequestID limits accepted incoming IDs to a safe, short charset.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestIDMiddleware reuses a well-formed incoming X-Request-ID or generates one,
+// echoes it in the response and stores it in the request context for logging.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if !validRequestID.MatchString(id) {
+			id = uuid.New().String()
+		}
+
+		c.Header(HeaderRequestID, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// requestLogger writes one structured access log line per request.
+// The route template is logged instead of the raw path, so tokens in
+// confirm/unsubscribe URLs and email addresses in admin lookups stay out of logs.
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		level := slog.LevelInfo
+		switch status := c.Writer.Status(); {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		case probeRoutes[route]:
+			level = slog.LevelDebug
+		}
+
+		slog.Log(c.Request.Context(), level, "http request",
+			"method", c.Request.Method,
+			"route", route,
+			"status", c.Writer.Status(),
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"weatherApi/pkg/logging"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// captureLogs routes the default slog logger into a buffer for the duration of the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(logging.New(&buf, "debug"))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+// TestRequestID_GeneratedAndEchoed verifies that every response carries a request ID
+// that also appears in the access log, and that tokens in the path are not logged.
+func TestRequestID_GeneratedAndEchoed(t *testing.T) {
+	router, _ := setupTestRouterWithDB(t)
+	logs := captureLogs(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/confirm/secret-token", nil))
+
+	id := w.Header().Get(HeaderRequestID)
+	assert.NotEmpty(t, id)
+	assert.Contains(t, logs.String(), `"request_id":"`+id+`"`)
+	assert.Contains(t, logs.String(), `"route":"/api/confirm/:token"`)
+	assert.NotContains(t, logs.String(), "secret-token")
+}
+
+// TestRequestID_IncomingReused verifies that a well-formed incoming ID is kept
+// and a malformed one is replaced.
+func TestRequestID_IncomingReused(t *testing.T) {
+	router, _ := setupTestRouterWithDB(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set(HeaderRequestID, "lb-1234.abc")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "lb-1234.abc", w.Header().Get(HeaderRequestID))
+
+	req = httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set(HeaderRequestID, "bad id\nwith newline")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.NotEqual(t, "bad id\nwith newline", w.Header().Get(HeaderRequestID))
+	assert.NotEmpty(t, w.Header().Get(HeaderRequestID))
+}
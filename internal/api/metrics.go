@@ -0,0 +1,31 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"weatherApi/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that hit no registered route, so arbitrary
+// paths do not create new time series.
+const unmatchedRoute = "unmatched"
+
+// metricsMiddleware records request count and latency per route template and status.
+func metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
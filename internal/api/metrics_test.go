@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"weatherApi/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMetrics_Endpoint verifies that /metrics exposes per-route request counters
+// and the subscription gauges in the Prometheus text format.
+func TestMetrics_Endpoint(t *testing.T) {
+	router, h := setupTestRouterWithDB(t)
+	h.fetchWeather = func(_ context.Context, city string) (*model.Weather, int, error) {
+		return &model.Weather{Temperature: 10, Humidity: 50, Description: "Cloudy"}, http.StatusOK, nil
+	}
+
+	require.NoError(t, h.db.Create(&model.Subscription{
+		ID: "m-1", Email: "active@example.com", City: "Kyiv", Frequency: "daily", Status: model.StatusActive, Token: "m-1",
+	}).Error)
+	require.NoError(t, h.db.Create(&model.Subscription{
+		ID: "m-2", Email: "pending@example.com", City: "Kyiv", Frequency: "daily", Token: "m-2",
+	}).Error)
+
+	for _, target := range []string{"/api/weather?city=Kyiv", "/no/such/path"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, `weatherapi_http_requests_total{method="GET",route="/api/weather",status="200"}`)
+	assert.Contains(t, body, `weatherapi_http_request_duration_seconds_bucket{method="GET",route="/api/weather",status="200"`)
+	assert.Contains(t, body, `route="unmatched",status="404"`)
+	assert.NotContains(t, body, "/no/such/path", "raw paths must not become labels")
+	assert.Contains(t, body, `weatherapi_subscriptions{status="active"} 1`)
+	assert.Contains(t, body, `weatherapi_subscriptions{status="pending"} 1`)
+	assert.Contains(t, body, `weatherapi_subscriptions{status="unsubscribed"} 0`)
+}
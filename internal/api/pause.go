@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/jwtutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPauseDays bounds how far ahead a resume date may be set.
+const maxPauseDays = 365
+
+// errNotPaused is returned when resuming a subscription that is neither paused nor active.
+var errNotPaused = errors.New("subscription is not paused")
+
+// PauseRequest is the optional body of POST /api/pause/{token}.
+type PauseRequest struct {
+	ResumeOn string `form:"resume_on" json:"resume_on"` // YYYY-MM-DD (UTC); empty pauses until resumed
+}
+
+// pausePageHandler renders the page linked from every forecast email, where the
+// subscriber can pause updates (optionally until a date) or resume them.
+func (h *Handler) pausePageHandler(c *gin.Context) {
+	email, err := jwtutil.Parse(c.Param("token"))
+	if err != nil {
+		renderPausePage(c, http.StatusBadRequest, "Invalid token", nil)
+		return
+	}
+
+	sub, err := h.subs.GetByEmail(c.Request.Context(), email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			renderPausePage(c, http.StatusNotFound, "Token not found", nil)
+			return
+		}
+		renderPausePage(c, http.StatusInternalServerError, "Failed to load subscription", nil)
+		return
+	}
+
+	renderPausePage(c, http.StatusOK, "", sub)
+}
+
+// pauseHandler pauses an active subscription, or changes the resume date of a paused one.
+// Without resume_on the subscription stays paused until it is resumed.
+func (h *Handler) pauseHandler(c *gin.Context) {
+	email, err := jwtutil.Parse(c.Param("token"))
+	if err != nil {
+		respondPause(c, http.StatusBadRequest, "Invalid token", nil)
+		return
+	}
+
+	var req PauseRequest
+	if err := c.ShouldBind(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondPause(c, http.StatusBadRequest, "Invalid input", nil)
+		return
+	}
+
+	resumeOn, err := parseResumeOn(req.ResumeOn, time.Now())
+	if err != nil {
+		respondPause(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	var sub *model.Subscription
+	err = h.subs.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if sub, err = h.subs.GetByEmailForUpdate(ctx, email); err != nil {
+			return err
+		}
+		// Already paused: only the resume date changes
+		if sub.Status != model.StatusPaused {
+			if err := h.subs.Transition(ctx, sub.ID, model.StatusPaused, repository.StatusChange{
+				Actor: model.ActorSubscriber, Reason: "paused by subscriber",
+			}); err != nil {
+				return err
+			}
+			sub.Status = model.StatusPaused
+		}
+		sub.ResumeOn = resumeOn
+		return h.subs.SetResumeOn(ctx, sub.ID, resumeOn)
+	})
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondPause(c, http.StatusNotFound, "Token not found", nil)
+	case errors.Is(err, model.ErrInvalidTransition):
+		respondPause(c, http.StatusConflict, "Only active subscriptions can be paused", sub)
+	case err != nil:
+		slog.ErrorContext(ctx, "failed to pause subscription", "error", err)
+		respondPause(c, http.StatusInternalServerError, "Failed to pause subscription", nil)
+	case resumeOn != nil:
+		respondPause(c, http.StatusOK, "Subscription paused until "+resumeOn.Format(time.DateOnly), sub)
+	default:
+		respondPause(c, http.StatusOK, "Subscription paused until you resume it", sub)
+	}
+}
+
+// resumeHandler reactivates a paused subscription before its resume date.
+func (h *Handler) resumeHandler(c *gin.Context) {
+	email, err := jwtutil.Parse(c.Param("token"))
+	if err != nil {
+		respondPause(c, http.StatusBadRequest, "Invalid token", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	alreadyActive := false
+	var sub *model.Subscription
+	err = h.subs.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if sub, err = h.subs.GetByEmailForUpdate(ctx, email); err != nil {
+			return err
+		}
+		switch sub.Status {
+		case model.StatusActive:
+			alreadyActive = true
+			return nil
+		case model.StatusPaused:
+		default:
+			// The state machine also allows pending -> active, which only confirmation may do
+			return errNotPaused
+		}
+		if err := h.subs.Transition(ctx, sub.ID, model.StatusActive, repository.StatusChange{
+			Actor: model.ActorSubscriber, Reason: "resumed by subscriber",
+		}); err != nil {
+			return err
+		}
+		sub.Status, sub.ResumeOn = model.StatusActive, nil
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondPause(c, http.StatusNotFound, "Token not found", nil)
+	case errors.Is(err, errNotPaused), errors.Is(err, model.ErrInvalidTransition):
+		respondPause(c, http.StatusConflict, "Only paused subscriptions can be resumed", sub)
+	case err != nil:
+		slog.ErrorContext(ctx, "failed to resume subscription", "error", err)
+		respondPause(c, http.StatusInternalServerError, "Failed to resume subscription", nil)
+	case alreadyActive:
+		respondPause(c, http.StatusOK, "Subscription is already active", sub)
+	default:
+		respondPause(c, http.StatusOK, "Subscription resumed", sub)
+	}
+}
+
+// parseResumeOn validates an optional YYYY-MM-DD resume date, which must lie between
+// tomorrow and maxPauseDays from today (UTC). An empty value returns nil.
+func parseResumeOn(value string, now time.Time) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid resume_on, expected YYYY-MM-DD")
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	if !day.After(today) || day.After(today.AddDate(0, 0, maxPauseDays)) {
+		return nil, fmt.Errorf("resume_on must be between tomorrow and %d days from today", maxPauseDays)
+	}
+	return &day, nil
+}
+
+// respondPause answers a pause or resume request via negotiate, rendering the pause
+// page with the message and current state for browsers.
+func respondPause(c *gin.Context, status int, text string, sub *model.Subscription) {
+	negotiate(c, status, text, func() { renderPausePage(c, status, text, sub) })
+}
+
+// renderPausePage renders pause.html for the subscription, if known.
+func renderPausePage(c *gin.Context, status int, text string, sub *model.Subscription) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	c.HTML(status, "pause.html", gin.H{
+		"Token":   c.Param("token"),
+		"Sub":     sub,
+		"Success": status < http.StatusBadRequest,
+		"Message": text,
+		"MinDate": today.AddDate(0, 0, 1).Format(time.DateOnly),
+		"MaxDate": today.AddDate(0, 0, maxPauseDays).Format(time.DateOnly),
+	})
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/pkg/jwtutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createPauseSubscription stores a subscription with the given status and returns its token.
+func createPauseSubscription(t *testing.T, h *Handler, status string) string {
+	email := "holiday@example.com"
+	token, err := jwtutil.Generate(email)
+	require.NoError(t, err)
+	require.NoError(t, h.db.Create(&model.Subscription{
+		ID: "sub-1", Email: email, City: "Kyiv", Frequency: "daily",
+		Status: status, Token: token, CreatedAt: time.Now(),
+	}).Error)
+	return token
+}
+
+// pauseRequest posts a form to target, asking for JSON unless html is set.
+func pauseRequest(router *gin.Engine, target string, form url.Values, html bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if !html {
+		req.Header.Set("Accept", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestPause_UntilDateAndResume verifies pausing with a resume date, changing the date
+// while paused, and resuming early, each recorded in the status history.
+func TestPause_UntilDateAndResume(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	token := createPauseSubscription(t, h, model.StatusActive)
+	ctx := context.Background()
+
+	resumeOn := time.Now().UTC().AddDate(0, 0, 14).Format(time.DateOnly)
+	w := pauseRequest(router, "/api/pause/"+token, url.Values{"resume_on": {resumeOn}}, false)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"message":"Subscription paused until `+resumeOn+`"}`, w.Body.String())
+
+	sub, err := h.subs.GetByID(ctx, "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusPaused, sub.Status)
+	require.NotNil(t, sub.ResumeOn)
+	assert.Equal(t, resumeOn, sub.ResumeOn.UTC().Format(time.DateOnly))
+
+	// Pausing again only drops the date
+	w = pauseRequest(router, "/api/pause/"+token, nil, false)
+	require.Equal(t, http.StatusOK, w.Code)
+	sub, err = h.subs.GetByID(ctx, "sub-1")
+	require.NoError(t, err)
+	assert.Nil(t, sub.ResumeOn)
+
+	w = pauseRequest(router, "/api/resume/"+token, nil, false)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message":"Subscription resumed"}`, w.Body.String())
+
+	events, err := h.subs.Events(ctx, "sub-1")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, model.StatusPaused, events[0].ToStatus)
+	assert.Equal(t, model.StatusActive, events[1].ToStatus)
+	assert.Equal(t, model.ActorSubscriber, events[1].Actor)
+}
+
+// TestPause_Rejected verifies invalid dates, unknown tokens and subscriptions that
+// are not active.
+func TestPause_Rejected(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	token := createPauseSubscription(t, h, model.StatusPending)
+
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	for _, value := range []string{"next week", yesterday, time.Now().UTC().AddDate(2, 0, 0).Format(time.DateOnly)} {
+		w := pauseRequest(router, "/api/pause/"+token, url.Values{"resume_on": {value}}, false)
+		assert.Equal(t, http.StatusBadRequest, w.Code, value)
+	}
+
+	w := pauseRequest(router, "/api/pause/"+token, nil, false)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = pauseRequest(router, "/api/resume/"+token, nil, false)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	ghost, err := jwtutil.Generate("ghost@example.com")
+	require.NoError(t, err)
+	w = pauseRequest(router, "/api/pause/"+ghost, nil, false)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = pauseRequest(router, "/api/pause/not-a-token", nil, false)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestPausePage verifies the page linked from emails and that its form posts
+// are answered with the page again.
+func TestPausePage(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	token := createPauseSubscription(t, h, model.StatusActive)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pause/"+token, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `action="/api/pause/`+token+`"`)
+	assert.Contains(t, w.Body.String(), "Pause updates")
+
+	w = pauseRequest(router, "/api/pause/"+token, nil, true)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "paused until you resume them")
+	assert.Contains(t, w.Body.String(), `action="/api/resume/`+token+`"`)
+}
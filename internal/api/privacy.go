@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/jwtutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// privacyScope limits magic links to the data export and erasure endpoints.
+// Subscription tokens from forecast emails are not accepted there.
+const privacyScope = "privacy"
+
+// PrivacyRequest is the body of POST /api/privacy/request.
+type PrivacyRequest struct {
+	Email string `form:"email" json:"email" binding:"required,email"`
+}
+
+// PrivacyExport is everything stored about one email address, as downloaded by its owner.
+type PrivacyExport struct {
+	Email         string                    `json:"email"`
+	ExportedAt    time.Time                 `json:"exported_at"`
+	Subscription  *model.Subscription       `json:"subscription"`
+	StatusHistory []model.SubscriptionEvent `json:"status_history"`
+	Deliveries    []model.Delivery          `json:"deliveries"`
+	Engagement    []model.EngagementEvent   `json:"engagement_events"`
+	SentAlerts    []model.SentAlert         `json:"sent_alerts"`
+	OutboxJobs    []model.OutboxJob         `json:"outbox_jobs"`
+}
+
+// privacyLinkHandler emails a magic link to the data page if the address has a subscription.
+// The response is the same either way, so it cannot be used to find out who is subscribed.
+func (h *Handler) privacyLinkHandler(c *gin.Context) {
+	var req PrivacyRequest
+	if err := c.ShouldBind(&req); err != nil {
+		respondPrivacy(c, http.StatusBadRequest, "Invalid input", "")
+		return
+	}
+	if !h.allowRequest(c, emailKey("privacy", req.Email), h.limits.SubscribePerEmail) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	_, err := h.subs.GetByEmail(ctx, req.Email)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+	case err != nil:
+		slog.ErrorContext(ctx, "failed to look up subscription for privacy link", "error", err)
+		respondPrivacy(c, http.StatusInternalServerError, "Failed to send link", "")
+		return
+	default:
+		token, err := jwtutil.GenerateScoped(req.Email, privacyScope, config.C.PrivacyLinkTTL)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to generate privacy token", "error", err)
+			respondPrivacy(c, http.StatusInternalServerError, "Failed to send link", "")
+			return
+		}
+		h.sendPrivacyLinkAsync(ctx, req.Email, token)
+	}
+
+	respondPrivacy(c, http.StatusOK, "If we store data for this address, we have sent a link to it", "")
+}
+
+// privacyPageHandler renders the request form, or with a valid magic link the page
+// offering the data export and erasure.
+func (h *Handler) privacyPageHandler(c *gin.Context) {
+	if c.Param("token") == "" {
+		renderPrivacyPage(c, http.StatusOK, "", "")
+		return
+	}
+	email, ok := privacyEmail(c)
+	if !ok {
+		return
+	}
+	renderPrivacyPage(c, http.StatusOK, "", email)
+}
+
+// privacyExportHandler downloads everything stored about the magic link's address as JSON.
+func (h *Handler) privacyExportHandler(c *gin.Context) {
+	email, ok := privacyEmail(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	export, err := h.exportData(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No data stored for this address"})
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to export data", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="weather-subscription-data.json"`)
+	c.IndentedJSON(http.StatusOK, export)
+}
+
+// exportData collects the subscription of email and every record kept for it.
+func (h *Handler) exportData(ctx context.Context, email string) (*PrivacyExport, error) {
+	sub, err := h.subs.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	events, err := h.subs.Events(ctx, sub.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &PrivacyExport{Email: email, ExportedAt: time.Now().UTC(), Subscription: sub, StatusHistory: events}
+	tx := h.db.WithContext(ctx)
+	if err := tx.Where("subscription_id = ?", sub.ID).Order("created_at").Find(&export.Deliveries).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("subscription_id = ?", sub.ID).Order("created_at").Find(&export.Engagement).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("subscription_id = ?", sub.ID).Order("sent_at").Find(&export.SentAlerts).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("subscription_id = ?", sub.ID).Order("created_at").Find(&export.OutboxJobs).Error; err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// privacyEraseHandler permanently deletes everything stored about the magic link's address.
+// Only a suppression record with the hashed address is kept.
+func (h *Handler) privacyEraseHandler(c *gin.Context) {
+	email, ok := privacyEmail(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.subs.Transaction(ctx, func(ctx context.Context) error {
+		return h.eraseData(ctx, email)
+	}); err != nil {
+		slog.ErrorContext(ctx, "failed to erase data", "error", err)
+		respondPrivacy(c, http.StatusInternalServerError, "Failed to erase data", "")
+		return
+	}
+
+	slog.InfoContext(ctx, "subscriber data erased")
+	respondPrivacy(c, http.StatusOK, "All your data has been erased", "")
+}
+
+// eraseData deletes the subscription of email with its history, removes the address from
+// audit entries and rate limit buckets, and records the suppression. Erasing an address
+// that has no subscription only records the suppression.
+func (h *Handler) eraseData(ctx context.Context, email string) error {
+	tx := repository.Conn(ctx, h.db)
+
+	sub, err := h.subs.GetByEmailForUpdate(ctx, email)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+	case err != nil:
+		return err
+	default:
+		if err := h.subs.Delete(ctx, sub.ID); err != nil {
+			return err
+		}
+		// Admin lookups by email store the address in the audit details
+		if err := tx.Model(&model.AuditLog{}).
+			Where("target_id = ? AND details <> ''", sub.ID).
+			Update("details", "redacted on erasure").Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("key IN ?", []string{emailKey("subscribe", email), emailKey("privacy", email)}).
+		Delete(&model.RateLimitBucket{}).Error; err != nil {
+		return err
+	}
+
+	return h.suppressions.Add(ctx, email, model.SuppressionErased, time.Now())
+}
+
+// privacyEmail returns the address of the request's magic link. Invalid or expired
+// links are answered with 400 and ok is false.
+func privacyEmail(c *gin.Context) (string, bool) {
+	email, err := jwtutil.ParseScoped(c.Param("token"), privacyScope)
+	if err != nil {
+		respondPrivacy(c, http.StatusBadRequest, "Invalid or expired link, please request a new one", "")
+		return "", false
+	}
+	return email, true
+}
+
+// respondPrivacy answers via negotiate, rendering the privacy page with the message
+// for browsers.
+func respondPrivacy(c *gin.Context, status int, text, email string) {
+	negotiate(c, status, text, func() { renderPrivacyPage(c, status, text, email) })
+}
+
+// renderPrivacyPage renders privacy.html. With an email it offers export and erasure
+// for the magic link's token; without a token it shows the request form.
+func renderPrivacyPage(c *gin.Context, status int, text, email string) {
+	c.HTML(status, "privacy.html", gin.H{
+		"Token":   c.Param("token"),
+		"Email":   email,
+		"Success": status < http.StatusBadRequest,
+		"Message": text,
+	})
+}
+
+// sendPrivacyLinkAsync sends the magic link email via sendAsync.
+func (h *Handler) sendPrivacyLinkAsync(ctx context.Context, email, token string) {
+	sendAsync(ctx, "privacy link", email, func(ctx context.Context) error {
+		return h.sendPrivacyLink(ctx, email, token, config.C.PrivacyLinkTTL)
+	})
+}
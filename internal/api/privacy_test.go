@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/pkg/emailhash"
+	"weatherApi/pkg/jwtutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// privacyToken returns a magic link token for the address used by createPauseSubscription.
+func privacyToken(t *testing.T, ttl time.Duration) string {
+	token, err := jwtutil.GenerateScoped("holiday@example.com", privacyScope, ttl)
+	require.NoError(t, err)
+	return token
+}
+
+// getJSON performs a GET request asking for JSON.
+func getJSON(router *gin.Engine, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestPrivacy_RequestLink verifies that the link request answers the same for known
+// and unknown addresses, so it does not reveal who is subscribed.
+func TestPrivacy_RequestLink(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	createPauseSubscription(t, h, model.StatusActive)
+
+	known := pauseRequest(router, "/api/privacy/request", url.Values{"email": {"holiday@example.com"}}, false)
+	unknown := pauseRequest(router, "/api/privacy/request", url.Values{"email": {"nobody@example.com"}}, false)
+	require.Equal(t, http.StatusOK, known.Code)
+	assert.Equal(t, known.Body.String(), unknown.Body.String())
+
+	w := pauseRequest(router, "/api/privacy/request", url.Values{"email": {"not-an-email"}}, false)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestPrivacy_ExportAndErase verifies that only a valid magic link gives access, that the
+// export contains the subscription and its records, and that erasure deletes them and
+// leaves only a hashed suppression record.
+func TestPrivacy_ExportAndErase(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	subToken := createPauseSubscription(t, h, model.StatusActive)
+	now := time.Now()
+	require.NoError(t, h.db.Create(&model.Delivery{ID: "d-1", SubscriptionID: "sub-1", Kind: "weather.update", Status: model.DeliverySent, CreatedAt: now}).Error)
+	require.NoError(t, h.db.Create(&model.OutboxJob{ID: "j-1", Kind: model.JobWelcome, SubscriptionID: "sub-1", RunAt: now, CreatedAt: now}).Error)
+	require.NoError(t, h.db.Create(&model.AuditLog{ID: "a-1", Actor: "alice", Role: "admin", Action: "subscription.lookup_email", TargetID: "sub-1", Details: "holiday@example.com", CreatedAt: now}).Error)
+
+	// Forecast email tokens and expired links are rejected
+	assert.Equal(t, http.StatusBadRequest, getJSON(router, "/api/privacy/"+subToken+"/export").Code)
+	assert.Equal(t, http.StatusBadRequest, getJSON(router, "/api/privacy/"+privacyToken(t, -time.Minute)+"/export").Code)
+	token := privacyToken(t, time.Hour)
+	assert.Equal(t, http.StatusBadRequest, pauseRequest(router, "/api/pause/"+token, nil, false).Code, "magic links are not subscription tokens")
+
+	w := getJSON(router, "/api/privacy/"+token+"/export")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	var export PrivacyExport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+	assert.Equal(t, "holiday@example.com", export.Email)
+	require.NotNil(t, export.Subscription)
+	assert.Equal(t, "Kyiv", export.Subscription.City)
+	assert.Len(t, export.Deliveries, 1)
+	assert.Len(t, export.OutboxJobs, 1)
+
+	w = pauseRequest(router, "/api/privacy/"+token+"/erase", nil, false)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	for _, m := range []any{&model.Subscription{}, &model.Delivery{}, &model.OutboxJob{}} {
+		var count int64
+		require.NoError(t, h.db.Model(m).Count(&count).Error)
+		assert.Zero(t, count, "%T", m)
+	}
+	var audit model.AuditLog
+	require.NoError(t, h.db.First(&audit, "id = ?", "a-1").Error)
+	assert.NotContains(t, audit.Details, "holiday@example.com")
+
+	var suppression model.Suppression
+	require.NoError(t, h.db.First(&suppression).Error)
+	assert.Equal(t, emailhash.Sum("Holiday@Example.com "), suppression.EmailHash)
+	assert.Equal(t, model.SuppressionErased, suppression.Reason)
+
+	assert.Equal(t, http.StatusNotFound, getJSON(router, "/api/privacy/"+token+"/export").Code)
+	w = pauseRequest(router, "/api/privacy/"+token+"/erase", nil, false)
+	assert.Equal(t, http.StatusOK, w.Code, "erasing twice is harmless")
+}
+
+// TestPrivacyPage verifies the request form and the page behind a magic link.
+func TestPrivacyPage(t *testing.T) {
+	t.Parallel()
+	router, _ := setupTestRouterWithDB(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/privacy", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `action="/api/privacy/request"`)
+
+	token := privacyToken(t, time.Hour)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/privacy/"+token, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/api/privacy/"+token+"/export")
+	assert.Contains(t, w.Body.String(), "/api/privacy/"+token+"/erase")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/privacy/not-a-token", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
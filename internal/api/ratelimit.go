@@ -0,0 +1,68 @@
+package api
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"weatherApi/pkg/emailhash"
+	"weatherApi/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitByIP returns middleware that limits requests per client IP for the given scope.
+// The limit is evaluated on every request, so changes to the handler's limits take effect immediately.
+func (h *Handler) rateLimitByIP(scope string, limit func() ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.allowRequest(c, scope+":ip:"+c.ClientIP(), limit()) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// emailKey returns the bucket key limiting requests per address for the given scope.
+// The address is stored as its keyed hash (emailhash.Sum), never in plain text.
+func emailKey(scope, address string) string {
+	return scope + ":email:" + emailhash.Sum(address)
+}
+
+// subscribeLimitPerIP returns the per-IP limit for /api/subscribe and privacy link requests.
+func (h *Handler) subscribeLimitPerIP() ratelimit.Limit {
+	return h.limits.SubscribePerIP
+}
+
+// citySearchLimitPerIP returns the per-IP limit for /api/cities/search.
+func (h *Handler) citySearchLimitPerIP() ratelimit.Limit {
+	return h.limits.CitySearchPerIP
+}
+
+// allowRequest consumes a token for key and responds with 429 if the bucket is empty.
+// Returns false if the request has been rejected and the handler must stop.
+// Store failures are logged and the request is let through (fail open), so a
+// database hiccup does not block legitimate signups.
+func (h *Handler) allowRequest(c *gin.Context, key string, limit ratelimit.Limit) bool {
+	allowed, wait, err := h.limiter.Take(key, limit, time.Now())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "rate limit store failed, allowing request", "error", err)
+		return true
+	}
+	if !allowed {
+		tooManyRequests(c, wait, "Too many requests, please try again later")
+		return false
+	}
+	return true
+}
+
+// tooManyRequests aborts with HTTP 429 and a Retry-After header (in whole seconds).
+func tooManyRequests(c *gin.Context, wait time.Duration, message string) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": message, "retry_after": seconds})
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+	"weatherApi/pkg/emailhash"
+	"weatherApi/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// postSubscribe submits the subscribe form from the given client IP.
+func postSubscribe(router *gin.Engine, form url.Values, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/subscribe", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = ip + ":12345"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// subscribeForm returns a valid subscribe form for the given email.
+func subscribeForm(email string) url.Values {
+	form := url.Values{}
+	form.Add("email", email)
+	form.Add("city", "Kyiv")
+	form.Add("frequency", "daily")
+	return form
+}
+
+// withConfig temporarily overrides configuration for a single test.
+func withConfig(t *testing.T, update func(c *config.Config)) {
+	original := *config.C
+	update(config.C)
+	t.Cleanup(func() { *config.C = original })
+}
+
+// TestSubscribe_Honeypot verifies that a filled honeypot field is rejected server-side
+// and no subscription is created.
+func TestSubscribe_Honeypot(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+
+	form := subscribeForm("bot@example.com")
+	form.Add("nickname", "spammy")
+
+	w := postSubscribe(router, form, "198.51.100.1")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"Spam detected"}`, w.Body.String())
+
+	var count int64
+	require.NoError(t, h.db.Model(&model.Subscription{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+// TestSubscribe_RateLimitPerIP verifies that one client IP is throttled with
+// HTTP 429 and Retry-After, while other IPs are unaffected.
+func TestSubscribe_RateLimitPerIP(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	h.limits.SubscribePerIP = ratelimit.PerHour(2)
+
+	assert.Equal(t, http.StatusOK, postSubscribe(router, subscribeForm("a@example.com"), "198.51.100.1").Code)
+	assert.Equal(t, http.StatusOK, postSubscribe(router, subscribeForm("b@example.com"), "198.51.100.1").Code)
+
+	w := postSubscribe(router, subscribeForm("c@example.com"), "198.51.100.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "Too many requests")
+
+	assert.Equal(t, http.StatusOK, postSubscribe(router, subscribeForm("c@example.com"), "198.51.100.2").Code)
+}
+
+// TestSubscribe_RateLimitPerEmail verifies that one target address cannot be
+// flooded with confirmation emails from many IPs, and that the bucket key does not
+// contain the address.
+func TestSubscribe_RateLimitPerEmail(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	h.limits = Limits{SubscribePerEmail: ratelimit.PerHour(1)}
+	h.limiter = ratelimit.NewGormStore(h.db)
+
+	assert.Equal(t, http.StatusOK, postSubscribe(router, subscribeForm("victim@example.com"), "198.51.100.1").Code)
+
+	w := postSubscribe(router, subscribeForm("Victim@Example.com"), "198.51.100.2")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	var keys []string
+	require.NoError(t, h.db.Model(&model.RateLimitBucket{}).Pluck("key", &keys).Error)
+	assert.Contains(t, keys, "subscribe:email:"+emailhash.Sum("victim@example.com"))
+	for _, key := range keys {
+		assert.NotContains(t, strings.ToLower(key), "victim")
+	}
+}
+
+// TestSubscribe_ResendCooldown verifies that re-submitting a pending subscription
+// within the cooldown returns 429 with the remaining wait in Retry-After.
+func TestSubscribe_ResendCooldown(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	h.limits.ResendCooldown = 10 * time.Minute
+
+	assert.Equal(t, http.StatusOK, postSubscribe(router, subscribeForm("pending@example.com"), "198.51.100.1").Code)
+
+	w := postSubscribe(router, subscribeForm("pending@example.com"), "198.51.100.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "Confirmation email was sent recently")
+
+	retryAfter := w.Header().Get("Retry-After")
+	require.NotEmpty(t, retryAfter)
+	assert.LessOrEqual(t, len(retryAfter), 3) // at most 600 seconds
+}
+
+// TestGormStore_SharedBuckets verifies the DB-backed store enforces the token bucket
+// and refills over time.
+func TestGormStore_SharedBuckets(t *testing.T) {
+	t.Parallel()
+	h := newTestHandler(t)
+	store := ratelimit.NewGormStore(h.db)
+	limit := ratelimit.Limit{Burst: 2, Period: time.Minute}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := store.Take("k", limit, now)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	allowed, wait, err := store.Take("k", limit, now)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.InDelta(t, 30*time.Second, wait, float64(time.Second))
+
+	allowed, _, err = store.Take("k", limit, now.Add(31*time.Second))
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+// TestGormStore_DeleteIdle verifies that only buckets idle since before the cutoff
+// are deleted.
+func TestGormStore_DeleteIdle(t *testing.T) {
+	t.Parallel()
+	h := newTestHandler(t)
+	store := ratelimit.NewGormStore(h.db)
+	limit := ratelimit.PerHour(5)
+	now := time.Now()
+
+	for key, used := range map[string]time.Time{"idle": now.Add(-2 * time.Hour), "busy": now.Add(-time.Minute)} {
+		_, _, err := store.Take(key, limit, used)
+		require.NoError(t, err)
+	}
+
+	deleted, err := store.DeleteIdle(now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, deleted)
+
+	var keys []string
+	require.NoError(t, h.db.Model(&model.RateLimitBucket{}).Pluck("key", &keys).Error)
+	assert.Equal(t, []string{"busy"}, keys)
+}
@@ -1,32 +1,62 @@
 package api
 
 import (
+	"context"
 	"net/http"
 
+	"weatherApi/pkg/metrics"
+
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterRoutes wires all API and UI routes.
 // Only development-safe routes should be exposed in production builds.
-func RegisterRoutes(r *gin.Engine) {
+func RegisterRoutes(r *gin.Engine, h *Handler) {
+	// Tracing, request IDs, access logs and metrics must wrap every route registered below
+	r.Use(tracingMiddleware(), requestIDMiddleware(), requestLogger(), metricsMiddleware())
+	metrics.SetSubscriptionCounter(func() (map[string]int64, error) {
+		return h.subs.CountByStatus(context.Background())
+	})
+
 	api := r.Group("/api")
 	{
-		api.POST("/subscribe", subscribeHandler)
-		api.GET("/confirm/:token", confirmHandler)
-		api.GET("/unsubscribe/:token", unsubscribeHandler)
-		api.GET("/weather", getWeatherHandler)
+		api.POST("/subscribe", h.rateLimitByIP("subscribe", h.subscribeLimitPerIP), h.subscribeHandler)
+		api.GET("/confirm/:token", h.confirmHandler)
+		api.GET("/unsubscribe/:token", h.unsubscribePageHandler)
+		api.POST("/unsubscribe/:token", h.unsubscribeHandler)
+		api.POST("/pause/:token", h.pauseHandler)
+		api.POST("/resume/:token", h.resumeHandler)
+		api.POST("/privacy/request", h.rateLimitByIP("privacy", h.subscribeLimitPerIP), h.privacyLinkHandler)
+		api.GET("/privacy/:token/export", h.privacyExportHandler)
+		api.POST("/privacy/:token/erase", h.privacyEraseHandler)
+		api.GET("/weather", h.getWeatherHandler)
+		api.GET("/cities/search", h.rateLimitByIP("cities", h.citySearchLimitPerIP), h.searchCitiesHandler)
 	}
 
-	// Register only in non-production mode
-	if gin.Mode() != gin.ReleaseMode {
-		r.GET("/subscriptions", listSubscriptionsHandler)
-	}
+	// Authenticated admin API (replaces the former debug-only /subscriptions dump)
+	// and the server-rendered admin dashboard for support staff
+	registerAdminRoutes(r, h)
+	registerDashboardRoutes(r, h)
+
+	// Signed delivery events (bounces, drops, spam reports) from SendGrid
+	r.POST("/webhooks/sendgrid", h.sendgridWebhookHandler)
 
-	// Health check endpoint
+	// Open pixel and click redirects of tracked emails (ENGAGEMENT_TRACKING)
+	r.GET("/track/open/:id", h.trackOpenHandler)
+	r.GET("/track/click/:id", h.trackClickHandler)
+
+	// Health check endpoint (static; kept for existing monitors)
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Liveness and readiness probes with per-dependency checks
+	r.GET("/healthz", h.livenessHandler)
+	r.GET("/readyz", h.readinessHandler)
+
+	// Prometheus scrape endpoint
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	if gin.Mode() != gin.TestMode {
 		r.LoadHTMLGlob("templates/*.html")
 		r.Static("/static", "./static")
@@ -36,6 +66,13 @@ func RegisterRoutes(r *gin.Engine) {
 		c.HTML(http.StatusOK, "subscribe.html", nil)
 	})
 
+	// Pause/resume page linked from forecast emails
+	r.GET("/pause/:token", h.pausePageHandler)
+
+	// Data export and erasure: request form and the page behind the emailed magic link
+	r.GET("/privacy", h.privacyPageHandler)
+	r.GET("/privacy/:token", h.privacyPageHandler)
+
 	r.GET("/", func(c *gin.Context) {
 		c.Redirect(http.StatusFound, "/subscribe")
 	})
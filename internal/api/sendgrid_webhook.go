@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sendgrid/sendgrid-go/helpers/eventwebhook"
+)
+
+const (
+	// maxWebhookBody caps the event batches accepted from SendGrid.
+	maxWebhookBody = 1 << 20
+
+	// maxWebhookAge is how far the signed timestamp may be from now. Older batches are
+	// rejected, so a captured request cannot be replayed later.
+	maxWebhookAge = 5 * time.Minute
+)
+
+// sendgridEvent is the part of a SendGrid event webhook entry we use.
+type sendgridEvent struct {
+	Email     string `json:"email"`
+	Event     string `json:"event"`
+	Type      string `json:"type"`
+	Reason    string `json:"reason"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// suppressionReason maps a SendGrid event to the suppression it causes, if any.
+// Blocked bounces are temporary rejections by the receiving server and are ignored.
+func (e sendgridEvent) suppressionReason() (string, bool) {
+	switch e.Event {
+	case "bounce":
+		return model.SuppressionBounce, e.Type != "blocked"
+	case "dropped":
+		return model.SuppressionDropped, true
+	case "spamreport":
+		return model.SuppressionSpamReport, true
+	}
+	return "", false
+}
+
+// SetSendGridWebhookKey parses the verification key shown in SendGrid's webhook settings
+// (base64 DER encoded ECDSA). An empty key disables the webhook endpoint.
+func (h *Handler) SetSendGridWebhookKey(encoded string) error {
+	if encoded == "" {
+		h.webhookKey = nil
+		return nil
+	}
+	key, err := sendgridPublicKey(encoded)
+	if err != nil {
+		return err
+	}
+	h.webhookKey = key
+	return nil
+}
+
+// sendgridWebhookHandler receives SendGrid's signed event webhook. Bounces, drops and
+// spam reports put the address on the suppression list and move its subscription to
+// bounced. Any storage failure answers 500 so SendGrid retries the whole batch.
+func (h *Handler) sendgridWebhookHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	if h.webhookKey == nil {
+		slog.ErrorContext(ctx, "SendGrid webhook is not configured")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Webhook not configured"})
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody+1))
+	if err != nil || len(payload) > maxWebhookBody {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+		return
+	}
+
+	timestamp := c.GetHeader(eventwebhook.TimestampHTTPHeader)
+	ok, err := eventwebhook.VerifySignature(h.webhookKey, payload,
+		c.GetHeader(eventwebhook.VerificationHTTPHeader), timestamp)
+	if err != nil || !ok {
+		slog.WarnContext(ctx, "rejected SendGrid webhook with invalid signature")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid signature"})
+		return
+	}
+	if !freshWebhook(timestamp, time.Now()) {
+		slog.WarnContext(ctx, "rejected SendGrid webhook with stale timestamp", "timestamp", timestamp)
+		c.JSON(http.StatusForbidden, gin.H{"error": "Stale timestamp"})
+		return
+	}
+
+	var events []sendgridEvent
+	if err := json.Unmarshal(payload, &events); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload"})
+		return
+	}
+
+	handled := 0
+	for _, event := range events {
+		reason, ok := event.suppressionReason()
+		if !ok || event.Email == "" {
+			continue
+		}
+		if err := h.suppressAddress(ctx, event, reason); err != nil {
+			slog.ErrorContext(ctx, "failed to record SendGrid event", "event", event.Event, logging.Email(event.Email), "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record events"})
+			return
+		}
+		handled++
+	}
+
+	slog.InfoContext(ctx, "SendGrid events received", "events", len(events), "suppressed", handled)
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// suppressAddress records the suppression of the event's address and marks its
+// subscription as bounced. Addresses without a subscription are only suppressed.
+func (h *Handler) suppressAddress(ctx context.Context, event sendgridEvent, reason string) error {
+	at := time.Now()
+	if event.Timestamp > 0 {
+		at = time.Unix(event.Timestamp, 0)
+	}
+
+	return h.subs.Transaction(ctx, func(ctx context.Context) error {
+		if err := h.suppressions.Add(ctx, event.Email, reason, at); err != nil {
+			return err
+		}
+
+		sub, err := h.subs.GetByEmailForUpdate(ctx, event.Email)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if sub.Status == model.StatusBounced {
+			return nil
+		}
+
+		detail := "SendGrid " + event.Event
+		if event.Reason != "" {
+			detail += ": " + event.Reason
+		}
+		err = h.subs.Transition(ctx, sub.ID, model.StatusBounced, repository.StatusChange{
+			Actor: model.ActorSystem, Reason: detail,
+		})
+		// Unsubscribed addresses stay unsubscribed; the suppression still blocks them
+		if errors.Is(err, model.ErrInvalidTransition) {
+			return nil
+		}
+		return err
+	})
+}
+
+// freshWebhook reports whether the signed Unix timestamp is within maxWebhookAge of now.
+func freshWebhook(timestamp string, now time.Time) bool {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := now.Sub(time.Unix(sec, 0))
+	return age <= maxWebhookAge && age >= -maxWebhookAge
+}
+
+// sendgridPublicKey parses the verification key shown in SendGrid's webhook settings:
+// a base64 DER encoded ECDSA public key.
+func sendgridPublicKey(encoded string) (*ecdsa.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode webhook public key: %w", err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse webhook public key: %w", err)
+	}
+	key, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("webhook public key is %T, not ECDSA", pub)
+	}
+	return key, nil
+}
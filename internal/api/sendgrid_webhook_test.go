@@ -0,0 +1,134 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"weatherApi/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sendgrid/sendgrid-go/helpers/eventwebhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withWebhookKey configures the handler with a fresh SendGrid verification key, encoded
+// like in SendGrid's settings, and returns its private half.
+func withWebhookKey(t *testing.T, h *Handler) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	require.NoError(t, h.SetSendGridWebhookKey(base64.StdEncoding.EncodeToString(der)))
+	return key
+}
+
+// postWebhook posts payload signed with key the way SendGrid does, timestamped now.
+func postWebhook(t *testing.T, router *gin.Engine, key *ecdsa.PrivateKey, payload string) *httptest.ResponseRecorder {
+	return postWebhookAt(t, router, key, payload, time.Now())
+}
+
+// postWebhookAt is postWebhook with the given signing time.
+func postWebhookAt(t *testing.T, router *gin.Engine, key *ecdsa.PrivateKey, payload string, at time.Time) *httptest.ResponseRecorder {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	digest := sha256.Sum256([]byte(timestamp + payload))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/sendgrid", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(eventwebhook.VerificationHTTPHeader, base64.StdEncoding.EncodeToString(signature))
+	req.Header.Set(eventwebhook.TimestampHTTPHeader, timestamp)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestSendGridWebhook_Signature verifies that unsigned or tampered events are rejected
+// and change nothing.
+func TestSendGridWebhook_Signature(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	createPauseSubscription(t, h, model.StatusActive)
+	payload := `[{"email":"holiday@example.com","event":"bounce","type":"bounce"}]`
+
+	key := withWebhookKey(t, h)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, postWebhook(t, router, other, payload).Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/sendgrid", strings.NewReader(payload))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var count int64
+	require.NoError(t, h.db.Model(&model.Suppression{}).Count(&count).Error)
+	assert.Zero(t, count)
+	sub, err := h.subs.GetByID(t.Context(), "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusActive, sub.Status)
+
+	// A captured batch cannot be replayed later
+	assert.Equal(t, http.StatusForbidden, postWebhookAt(t, router, key, payload, time.Now().Add(-time.Hour)).Code)
+
+	require.NoError(t, h.db.Model(&model.Suppression{}).Count(&count).Error)
+	assert.Zero(t, count)
+	sub, err = h.subs.GetByID(t.Context(), "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusActive, sub.Status)
+
+	require.NoError(t, h.SetSendGridWebhookKey(""))
+	assert.Equal(t, http.StatusServiceUnavailable, postWebhook(t, router, key, payload).Code)
+	assert.Error(t, h.SetSendGridWebhookKey("not-a-key"))
+}
+
+// TestSendGridWebhook_Suppresses verifies that bounces, drops and spam reports suppress
+// the address and mark its subscription as bounced, while other events are ignored.
+func TestSendGridWebhook_Suppresses(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	key := withWebhookKey(t, h)
+	createPauseSubscription(t, h, model.StatusActive)
+
+	w := postWebhook(t, router, key, `[
+		{"email":"holiday@example.com","event":"delivered"},
+		{"email":"holiday@example.com","event":"bounce","type":"blocked","reason":"try again later"}
+	]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	blocked, err := h.suppressions.Blocked(t.Context(), "holiday@example.com")
+	require.NoError(t, err)
+	assert.False(t, blocked, "delivered and blocked events don't suppress")
+
+	w = postWebhook(t, router, key, `[
+		{"email":"holiday@example.com","event":"bounce","type":"bounce","reason":"550 mailbox unavailable","timestamp":1760000000},
+		{"email":"holiday@example.com","event":"spamreport"},
+		{"email":"stranger@example.com","event":"dropped","reason":"Invalid"}
+	]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	for _, email := range []string{"holiday@example.com", "stranger@example.com"} {
+		blocked, err := h.suppressions.Blocked(t.Context(), email)
+		require.NoError(t, err)
+		assert.True(t, blocked, email)
+	}
+
+	sub, err := h.subs.GetByID(t.Context(), "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusBounced, sub.Status)
+	events, err := h.subs.Events(t.Context(), "sub-1")
+	require.NoError(t, err)
+	last := events[len(events)-1]
+	assert.Equal(t, model.ActorSystem, last.Actor)
+	assert.Equal(t, "SendGrid bounce: 550 mailbox unavailable", last.Reason)
+}
@@ -1,48 +1,104 @@
 package api
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"weatherApi/internal/db"
 	"weatherApi/internal/model"
-	emailutil "weatherApi/pkg/email"
+	"weatherApi/internal/repository"
 	"weatherApi/pkg/jwtutil"
-	"weatherApi/pkg/weatherapi"
+	"weatherApi/pkg/logging"
+	"weatherApi/pkg/notify"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// Allows replacing weatherapi.CityExists in tests
-var cityValidator = weatherapi.CityExists
-
 type SubscribeRequest struct {
-	Email     string `form:"email" binding:"required,email"`
-	City      string `form:"city" binding:"required"`
-	Frequency string `form:"frequency" binding:"required,oneof=daily hourly"`
+	Email      string   `form:"email" binding:"required,email"`
+	City       string   `form:"city" binding:"required"`
+	LocationID int      `form:"location_id" json:"location_id"` // Provider location ID picked via autocomplete (optional)
+	Frequency  string   `form:"frequency" binding:"required,oneof=daily hourly alerts"`
+	Conditions []string `form:"conditions" json:"conditions"` // Optional rules, e.g. "temperature>30", "precipitation>60"
+	Channel    string   `form:"channel" json:"channel" binding:"omitempty,oneof=email webhook both"`
+	WebhookURL string   `form:"webhook_url" json:"webhook_url" binding:"omitempty,url"`
+	Nickname   string   `form:"nickname" json:"nickname"` // Honeypot: hidden in the form, must stay empty
+}
+
+// maxConditions limits how many rules a single subscription may carry.
+const maxConditions = 5
+
+// subscribeAttempts bounds how often the subscribe transaction is retried after
+// losing an insert race on the email's unique index, or a write conflict, to a
+// concurrent request.
+const subscribeAttempts = 3
+
+// errAlreadySubscribed is returned when the email has a confirmed (active or paused) subscription.
+var errAlreadySubscribed = errors.New("Email already subscribed")
+
+// resendCooldownError reports that the confirmation email was sent too recently.
+type resendCooldownError struct {
+	wait time.Duration
+}
+
+func (e *resendCooldownError) Error() string {
+	return fmt.Sprintf("confirmation email was sent recently, retry in %s", e.wait)
 }
 
 // subscribeHandler handles new subscription requests:
-// - validates input
-// - checks if the city exists
-// - updates or creates a subscription
-// - sends confirmation email asynchronously
-func subscribeHandler(c *gin.Context) {
+//   - validates input and rejects filled honeypot fields
+//   - enforces per-address rate limits and the confirmation resend cooldown
+//   - resolves the city to a canonical location
+//   - updates or creates the subscription in one transaction, so concurrent
+//     submits for the same email neither fail nor both send an email
+//   - sends confirmation email asynchronously
+func (h *Handler) subscribeHandler(c *gin.Context) {
 	var req SubscribeRequest
 	if err := c.ShouldBind(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
 
-	if err := validateCity(req.City); err != nil {
+	// Real users never see the honeypot field; only bots fill it in
+	if req.Nickname != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Spam detected"})
+		return
+	}
+
+	if err := validateChannel(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conditions, err := parseConditions(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Counted only for well-formed requests, since only those can trigger an email
+	if !h.allowRequest(c, emailKey("subscribe", req.Email), h.limits.SubscribePerEmail) {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	found, err := h.lookupLocation(ctx, req)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	existingSub, err := checkExistingSubscription(req)
+	loc, err := db.FindOrCreateLocation(h.db.WithContext(ctx), found)
 	if err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save location"})
 		return
 	}
 
@@ -52,49 +108,159 @@ func subscribeHandler(c *gin.Context) {
 		return
 	}
 
-	if existingSub != nil {
-		// Update existing unconfirmed/unsubscribed subscription with new data and token
-		if err := updateSubscription(existingSub, req, token); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subscription"})
-			return
-		}
-	} else {
-		// Create new subscription
-		if err := createSubscription(req, token); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save subscription"})
+	var webhookSecret string
+	if req.WebhookURL != "" {
+		if webhookSecret, err = generateWebhookSecret(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create webhook secret"})
 			return
 		}
 	}
 
+	var cooldown *resendCooldownError
+	err = h.upsertSubscription(ctx, req, loc, conditions, token, webhookSecret)
+	switch {
+	case errors.Is(err, errAlreadySubscribed), errors.Is(err, repository.ErrDuplicateEmail):
+		c.JSON(http.StatusConflict, gin.H{"error": errAlreadySubscribed.Error()})
+		return
+	case errors.As(err, &cooldown):
+		tooManyRequests(c, cooldown.wait, "Confirmation email was sent recently, please check your inbox")
+		return
+	case err != nil:
+		slog.ErrorContext(ctx, "failed to save subscription", logging.Email(req.Email), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save subscription"})
+		return
+	}
+
 	// Send confirmation email in a separate goroutine
-	sendConfirmationEmailAsync(req.Email, token)
+	h.sendConfirmationEmailAsync(ctx, req.Email, token)
+
+	// The webhook secret is shown only once, so the receiver can verify signatures
+	if webhookSecret != "" {
+		c.JSON(http.StatusOK, gin.H{
+			"message":        "Subscription successful. Confirmation email sent.",
+			"webhook_secret": webhookSecret,
+		})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Subscription successful. Confirmation email sent."})
 }
 
-// validateCity checks if the requested city exists using the external weather API
-func validateCity(city string) error {
-	ok, err := cityValidator(city)
-	if err != nil {
-		return fmt.Errorf("Failed to validate city")
+// validateChannel normalizes the delivery channel and checks that webhook
+// deliveries have a public https endpoint. Email stays the default channel; the
+// email address is still required because it is used for confirmation.
+func validateChannel(req *SubscribeRequest) error {
+	if req.Channel == "" {
+		req.Channel = notify.ChannelEmail
 	}
-	if !ok {
-		return fmt.Errorf("City not found")
+
+	if req.Channel == notify.ChannelEmail {
+		req.WebhookURL = ""
+		return nil
+	}
+
+	if req.WebhookURL == "" {
+		return fmt.Errorf("Webhook URL is required for webhook delivery")
+	}
+	if err := notify.ValidateWebhookURL(req.WebhookURL); err != nil {
+		return fmt.Errorf("Webhook URL must be a public https URL")
 	}
 	return nil
 }
 
-// checkExistingSubscription returns an existing subscription if found,
-// or an error if the email is already subscribed and confirmed
-func checkExistingSubscription(req SubscribeRequest) (*model.Subscription, error) {
-	var existing model.Subscription
-	err := DB.Where("email = ?", req.Email).First(&existing).Error
-	if err == nil {
-		if existing.IsConfirmed && !existing.IsUnsubscribed {
-			return nil, fmt.Errorf("Email already subscribed")
+// lookupLocation maps the requested city to a canonical location using the external weather API.
+// A provider location ID picked via autocomplete takes precedence over the free-text city.
+func (h *Handler) lookupLocation(ctx context.Context, req SubscribeRequest) (*model.Location, error) {
+	query := req.City
+	if req.LocationID > 0 {
+		query = fmt.Sprintf("id:%d", req.LocationID)
+	}
+
+	loc, err := h.resolveLocation(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to validate city")
+	}
+	if loc == nil {
+		return nil, fmt.Errorf("City not found")
+	}
+	return loc, nil
+}
+
+// parseConditions validates the optional send-only-if rules of the request.
+// Conditions apply to periodic updates only; "alerts" subscriptions are event-driven.
+func parseConditions(req SubscribeRequest) ([]model.Condition, error) {
+	if len(req.Conditions) == 0 {
+		return nil, nil
+	}
+	if req.Frequency == "alerts" {
+		return nil, fmt.Errorf("Conditions are not supported for alerts")
+	}
+	if len(req.Conditions) > maxConditions {
+		return nil, fmt.Errorf("At most %d conditions are allowed", maxConditions)
+	}
+
+	conditions := make([]model.Condition, 0, len(req.Conditions))
+	for _, raw := range req.Conditions {
+		cond, err := model.ParseCondition(raw)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid condition: %v", err)
 		}
-		return &existing, nil
+		conditions = append(conditions, cond)
+	}
+	return conditions, nil
+}
+
+// resendCooldownRemaining returns how long to wait before another confirmation email
+// may be sent for an existing subscription, or zero if it may be sent now.
+func resendCooldownRemaining(sub *model.Subscription, cooldown time.Duration, now time.Time) time.Duration {
+	if sub == nil || sub.ConfirmationSentAt == nil {
+		return 0
 	}
-	return nil, nil
+	return sub.ConfirmationSentAt.Add(cooldown).Sub(now)
+}
+
+// upsertSubscription creates the subscription, or moves an existing unconfirmed one
+// (pending, unsubscribed, bounced or expired) back to pending, inside a transaction
+// that holds the row lock for the email. If a concurrent request inserts the same
+// email first or wins a write conflict, the transaction is retried and then takes
+// the update path (usually ending in the resend cooldown).
+func (h *Handler) upsertSubscription(ctx context.Context, req SubscribeRequest, loc *model.Location, conditions []model.Condition, token, webhookSecret string) error {
+	var err error
+	for attempt := 0; attempt < subscribeAttempts; attempt++ {
+		err = h.subs.Transaction(ctx, func(ctx context.Context) error {
+			existing, err := h.checkExistingSubscription(ctx, req)
+			if err != nil {
+				return err
+			}
+			if wait := resendCooldownRemaining(existing, h.limits.ResendCooldown, time.Now()); wait > 0 {
+				return &resendCooldownError{wait: wait}
+			}
+			if existing != nil {
+				// Refresh the existing unconfirmed subscription with new data and token
+				return h.updateSubscription(ctx, existing, req, loc, conditions, token, webhookSecret)
+			}
+			return h.createSubscription(ctx, req, loc, conditions, token, webhookSecret)
+		})
+		if !errors.Is(err, repository.ErrDuplicateEmail) && !errors.Is(err, repository.ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// checkExistingSubscription locks and returns the subscription for the email, nil if there
+// is none, or errAlreadySubscribed if it is active or paused. Lookup failures are
+// returned as is, so they are not mistaken for a missing subscription.
+func (h *Handler) checkExistingSubscription(ctx context.Context, req SubscribeRequest) (*model.Subscription, error) {
+	existing, err := h.subs.GetByEmailForUpdate(ctx, req.Email)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case existing.Status == model.StatusActive, existing.Status == model.StatusPaused:
+		return nil, errAlreadySubscribed
+	}
+	return existing, nil
 }
 
 // generateToken creates a JWT for email confirmation and unsubscribe links
@@ -102,37 +268,84 @@ func generateToken(email string) (string, error) {
 	return jwtutil.Generate(email)
 }
 
-// createSubscription saves a new unconfirmed subscription to the database
-func createSubscription(req SubscribeRequest, token string) error {
+// generateWebhookSecret creates a random per-endpoint secret for signing webhook payloads
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// createSubscription saves a new pending subscription (with its conditions) to the database
+func (h *Handler) createSubscription(ctx context.Context, req SubscribeRequest, loc *model.Location, conditions []model.Condition, token, webhookSecret string) error {
+	id := uuid.New().String()
+	now := time.Now()
 	sub := model.Subscription{
-		ID:             uuid.New().String(),
-		Email:          req.Email,
-		City:           req.City,
-		Frequency:      req.Frequency,
-		IsConfirmed:    false,
-		IsUnsubscribed: false,
-		Token:          token,
-		CreatedAt:      time.Now(),
+		ID:                 id,
+		Email:              req.Email,
+		City:               loc.Name,
+		LocationID:         &loc.ID,
+		Location:           loc,
+		Conditions:         withIDs(conditions, id),
+		Frequency:          req.Frequency,
+		Channel:            req.Channel,
+		WebhookURL:         req.WebhookURL,
+		WebhookSecret:      webhookSecret,
+		Status:             model.StatusPending,
+		ConfirmationSentAt: &now,
+		Token:              token,
+		CreatedAt:          now,
 	}
-	return DB.Create(&sub).Error
+	return h.subs.Create(ctx, &sub, repository.StatusChange{Actor: model.ActorSubscriber, Reason: "subscribed"})
 }
 
-// updateSubscription updates an existing subscription with new values and resets confirmation status
-func updateSubscription(sub *model.Subscription, req SubscribeRequest, token string) error {
-	sub.City = req.City
+// updateSubscription updates an existing subscription with new values and moves it back to pending.
+// Previously stored conditions are replaced by the new ones; the original creation time is kept.
+func (h *Handler) updateSubscription(ctx context.Context, sub *model.Subscription, req SubscribeRequest, loc *model.Location, conditions []model.Condition, token, webhookSecret string) error {
+	if err := h.subs.Transition(ctx, sub.ID, model.StatusPending, repository.StatusChange{
+		Actor: model.ActorSubscriber, Reason: "subscribed again",
+	}); err != nil {
+		return err
+	}
+
+	sub.Conditions = withIDs(conditions, sub.ID)
+	sub.City = loc.Name
+	sub.LocationID = &loc.ID
+	sub.Location = loc
 	sub.Frequency = req.Frequency
+	sub.Channel = req.Channel
+	sub.WebhookURL = req.WebhookURL
+	sub.WebhookSecret = webhookSecret
+	now := time.Now()
 	sub.Token = token
-	sub.CreatedAt = time.Now()
-	sub.IsConfirmed = false
-	sub.IsUnsubscribed = false
-	return DB.Save(sub).Error
+	sub.ConfirmationSentAt = &now
+	return h.subs.Update(ctx, sub)
+}
+
+// withIDs assigns fresh IDs and the owning subscription to parsed conditions
+func withIDs(conditions []model.Condition, subscriptionID string) []model.Condition {
+	for i := range conditions {
+		conditions[i].ID = uuid.New().String()
+		conditions[i].SubscriptionID = subscriptionID
+	}
+	return conditions
+}
+
+// sendConfirmationEmailAsync sends the confirmation email via sendAsync.
+func (h *Handler) sendConfirmationEmailAsync(ctx context.Context, email, token string) {
+	sendAsync(ctx, "confirmation", email, func(ctx context.Context) error {
+		return h.sendConfirmation(ctx, email, token)
+	})
 }
 
-// sendConfirmationEmailAsync sends the confirmation email in a background goroutine
-func sendConfirmationEmailAsync(email, token string) {
+// sendAsync runs send in a background goroutine and logs an error naming the kind of
+// email if it fails. The request's log context is kept, but not its cancellation.
+func sendAsync(ctx context.Context, kind, email string, send func(ctx context.Context) error) {
+	ctx = context.WithoutCancel(ctx)
 	go func() {
-		if err := emailutil.SendConfirmationEmail(email, token); err != nil {
-			fmt.Printf("Failed to send confirmation email to %s: %v\n", email, err)
+		if err := send(ctx); err != nil {
+			slog.ErrorContext(ctx, "failed to send "+kind+" email", logging.Email(email), "error", err)
 		}
 	}()
 }
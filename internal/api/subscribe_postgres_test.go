@@ -0,0 +1,71 @@
+//go:build postgres
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+
+	"weatherApi/internal/db"
+	"weatherApi/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSubscribe_ConcurrentSameEmailPostgres races simultaneous first submits for one
+// address on Postgres. No row exists yet, so GetByEmailForUpdate locks nothing and the
+// losers hit the unique index; only the retry keeps them from failing.
+//
+// Run with: TEST_POSTGRES_URL=postgres://... go test -tags postgres ./internal/api
+func TestSubscribe_ConcurrentSameEmailPostgres(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_URL")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_URL not set")
+	}
+	gdb, err := db.Open("postgres", dsn)
+	require.NoError(t, err)
+	sqlDB, err := gdb.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	h := newTestHandlerWithDB(t, gdb)
+	h.limits = Limits{}
+	router := gin.Default()
+	RegisterRoutes(router, h)
+
+	email := "race-" + uuid.New().String() + "@example.com"
+	t.Cleanup(func() {
+		var sub model.Subscription
+		if gdb.Where("email = ?", email).First(&sub).Error == nil {
+			_ = h.subs.Delete(t.Context(), sub.ID)
+		}
+	})
+
+	const workers = 20
+	codes := make([]int, workers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			codes[i] = postSubscribe(router, subscribeForm(email), fmt.Sprintf("198.51.100.%d", i+1)).Code
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, code := range codes {
+		assert.Equal(t, http.StatusOK, code, "request %d", i)
+	}
+	var count int64
+	require.NoError(t, gdb.Model(&model.Subscription{}).Where("email = ?", email).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
@@ -1,15 +1,25 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 	"weatherApi/config"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/ratelimit"
+	"weatherApi/pkg/scheduler"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -19,6 +29,7 @@ import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"weatherApi/internal/db"
 	"weatherApi/internal/model"
 )
 
@@ -38,34 +49,97 @@ func TestMain(m *testing.M) {
 	mustSetEnv("WEATHER_API_KEY", "dummy-weather-key")
 
 	config.Reload()
+	gin.SetMode(gin.TestMode)
 
 	os.Exit(m.Run())
 }
 
-// setupTestRouterWithDB creates an in-memory SQLite database and initializes
-// a test router with all API routes registered. It also sets up a mock city
-// validator that accepts all cities.
-func setupTestRouterWithDB(t *testing.T) *gin.Engine {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+// newTestHandler builds a Handler over a fresh in-memory SQLite database with
+// fakes for the location resolver, weather provider, notifier and transactional
+// emails. Every test gets its own database, scheduler, limits and rate limit buckets.
+func newTestHandler(t *testing.T) *Handler {
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	if err != nil {
 		t.Fatalf("failed to connect to test DB: %v", err)
 	}
 
-	err = db.AutoMigrate(&model.Subscription{})
+	// Every connection to ":memory:" opens a separate empty database,
+	// so concurrent requests must share a single one.
+	sqlDB, err := gdb.DB()
 	if err != nil {
-		t.Fatalf("failed to migrate test DB: %v", err)
+		t.Fatalf("failed to get test DB handle: %v", err)
 	}
+	sqlDB.SetMaxOpenConns(1)
+	return newTestHandlerWithDB(t, gdb)
+}
+
+// newConcurrentTestHandler is newTestHandler over a file-backed SQLite database in WAL
+// mode with several connections, so concurrent requests use separate connections instead
+// of queueing for a single one. Transactions begin with the write lock (_txlock=immediate)
+// and wait for it, as SQLite deployments should be configured.
+func newConcurrentTestHandler(t *testing.T) *Handler {
+	dsn := "file:" + filepath.Join(t.TempDir(), "weather.db") + "?_journal_mode=WAL&_busy_timeout=5000&_txlock=immediate"
+	gdb, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := gdb.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(8)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	return newTestHandlerWithDB(t, gdb)
+}
 
-	SetDB(db)
+// newTestHandlerWithDB migrates gdb and builds a Handler over it with the test fakes.
+func newTestHandlerWithDB(t *testing.T, gdb *gorm.DB) *Handler {
+	if _, err := db.Migrate(gdb); err != nil {
+		t.Fatalf("failed to migrate test DB: %v", err)
+	}
 
-	cityValidator = func(city string) (bool, error) {
-		return true, nil // Accept all cities in tests
+	subs := repository.NewGormSubscriptionRepository(gdb)
+	sched := scheduler.New(subs, gdb)
+	sched.FetchWeather = func(_ context.Context, city string) (*model.Weather, int, error) {
+		return &model.Weather{Temperature: 22.5, Humidity: 60, Description: "Clear skies"}, 200, nil
 	}
+	sched.FetchForecast = func(_ context.Context, city string, days int) (*model.Forecast, int, error) {
+		return &model.Forecast{}, 200, nil
+	}
+	sched.Notifier = noopSender{} // simulate successful delivery
+
+	h := NewHandler(subs, gdb, sched)
+	h.resolveLocation = fakeResolveLocation // Accept all cities in tests
+	h.limiter = ratelimit.NewMemoryStore()
+	h.sendConfirmation = func(context.Context, string, string) error { return nil }
+	h.sendPrivacyLink = func(context.Context, string, string, time.Duration) error { return nil }
+	return h
+}
 
-	gin.SetMode(gin.TestMode)
+// setupTestRouterWithDB creates a test handler and a router with all API routes registered.
+// HTML templates are loaded here because RegisterRoutes skips them in test mode.
+func setupTestRouterWithDB(t *testing.T) (*gin.Engine, *Handler) {
+	h := newTestHandler(t)
 	r := gin.Default()
-	RegisterRoutes(r)
-	return r
+	RegisterRoutes(r, h)
+	r.LoadHTMLGlob("../../templates/*.html")
+	return r, h
+}
+
+// fakeResolveLocation resolves any city without calling the provider.
+// Input is normalized like the real provider would (case, spaces, the "Kiev" alias),
+// and the provider ID is derived from the normalized name so equal cities share one ID.
+func fakeResolveLocation(_ context.Context, query string) (*model.Location, error) {
+	name := strings.ToLower(strings.TrimSpace(query))
+	if name == "kiev" {
+		name = "kyiv"
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+
+	return &model.Location{
+		ProviderID: int(h.Sum32() & 0x7fffffff),
+		Name:       strings.ToUpper(name[:1]) + name[1:],
+		Country:    "Testland",
+		Timezone:   "UTC",
+	}, nil
 }
 
 // TestSubscribe_Success verifies that a valid subscription request:
@@ -73,7 +147,8 @@ func setupTestRouterWithDB(t *testing.T) *gin.Engine {
 // - Returns success message about confirmation email
 // - Creates subscription record in database
 func TestSubscribe_Success(t *testing.T) {
-	router := setupTestRouterWithDB(t)
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
 
 	form := url.Values{}
 	form.Add("email", "test@example.com")
@@ -89,13 +164,72 @@ func TestSubscribe_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	expected := `{"message":"Subscription successful. Confirmation email sent."}`
 	assert.JSONEq(t, expected, w.Body.String())
+
+	var sub model.Subscription
+	require.NoError(t, h.db.Preload("Location").Where("email = ?", "test@example.com").First(&sub).Error)
+	require.NotNil(t, sub.Location)
+	assert.Equal(t, "Kyiv", sub.City)
+	assert.Equal(t, "Kyiv", sub.Location.Name)
+}
+
+// TestSubscribe_SharesCanonicalLocation verifies that differently spelled input
+// for the same place ("kyiv", "Kyiv ", "Kiev") resolves to a single Location row.
+func TestSubscribe_SharesCanonicalLocation(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+
+	for i, city := range []string{"kyiv", "Kyiv ", "Kiev"} {
+		form := url.Values{}
+		form.Add("email", fmt.Sprintf("user%d@example.com", i))
+		form.Add("city", city)
+		form.Add("frequency", "daily")
+
+		req := httptest.NewRequest(http.MethodPost, "/api/subscribe", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	var locations int64
+	require.NoError(t, h.db.Model(&model.Location{}).Count(&locations).Error)
+	assert.Equal(t, int64(1), locations)
+
+	var linked int64
+	require.NoError(t, h.db.Model(&model.Subscription{}).Where("location_id IS NOT NULL").Count(&linked).Error)
+	assert.Equal(t, int64(3), linked)
+}
+
+// TestSubscribe_CityNotFound verifies that a city the provider cannot resolve:
+// - Returns HTTP 400 Bad Request
+// - Does not create a subscription
+func TestSubscribe_CityNotFound(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	h.resolveLocation = func(_ context.Context, query string) (*model.Location, error) {
+		return nil, nil
+	}
+
+	form := url.Values{}
+	form.Add("email", "nowhere@example.com")
+	form.Add("city", "Atlantis")
+	form.Add("frequency", "daily")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/subscribe", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"City not found"}`, w.Body.String())
 }
 
 // TestSubscribe_MissingEmail verifies that a subscription request without email:
 // - Returns HTTP 400 Bad Request
 // - Contains "Invalid input" in error message
 func TestSubscribe_MissingEmail(t *testing.T) {
-	router := setupTestRouterWithDB(t)
+	t.Parallel()
+	router, _ := setupTestRouterWithDB(t)
 
 	form := url.Values{}
 	form.Add("city", "Kyiv")
@@ -115,7 +249,8 @@ func TestSubscribe_MissingEmail(t *testing.T) {
 // - Returns HTTP 400 Bad Request when frequency is not "daily" or "hourly"
 // - Contains "Invalid input" in error message
 func TestSubscribe_InvalidFrequency(t *testing.T) {
-	router := setupTestRouterWithDB(t)
+	t.Parallel()
+	router, _ := setupTestRouterWithDB(t)
 
 	form := url.Values{}
 	form.Add("email", "test@example.com")
@@ -137,17 +272,17 @@ func TestSubscribe_InvalidFrequency(t *testing.T) {
 // - Contains appropriate error message about duplicate subscription
 // - Does not create duplicate subscription in database
 func TestSubscribe_DuplicateEmail(t *testing.T) {
-	router := setupTestRouterWithDB(t)
-
-	err := DB.Create(&model.Subscription{
-		ID:             uuid.New().String(),
-		Email:          "duplicate@example.com",
-		City:           "Kyiv",
-		Frequency:      "daily",
-		IsConfirmed:    true,
-		IsUnsubscribed: false,
-		Token:          "some-token",
-		CreatedAt:      time.Now(),
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+
+	err := h.db.Create(&model.Subscription{
+		ID:        uuid.New().String(),
+		Email:     "duplicate@example.com",
+		City:      "Kyiv",
+		Frequency: "daily",
+		Status:    model.StatusActive,
+		Token:     "some-token",
+		CreatedAt: time.Now(),
 	}).Error
 	require.NoError(t, err)
 
@@ -164,3 +299,303 @@ func TestSubscribe_DuplicateEmail(t *testing.T) {
 	assert.Equal(t, http.StatusConflict, w.Code)
 	assert.Contains(t, w.Body.String(), "Email already subscribed")
 }
+
+// TestSubscribe_AgainAfterUnsubscribe verifies that an unsubscribed address can subscribe
+// again: the subscription goes back to pending, keeps its creation time and the change
+// is recorded in its status history.
+func TestSubscribe_AgainAfterUnsubscribe(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, h.db.Create(&model.Subscription{
+		ID: "sub-1", Email: "back@example.com", City: "Kyiv", Frequency: "daily",
+		Status: model.StatusUnsubscribed, Token: "old-token", CreatedAt: created,
+	}).Error)
+
+	form := url.Values{"email": {"back@example.com"}, "city": {"Lviv"}, "frequency": {"hourly"}}
+	req := httptest.NewRequest(http.MethodPost, "/api/subscribe", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	ctx := context.Background()
+	sub, err := h.subs.GetByID(ctx, "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusPending, sub.Status)
+	assert.Equal(t, "Lviv", sub.City)
+	assert.Equal(t, "hourly", sub.Frequency)
+	assert.True(t, created.Equal(sub.CreatedAt), "creation time is kept")
+
+	events, err := h.subs.Events(ctx, "sub-1")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, model.StatusUnsubscribed, events[0].FromStatus)
+	assert.Equal(t, model.StatusPending, events[0].ToStatus)
+	assert.Equal(t, model.ActorSubscriber, events[0].Actor)
+}
+
+// TestSubscribe_WithConditions verifies that optional conditions are parsed
+// and stored together with the subscription.
+func TestSubscribe_WithConditions(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+
+	form := url.Values{}
+	form.Add("email", "rain@example.com")
+	form.Add("city", "Kyiv")
+	form.Add("frequency", "daily")
+	form.Add("conditions", "precipitation > 60")
+	form.Add("conditions", "temperature<0")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/subscribe", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var sub model.Subscription
+	require.NoError(t, h.db.Preload("Conditions").Where("email = ?", "rain@example.com").First(&sub).Error)
+	require.Len(t, sub.Conditions, 2)
+
+	got := []string{sub.Conditions[0].String(), sub.Conditions[1].String()}
+	assert.ElementsMatch(t, []string{"precipitation>60", "temperature<0"}, got)
+}
+
+// TestSubscribe_InvalidCondition verifies that malformed or out-of-range conditions:
+// - Return HTTP 400 Bad Request
+// - Do not create a subscription
+func TestSubscribe_InvalidCondition(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+
+	for _, cond := range []string{"humidity>50", "precipitation>150", "temperature=5", "wind>", "temperature<NaN", "wind>Inf"} {
+		form := url.Values{}
+		form.Add("email", "bad@example.com")
+		form.Add("city", "Kyiv")
+		form.Add("frequency", "hourly")
+		form.Add("conditions", cond)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/subscribe", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, cond)
+		assert.Contains(t, w.Body.String(), "Invalid condition", cond)
+	}
+
+	var count int64
+	require.NoError(t, h.db.Model(&model.Subscription{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+// TestSubscribe_WebhookChannel verifies that webhook subscriptions:
+// - Require a webhook URL
+// - Return a per-endpoint signing secret once and store it with the subscription
+func TestSubscribe_WebhookChannel(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/subscribe", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	form := url.Values{}
+	form.Add("email", "hooks@example.com")
+	form.Add("city", "Kyiv")
+	form.Add("frequency", "hourly")
+	form.Add("channel", "both")
+
+	w := post(form)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Webhook URL is required")
+
+	for _, internal := range []string{
+		"http://hooks.example.com/weather",
+		"https://169.254.169.254/latest/meta-data/",
+		"https://localhost:8080/admin/api/subscriptions",
+		"https://10.0.0.5/hook",
+		"https://[::1]/hook",
+	} {
+		form.Set("webhook_url", internal)
+		w = post(form)
+		assert.Equal(t, http.StatusBadRequest, w.Code, internal)
+		assert.Contains(t, w.Body.String(), "public https URL", internal)
+	}
+
+	form.Set("webhook_url", "https://hooks.example.com/weather")
+	w = post(form)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		WebhookSecret string `json:"webhook_secret"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp.WebhookSecret, 64)
+
+	var sub model.Subscription
+	require.NoError(t, h.db.Where("email = ?", "hooks@example.com").First(&sub).Error)
+	assert.Equal(t, "both", sub.Channel)
+	assert.Equal(t, "https://hooks.example.com/weather", sub.WebhookURL)
+	assert.Equal(t, resp.WebhookSecret, sub.WebhookSecret)
+}
+
+// slowLookupRepository delays email lookups, widening the window between the
+// existence check and the write so that concurrent requests really overlap.
+type slowLookupRepository struct {
+	repository.SubscriptionRepository
+}
+
+func (r slowLookupRepository) GetByEmail(ctx context.Context, email string) (*model.Subscription, error) {
+	defer time.Sleep(5 * time.Millisecond)
+	return r.SubscriptionRepository.GetByEmail(ctx, email)
+}
+
+func (r slowLookupRepository) GetByEmailForUpdate(ctx context.Context, email string) (*model.Subscription, error) {
+	defer time.Sleep(5 * time.Millisecond)
+	return r.SubscriptionRepository.GetByEmailForUpdate(ctx, email)
+}
+
+// TestSubscribe_ConcurrentSameEmail hammers the endpoint with simultaneous submits for
+// one address over separate connections: none may fail, and exactly one subscription
+// must be stored. SQLite serializes the transactions on the database write lock; the
+// insert race that Postgres allows is covered by TestSubscribe_RetriesLostInsertRace
+// and, with -tags postgres, TestSubscribe_ConcurrentSameEmailPostgres.
+func TestSubscribe_ConcurrentSameEmail(t *testing.T) {
+	h := newConcurrentTestHandler(t)
+	h.subs = slowLookupRepository{SubscriptionRepository: h.subs}
+	router := gin.Default()
+	RegisterRoutes(router, h)
+	h.limits = Limits{}
+
+	const workers = 20
+	codes := make([]int, workers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			codes[i] = postSubscribe(router, subscribeForm("race@example.com"), fmt.Sprintf("198.51.100.%d", i+1)).Code
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, code := range codes {
+		assert.Equal(t, http.StatusOK, code, "request %d", i)
+	}
+
+	var count int64
+	require.NoError(t, h.db.Model(&model.Subscription{}).Where("email = ?", "race@example.com").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+// TestSubscribe_ConcurrentSameEmailCooldown verifies that only one of many simultaneous
+// submits sends a confirmation; the others hit the resend cooldown instead of a 500.
+func TestSubscribe_ConcurrentSameEmailCooldown(t *testing.T) {
+	h := newConcurrentTestHandler(t)
+	h.subs = slowLookupRepository{SubscriptionRepository: h.subs}
+	router := gin.Default()
+	RegisterRoutes(router, h)
+	h.limits = Limits{ResendCooldown: time.Minute}
+
+	const workers = 20
+	codes := make(chan int, workers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			codes <- postSubscribe(router, subscribeForm("cooldown@example.com"), fmt.Sprintf("198.51.100.%d", i+1)).Code
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for code := range codes {
+		counts[code]++
+	}
+	assert.Equal(t, map[int]int{http.StatusOK: 1, http.StatusTooManyRequests: workers - 1}, counts)
+}
+
+// racingRepository simulates a concurrent request that inserts the same email
+// between the lookup and the insert of the first attempt.
+type racingRepository struct {
+	repository.SubscriptionRepository
+	once sync.Once
+}
+
+func (r *racingRepository) Create(ctx context.Context, sub *model.Subscription, change repository.StatusChange) error {
+	lost := false
+	r.once.Do(func() {
+		rival := *sub
+		rival.ID = "rival"
+		rival.ConfirmationSentAt = nil
+		// The rival commits on its own, outside this request's transaction
+		_ = r.SubscriptionRepository.Create(context.Background(), &rival, change)
+		lost = true
+	})
+	if lost {
+		return repository.ErrDuplicateEmail
+	}
+	return r.SubscriptionRepository.Create(ctx, sub, change)
+}
+
+// TestSubscribe_RetriesLostInsertRace verifies that losing the insert race is retried
+// and ends up updating the subscription created by the other request.
+func TestSubscribe_RetriesLostInsertRace(t *testing.T) {
+	t.Parallel()
+	h := newTestHandler(t)
+	subs := repository.NewMemorySubscriptionRepository()
+	h.subs = &racingRepository{SubscriptionRepository: subs}
+	router := gin.Default()
+	RegisterRoutes(router, h)
+
+	w := postSubscribe(router, subscribeForm("lost@example.com"), "198.51.100.1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	sub, err := subs.GetByEmail(context.Background(), "lost@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "rival", sub.ID)
+	assert.NotNil(t, sub.ConfirmationSentAt)
+}
+
+// failingLookupRepository fails every locked lookup like a broken database connection.
+type failingLookupRepository struct {
+	repository.SubscriptionRepository
+}
+
+func (failingLookupRepository) GetByEmailForUpdate(context.Context, string) (*model.Subscription, error) {
+	return nil, errors.New("connection reset by peer")
+}
+
+// TestSubscribe_LookupErrorIsInternal verifies that a failing lookup returns 500
+// instead of being treated as "not subscribed yet".
+func TestSubscribe_LookupErrorIsInternal(t *testing.T) {
+	t.Parallel()
+	h := newTestHandler(t)
+	subs := repository.NewMemorySubscriptionRepository()
+	h.subs = failingLookupRepository{SubscriptionRepository: subs}
+	router := gin.Default()
+	RegisterRoutes(router, h)
+
+	w := postSubscribe(router, subscribeForm("broken@example.com"), "198.51.100.1")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error":"Failed to save subscription"}`, w.Body.String())
+
+	_, err := subs.GetByEmail(context.Background(), "broken@example.com")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+
+	"weatherApi/pkg/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// probeRoutes are polled by infrastructure; they are not traced and their
+// successful requests are logged at debug level only, to keep noise down.
+var probeRoutes = map[string]bool{
+	"/health":  true,
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// tracingMiddleware starts a server span per request, named after the route template,
+// and continues an incoming W3C trace context. Handlers pass c.Request.Context() down,
+// so DB, provider and email spans nest under it.
+// Only the route template is recorded, never the raw path, because confirm and
+// unsubscribe URLs carry tokens and admin lookups carry email addresses.
+func tracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if probeRoutes[route] {
+			c.Next()
+			return
+		}
+
+		name := route
+		if name == "" {
+			name = unmatchedRoute
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := tracing.Start(ctx, fmt.Sprintf("%s %s", c.Request.Method, name),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"weatherApi/internal/model"
+	"weatherApi/pkg/jwtutil"
+	"weatherApi/pkg/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestTracing_ConfirmChain verifies that a confirm request and its database queries
+// share one trace, and that the token never appears in span data.
+func TestTracing_ConfirmChain(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	router, h := setupTestRouterWithDB(t)
+	require.NoError(t, h.db.Use(tracing.GormPlugin{}))
+
+	token, err := jwtutil.Generate("traced@example.com")
+	require.NoError(t, err)
+	require.NoError(t, h.db.Create(&model.Subscription{
+		ID: "traced-1", Email: "traced@example.com", City: "Kyiv", Frequency: "daily", Token: token,
+	}).Error)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/confirm/"+token, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		byName[s.Name()] = s
+		for _, attr := range s.Attributes() {
+			assert.NotContains(t, attr.Value.Emit(), token)
+		}
+	}
+
+	server, ok := byName["GET /api/confirm/:token"]
+	require.True(t, ok, "server span named after the route")
+	assert.NotContains(t, byName, "scheduler.ProcessSubscription", "delivery is queued, not inline")
+	assert.Contains(t, byName, "gorm.query")
+	assert.Equal(t, server.SpanContext().TraceID(), byName["gorm.query"].SpanContext().TraceID())
+}
@@ -1,46 +1,104 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 
 	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
 	"weatherApi/pkg/jwtutil"
 
 	"github.com/gin-gonic/gin"
 )
 
-// unsubscribeHandler marks a subscription as unsubscribed using a secure token.
+// unsubscribePageHandler shows the page behind the unsubscribe link in email bodies.
+// It never changes anything, so link scanners and prefetchers cannot unsubscribe
+// anyone; the page's button POSTs to unsubscribeHandler.
+func (h *Handler) unsubscribePageHandler(c *gin.Context) {
+	email, err := jwtutil.Parse(c.Param("token"))
+	if err != nil {
+		respondUnsubscribe(c, http.StatusBadRequest, "Invalid token", nil)
+		return
+	}
+
+	sub, err := h.subs.GetByEmail(c.Request.Context(), email)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondUnsubscribe(c, http.StatusNotFound, "Token not found", nil)
+	case err != nil:
+		respondUnsubscribe(c, http.StatusInternalServerError, "Failed to load subscription", nil)
+	case sub.Status == model.StatusUnsubscribed:
+		respondUnsubscribe(c, http.StatusOK, "You are already unsubscribed", sub)
+	default:
+		respondUnsubscribe(c, http.StatusOK, "", sub)
+	}
+}
+
+// unsubscribeHandler moves a subscription to unsubscribed using a secure token.
 // The token is parsed to extract the user's email (acts as a form of lightweight authentication).
 // This endpoint does not require login — anyone with the token can unsubscribe.
-func unsubscribeHandler(c *gin.Context) {
+// It also serves RFC 8058 one-click requests from mail clients, whose
+// "List-Unsubscribe=One-Click" body needs no further handling.
+func (h *Handler) unsubscribeHandler(c *gin.Context) {
 	token := c.Param("token")
 
 	// Parse the token to extract the associated email
 	email, err := jwtutil.Parse(token)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token"})
+		respondUnsubscribe(c, http.StatusBadRequest, "Invalid token", nil)
 		return
 	}
 
-	// Retrieve subscription by email
-	var sub model.Subscription
-	if err := DB.Where("email = ?", email).First(&sub).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
-		return
-	}
+	// Locked, so concurrent clicks don't race on the status check
+	ctx := c.Request.Context()
+	alreadyUnsubscribed := false
+	var sub *model.Subscription
+	err = h.subs.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if sub, err = h.subs.GetByEmailForUpdate(ctx, email); err != nil {
+			return err
+		}
+		if sub.Status == model.StatusUnsubscribed {
+			alreadyUnsubscribed = true
+			return nil
+		}
+		if err := h.subs.Transition(ctx, sub.ID, model.StatusUnsubscribed, repository.StatusChange{
+			Actor: model.ActorSubscriber, Reason: "unsubscribe link",
+		}); err != nil {
+			return err
+		}
+		sub.Status = model.StatusUnsubscribed
+		return nil
+	})
 
-	// If already unsubscribed, return early
-	if sub.IsUnsubscribed {
-		c.JSON(http.StatusOK, gin.H{"message": "You are already unsubscribed"})
-		return
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondUnsubscribe(c, http.StatusNotFound, "Token not found", nil)
+	case err != nil:
+		slog.ErrorContext(ctx, "failed to unsubscribe", "error", err)
+		respondUnsubscribe(c, http.StatusInternalServerError, "Failed to unsubscribe", nil)
+	case alreadyUnsubscribed:
+		respondUnsubscribe(c, http.StatusOK, "You are already unsubscribed", sub)
+	default:
+		respondUnsubscribe(c, http.StatusOK, "Unsubscribed successfully", sub)
 	}
+}
 
-	// Mark subscription as unsubscribed
-	sub.IsUnsubscribed = true
-	if err := DB.Save(&sub).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsubscribe"})
+// respondUnsubscribe answers via negotiate, rendering the unsubscribe page for browsers.
+// Without a message the page asks for confirmation, and JSON clients get the current status.
+func respondUnsubscribe(c *gin.Context, status int, text string, sub *model.Subscription) {
+	if text == "" && c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+		c.JSON(status, gin.H{"message": "Send POST to this URL to unsubscribe", "status": sub.Status})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed successfully"})
+	negotiate(c, status, text, func() {
+		c.HTML(status, "unsubscribe.html", gin.H{
+			"Token":   c.Param("token"),
+			"Sub":     sub,
+			"Success": status < http.StatusBadRequest,
+			"Message": text,
+		})
+	})
 }
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -17,28 +19,26 @@ import (
 // TestUnsubscribeHandler_Success verifies that a valid unsubscribe request
 // properly updates the subscription status and returns success.
 func TestUnsubscribeHandler_Success(t *testing.T) {
-	router := setupTestRouterWithDB(t)
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
 
 	email := "user@unsubscribe.com"
 	token, err := jwtutil.Generate(email)
 	require.NoError(t, err)
 
 	// Create an active subscription for testing
-	err = DB.Create(&model.Subscription{
-		ID:             uuid.NewString(),
-		Email:          email,
-		City:           "Kyiv",
-		Frequency:      "daily",
-		IsConfirmed:    true,
-		IsUnsubscribed: false,
-		Token:          token,
-		CreatedAt:      time.Now(),
+	err = h.db.Create(&model.Subscription{
+		ID:        uuid.NewString(),
+		Email:     email,
+		City:      "Kyiv",
+		Frequency: "daily",
+		Status:    model.StatusActive,
+		Token:     token,
+		CreatedAt: time.Now(),
 	}).Error
 	require.NoError(t, err)
 
-	req := httptest.NewRequest(http.MethodGet, "/api/unsubscribe/"+token, nil)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	w := pauseRequest(router, "/api/unsubscribe/"+token, nil, false)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.JSONEq(t, `{"message":"Unsubscribed successfully"}`, w.Body.String())
@@ -47,11 +47,10 @@ func TestUnsubscribeHandler_Success(t *testing.T) {
 // TestUnsubscribeHandler_InvalidToken tests the handler's response
 // when provided with a malformed or invalid JWT token.
 func TestUnsubscribeHandler_InvalidToken(t *testing.T) {
-	router := setupTestRouterWithDB(t)
+	t.Parallel()
+	router, _ := setupTestRouterWithDB(t)
 
-	req := httptest.NewRequest(http.MethodGet, "/api/unsubscribe/not-a-token", nil)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	w := pauseRequest(router, "/api/unsubscribe/not-a-token", nil, false)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.JSONEq(t, `{"error":"Invalid token"}`, w.Body.String())
@@ -61,14 +60,13 @@ func TestUnsubscribeHandler_InvalidToken(t *testing.T) {
 // appropriate error when attempting to unsubscribe with a valid token
 // but no matching subscription in the database.
 func TestUnsubscribeHandler_NotFound(t *testing.T) {
-	router := setupTestRouterWithDB(t)
+	t.Parallel()
+	router, _ := setupTestRouterWithDB(t)
 
 	token, err := jwtutil.Generate("ghost@nowhere.com")
 	require.NoError(t, err)
 
-	req := httptest.NewRequest(http.MethodGet, "/api/unsubscribe/"+token, nil)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	w := pauseRequest(router, "/api/unsubscribe/"+token, nil, false)
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	assert.JSONEq(t, `{"error":"Token not found"}`, w.Body.String())
@@ -78,29 +76,62 @@ func TestUnsubscribeHandler_NotFound(t *testing.T) {
 // unsubscribe an already unsubscribed subscription returns a helpful message
 // rather than an error.
 func TestUnsubscribeHandler_AlreadyUnsubscribed(t *testing.T) {
-	router := setupTestRouterWithDB(t)
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
 
 	email := "already@unsubscribed.com"
 	token, err := jwtutil.Generate(email)
 	require.NoError(t, err)
 
 	// Create a subscription that's already unsubscribed
-	err = DB.Create(&model.Subscription{
-		ID:             uuid.NewString(),
-		Email:          email,
-		City:           "Kyiv",
-		Frequency:      "daily",
-		IsConfirmed:    true,
-		IsUnsubscribed: true,
-		Token:          token,
-		CreatedAt:      time.Now(),
+	err = h.db.Create(&model.Subscription{
+		ID:        uuid.NewString(),
+		Email:     email,
+		City:      "Kyiv",
+		Frequency: "daily",
+		Status:    model.StatusUnsubscribed,
+		Token:     token,
+		CreatedAt: time.Now(),
 	}).Error
 	require.NoError(t, err)
 
-	req := httptest.NewRequest(http.MethodGet, "/api/unsubscribe/"+token, nil)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	w := pauseRequest(router, "/api/unsubscribe/"+token, nil, false)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.JSONEq(t, `{"message":"You are already unsubscribed"}`, w.Body.String())
 }
+
+// TestUnsubscribePage_DoesNotUnsubscribe verifies that opening the link from an email
+// body (or a scanner prefetching it) only shows the confirmation page.
+func TestUnsubscribePage_DoesNotUnsubscribe(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	token := createPauseSubscription(t, h, model.StatusActive)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/unsubscribe/"+token, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `action="/api/unsubscribe/`+token+`"`)
+	assert.Contains(t, w.Body.String(), "/pause/"+token)
+
+	sub, err := h.subs.GetByID(context.Background(), "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusActive, sub.Status)
+}
+
+// TestUnsubscribeHandler_OneClick verifies the RFC 8058 one-click POST sent by mail
+// clients from the List-Unsubscribe header.
+func TestUnsubscribeHandler_OneClick(t *testing.T) {
+	t.Parallel()
+	router, h := setupTestRouterWithDB(t)
+	token := createPauseSubscription(t, h, model.StatusPaused)
+
+	w := pauseRequest(router, "/api/unsubscribe/"+token, url.Values{"List-Unsubscribe": {"One-Click"}}, true)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Unsubscribed successfully")
+
+	sub, err := h.subs.GetByID(context.Background(), "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusUnsubscribed, sub.Status)
+	assert.Nil(t, sub.ResumeOn)
+}
@@ -3,17 +3,13 @@ package api
 import (
 	"net/http"
 
-	"weatherApi/pkg/weatherapi"
-
 	"github.com/gin-gonic/gin"
 )
 
-var fetchWeather = weatherapi.FetchWithStatus
-
 // getWeatherHandler retrieves current weather for a given city.
 // This endpoint is intended for real-time weather preview (e.g., before subscribing).
 // It requires a "city" query parameter and responds with weather data in JSON.
-func getWeatherHandler(c *gin.Context) {
+func (h *Handler) getWeatherHandler(c *gin.Context) {
 	city := c.Query("city")
 	if city == "" {
 		// Client must specify a city name in query parameters
@@ -22,7 +18,7 @@ func getWeatherHandler(c *gin.Context) {
 	}
 
 	// Fetch weather using external API and return appropriate status code
-	weather, statusCode, err := fetchWeather(city)
+	weather, statusCode, err := h.fetchWeather(c.Request.Context(), city)
 	if err != nil {
 		c.JSON(statusCode, gin.H{"error": err.Error()})
 		return
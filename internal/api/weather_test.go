@@ -3,6 +3,7 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -18,19 +19,17 @@ import (
 // different behaviors for weather fetching operations
 var mockFetchWithStatus func(city string) (*model.Weather, int, error)
 
-// init replaces the production fetchWeather function with our test mock
-func init() {
-	fetchWeather = func(city string) (*model.Weather, int, error) {
-		return mockFetchWithStatus(city)
-	}
-}
-
 // setupTestRouterForWeather creates and configures a Gin router instance
-// specifically for testing the weather endpoint
+// specifically for testing the weather endpoint, with the provider replaced by our test mock
 func setupTestRouterForWeather() *gin.Engine {
-	gin.SetMode(gin.TestMode)
+	h := &Handler{
+		fetchWeather: func(_ context.Context, city string) (*model.Weather, int, error) {
+			return mockFetchWithStatus(city)
+		},
+	}
+
 	router := gin.Default()
-	router.GET("/api/weather", getWeatherHandler)
+	router.GET("/api/weather", h.getWeatherHandler)
 	return router
 }
 
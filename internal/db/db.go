@@ -2,11 +2,12 @@ package db
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
 
 	"weatherApi/config"
 
-	"weatherApi/internal/model"
+	"weatherApi/pkg/tracing"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
@@ -18,9 +19,25 @@ import (
 var DB *gorm.DB
 
 // InitDatabase initializes and returns a GORM DB connection based on the dbType and DSN provided.
-// Supports "postgres" and "sqlite". Also handles schema migration and optional Postgres extension.
+// Supports "postgres" and "sqlite". Also applies pending migrations (see Migrate).
 // This function should typically be called once at startup.
 func InitDatabase(dbType, dsn string) (*gorm.DB, error) {
+	db, err := Open(dbType, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply pending versioned migrations; replicas starting together take turns via the migration lock
+	if _, err := Migrate(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate: %v", err)
+	}
+
+	return db, nil
+}
+
+// Open connects to the database without touching the schema.
+// It registers the tracing plugin and enables the pgcrypto extension on Postgres.
+func Open(dbType, dsn string) (*gorm.DB, error) {
 	var dialector gorm.Dialector
 
 	switch dbType {
@@ -37,6 +54,11 @@ func InitDatabase(dbType, dsn string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect database: %v", err)
 	}
 
+	// Trace every statement; spans nest under the caller's context (DB.WithContext)
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %v", err)
+	}
+
 	// Enable pgcrypto (required for UUID generation, etc.)
 	if dbType == "postgres" {
 		err = db.Exec(`CREATE EXTENSION IF NOT EXISTS "pgcrypto"`).Error
@@ -45,12 +67,6 @@ func InitDatabase(dbType, dsn string) (*gorm.DB, error) {
 		}
 	}
 
-	// Run automatic schema migration for Subscription model
-	err = db.AutoMigrate(&model.Subscription{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to migrate: %v", err)
-	}
-
 	return db, nil
 }
 
@@ -64,8 +80,9 @@ func ConnectDefaultDB() {
 	var err error
 	DB, err = InitDatabase(dbType, dsn)
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("failed to initialize database", "error", err)
+		os.Exit(1)
 	}
 
-	fmt.Println("Connected to DB and ran AutoMigrate")
+	slog.Info("connected to database and applied migrations", "type", dbType)
 }
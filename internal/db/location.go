@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"weatherApi/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LocationResolver maps free-text city input to a canonical location.
+// Returns nil and no error when nothing matches.
+type LocationResolver func(ctx context.Context, query string) (*model.Location, error)
+
+// FindOrCreateLocation returns the stored location with the same provider ID,
+// inserting the given one first if it is not known yet.
+// Safe to call concurrently: a racing insert is ignored and the existing row is returned.
+func FindOrCreateLocation(gdb *gorm.DB, loc *model.Location) (*model.Location, error) {
+	if loc.ID == "" {
+		loc.ID = uuid.New().String()
+	}
+	if loc.CreatedAt.IsZero() {
+		loc.CreatedAt = time.Now()
+	}
+
+	err := gdb.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider_id"}},
+		DoNothing: true,
+	}).Create(loc).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to save location: %w", err)
+	}
+
+	var stored model.Location
+	if err := gdb.Where("provider_id = ?", loc.ProviderID).First(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to load location: %w", err)
+	}
+
+	return &stored, nil
+}
+
+// BackfillLocations resolves the free-text City of every subscription that has no
+// location yet and links it to a canonical Location row.
+// Each distinct (case/space-normalized) city text is resolved only once.
+// Rows that cannot be resolved are logged and left untouched for the next run.
+// Returns the number of subscriptions updated.
+func BackfillLocations(ctx context.Context, gdb *gorm.DB, resolve LocationResolver) (int, error) {
+	var subs []model.Subscription
+	if err := gdb.Where("location_id IS NULL").Find(&subs).Error; err != nil {
+		return 0, fmt.Errorf("failed to query subscriptions: %w", err)
+	}
+
+	resolved := make(map[string]*model.Location)
+	updated := 0
+
+	for _, sub := range subs {
+		key := strings.ToLower(strings.TrimSpace(sub.City))
+
+		loc, seen := resolved[key]
+		if !seen {
+			found, err := resolve(ctx, sub.City)
+			if err != nil {
+				slog.Warn("backfill failed to resolve city", "city", sub.City, "error", err)
+				continue
+			}
+			if found != nil {
+				loc, err = FindOrCreateLocation(gdb, found)
+				if err != nil {
+					return updated, err
+				}
+			}
+			resolved[key] = loc
+		}
+
+		if loc == nil {
+			slog.Warn("backfill city not found, subscription left unresolved", "city", sub.City, "subscription_id", sub.ID)
+			continue
+		}
+
+		err := gdb.Model(&model.Subscription{}).Where("id = ?", sub.ID).Updates(map[string]interface{}{
+			"location_id": loc.ID,
+			"city":        loc.Name,
+		}).Error
+		if err != nil {
+			return updated, fmt.Errorf("failed to update subscription %s: %w", sub.ID, err)
+		}
+		updated++
+	}
+
+	return updated, nil
+}
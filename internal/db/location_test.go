@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"weatherApi/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestBackfillLocations verifies that existing free-text cities are linked to
+// canonical locations, that equivalent spellings share one row and are resolved once,
+// and that unknown cities are left for a later run.
+func TestBackfillLocations(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	_, err = Migrate(gdb)
+	require.NoError(t, err)
+
+	for i, city := range []string{"kyiv", "Kyiv ", "Atlantis"} {
+		require.NoError(t, gdb.Create(&model.Subscription{
+			ID:        string(rune('a' + i)),
+			Email:     string(rune('a'+i)) + "@example.com",
+			City:      city,
+			Frequency: "daily",
+			Token:     "token",
+			CreatedAt: time.Now(),
+		}).Error)
+	}
+
+	calls := 0
+	resolve := func(_ context.Context, query string) (*model.Location, error) {
+		calls++
+		if strings.EqualFold(strings.TrimSpace(query), "kyiv") {
+			return &model.Location{ProviderID: 1, Name: "Kyiv", Country: "Ukraine", Timezone: "Europe/Kyiv"}, nil
+		}
+		return nil, nil
+	}
+
+	updated, err := BackfillLocations(context.Background(), gdb, resolve)
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated)
+	assert.Equal(t, 2, calls, "equivalent city spellings should be resolved once")
+
+	var subs []model.Subscription
+	require.NoError(t, gdb.Order("id").Find(&subs).Error)
+	require.NotNil(t, subs[0].LocationID)
+	require.NotNil(t, subs[1].LocationID)
+	assert.Equal(t, *subs[0].LocationID, *subs[1].LocationID)
+	assert.Equal(t, "Kyiv", subs[1].City)
+	assert.Nil(t, subs[2].LocationID)
+	assert.Equal(t, "Atlantis", subs[2].City)
+}
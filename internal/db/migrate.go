@@ -0,0 +1,370 @@
+package db
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the Postgres advisory lock held while migrating,
+// so that replicas starting together don't apply the same version twice.
+const migrationLockKey = 0x77656174686572 // "weather"
+
+// Waiting for the migration lock gives up after migrationLockTimeout, so a stuck
+// session holding it fails the deploy instead of hanging every replica.
+const (
+	migrationLockTimeout = 2 * time.Minute
+	migrationLockPoll    = time.Second
+)
+
+// baselineColumns are the subscriptions columns added while the schema was still
+// created by AutoMigrate, so databases from before them lack them. 0001_initial needs
+// them: the Postgres script adds missing ones itself, and on SQLite, which has no
+// ADD COLUMN IF NOT EXISTS, addBaselineColumns does.
+var baselineColumns = []struct{ name, definition string }{
+	{"location_id", "text REFERENCES locations (id)"},
+	{"channel", "text NOT NULL DEFAULT 'email'"},
+	{"webhook_url", "text"},
+	{"webhook_secret", "text"},
+	{"confirmation_sent_at", "datetime"},
+}
+
+// Migration is one versioned schema change with its up and down scripts.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// SchemaMigration records an applied migration in the schema_migrations table.
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"` // Migration version, e.g. 1 for 0001_initial
+	Name      string    `gorm:"not null"`                       // Migration name without the version prefix
+	AppliedAt time.Time `gorm:"not null"`                       // When the up script ran
+}
+
+// MigrationStatus describes a known migration and whether it is applied.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // nil while pending
+}
+
+// LoadMigrations reads the embedded migrations for the given dialect ("postgres" or "sqlite"),
+// sorted by version. Every version needs both an .up.sql and a .down.sql file.
+func LoadMigrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %q: %v", dialect, err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		file := e.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(file, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name %q", file)
+		}
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q", file)
+		}
+
+		body, err := fs.ReadFile(migrationFiles, path.Join(dir, file))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down scripts", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrate applies all pending migrations in version order, each in its own transaction.
+// It returns the number of migrations applied.
+func Migrate(gdb *gorm.DB) (applied int, err error) {
+	migrations, err := LoadMigrations(gdb.Dialector.Name())
+	if err != nil {
+		return 0, err
+	}
+
+	err = withMigrationLock(gdb, func(conn *gorm.DB) error {
+		done, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+			if err := conn.Transaction(func(tx *gorm.DB) error {
+				if m.Version == 1 && tx.Dialector.Name() == "sqlite" {
+					if err := addBaselineColumns(tx); err != nil {
+						return err
+					}
+				}
+				if err := execScript(tx, m.Up); err != nil {
+					return err
+				}
+				return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}).Error
+			}); err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %v", m.Version, m.Name, err)
+			}
+			slog.Info("applied migration", "version", m.Version, "name", m.Name)
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Rollback reverts the latest steps applied migrations, newest first.
+// It returns the number of migrations rolled back.
+func Rollback(gdb *gorm.DB, steps int) (rolledBack int, err error) {
+	migrations, err := LoadMigrations(gdb.Dialector.Name())
+	if err != nil {
+		return 0, err
+	}
+	known := make(map[int]Migration, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = m
+	}
+
+	err = withMigrationLock(gdb, func(conn *gorm.DB) error {
+		var latest []SchemaMigration
+		if err := conn.Order("version DESC").Limit(steps).Find(&latest).Error; err != nil {
+			return err
+		}
+
+		for _, sm := range latest {
+			m, ok := known[sm.Version]
+			if !ok {
+				return fmt.Errorf("migration %04d_%s is applied but unknown to this binary", sm.Version, sm.Name)
+			}
+			if err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := execScript(tx, m.Down); err != nil {
+					return err
+				}
+				return tx.Delete(&SchemaMigration{}, "version = ?", m.Version).Error
+			}); err != nil {
+				return fmt.Errorf("rollback of %04d_%s failed: %v", m.Version, m.Name, err)
+			}
+			slog.Info("rolled back migration", "version", m.Version, "name", m.Name)
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status lists the known migrations with their applied time, plus any applied
+// versions this binary doesn't know about (e.g. after deploying an older image).
+func Status(gdb *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations(gdb.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(gdb); err != nil {
+		return nil, err
+	}
+
+	var rows []SchemaMigration
+	if err := gdb.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	known := make(map[int]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+		s := MigrationStatus{Version: m.Version, Name: m.Name}
+		if r, ok := applied[m.Version]; ok {
+			s.AppliedAt = &r.AppliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	for _, r := range rows {
+		if !known[r.Version] {
+			statuses = append(statuses, MigrationStatus{Version: r.Version, Name: r.Name + " (unknown)", AppliedAt: &r.AppliedAt})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// withMigrationLock runs fn on a single pinned connection while holding the migration lock.
+// On Postgres this is a session advisory lock, so other replicas wait and then find nothing
+// left to apply. SQLite is only used for local runs and tests with a single process, so no lock is taken.
+func withMigrationLock(gdb *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return gdb.Connection(func(conn *gorm.DB) error {
+		if conn.Dialector.Name() == "postgres" {
+			if err := acquireMigrationLock(conn, migrationLockTimeout); err != nil {
+				return err
+			}
+			defer releaseMigrationLock(conn)
+		}
+
+		if err := ensureMigrationsTable(conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// acquireMigrationLock polls pg_try_advisory_lock until it gets the lock or timeout passes.
+func acquireMigrationLock(conn *gorm.DB, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var locked bool
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", migrationLockKey).Scan(&locked).Error; err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %v", err)
+		}
+		if locked {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for the migration lock", timeout)
+		}
+		slog.Info("waiting for migration lock held by another process")
+		time.Sleep(migrationLockPoll)
+	}
+}
+
+// releaseMigrationLock releases the advisory lock. A failure is only logged: the lock
+// is released anyway when the session ends, but until then other replicas wait for it.
+func releaseMigrationLock(conn *gorm.DB) {
+	var unlocked bool
+	if err := conn.Raw("SELECT pg_advisory_unlock(?)", migrationLockKey).Scan(&unlocked).Error; err != nil {
+		slog.Error("failed to release migration lock", "error", err)
+		return
+	}
+	if !unlocked {
+		slog.Error("migration lock was not held when releasing it")
+	}
+}
+
+// addBaselineColumns adds the baselineColumns missing from an existing SQLite
+// subscriptions table. A new database has no table yet and is left to 0001_initial.
+func addBaselineColumns(tx *gorm.DB) error {
+	var columns []struct{ Name string }
+	if err := tx.Raw("PRAGMA table_info(subscriptions)").Scan(&columns).Error; err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	existing := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		existing[c.Name] = true
+	}
+	for _, c := range baselineColumns {
+		if existing[c.name] {
+			continue
+		}
+		if err := tx.Exec("ALTER TABLE subscriptions ADD COLUMN " + c.name + " " + c.definition).Error; err != nil {
+			return fmt.Errorf("failed to add subscriptions.%s: %v", c.name, err)
+		}
+	}
+	return nil
+}
+
+// ensureMigrationsTable creates the schema_migrations bookkeeping table if it doesn't exist yet.
+func ensureMigrationsTable(gdb *gorm.DB) error {
+	timestamp := "datetime"
+	if gdb.Dialector.Name() == "postgres" {
+		timestamp = "timestamptz"
+	}
+	err := gdb.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       text NOT NULL,
+		applied_at ` + timestamp + ` NOT NULL
+	)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	return nil
+}
+
+// appliedVersions returns the set of versions recorded in schema_migrations.
+func appliedVersions(conn *gorm.DB) (map[int]struct{}, error) {
+	var versions []int
+	if err := conn.Model(&SchemaMigration{}).Pluck("version", &versions).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[int]struct{}, len(versions))
+	for _, v := range versions {
+		done[v] = struct{}{}
+	}
+	return done, nil
+}
+
+// execScript runs each statement of a migration script in order.
+func execScript(tx *gorm.DB, script string) error {
+	for _, stmt := range splitStatements(script) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements splits a script into statements on lines ending with ";".
+// Full-line "--" comments are dropped. Statements must not put ";" at a line end
+// inside string literals or function bodies.
+func splitStatements(script string) []string {
+	var stmts []string
+	var cur strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		cur.WriteString(line)
+		cur.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(cur.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
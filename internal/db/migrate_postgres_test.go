@@ -0,0 +1,39 @@
+//go:build postgres
+
+package db
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMigrate_AdoptsAutoMigratedSchemaPostgres runs testAdoptsAutoMigratedSchema against
+// the Postgres scripts, in a schema of its own that is dropped afterwards.
+//
+// Run with: TEST_POSTGRES_URL=postgres://... go test -tags postgres ./internal/db
+func TestMigrate_AdoptsAutoMigratedSchemaPostgres(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_URL")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_URL not set")
+	}
+	admin, err := Open("postgres", dsn)
+	require.NoError(t, err)
+	schema := "migrate_test_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+	require.NoError(t, admin.Exec("CREATE SCHEMA "+schema).Error)
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	u, err := url.Parse(dsn)
+	require.NoError(t, err)
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	gdb, err := Open("postgres", u.String())
+	require.NoError(t, err)
+
+	testAdoptsAutoMigratedSchema(t, gdb)
+}
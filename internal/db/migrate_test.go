@@ -0,0 +1,195 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"weatherApi/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// legacySubscription is the baseline model.Subscription as the old AutoMigrate boot path
+// first created it: no location, channel, webhook or confirmation-sent columns, and the
+// confirmation flags that migration 0003 replaced by a status column.
+type legacySubscription struct {
+	ID             string `gorm:"primaryKey"`
+	Email          string `gorm:"not null;uniqueIndex"`
+	City           string `gorm:"not null"`
+	Frequency      string `gorm:"type:text;not null"`
+	IsConfirmed    bool   `gorm:"default:false"`
+	IsUnsubscribed bool   `gorm:"default:false"`
+	Token          string `gorm:"not null"`
+	CreatedAt      time.Time
+}
+
+func (legacySubscription) TableName() string { return "subscriptions" }
+
+// legacyDelivery is model.Delivery before migration 0007 added the tracked flag.
+type legacyDelivery struct {
+	ID             string `gorm:"primaryKey"`
+	SubscriptionID string `gorm:"not null;index"`
+	Kind           string `gorm:"type:text;not null"`
+	Status         string `gorm:"type:text;not null"`
+	Error          string
+	CreatedAt      time.Time `gorm:"index"`
+}
+
+func (legacyDelivery) TableName() string { return "deliveries" }
+
+// legacyModels are the models the old AutoMigrate boot path created, i.e. the schema
+// of databases that predate versioned migrations.
+var legacyModels = []any{
+	&model.Location{}, &legacySubscription{}, &model.Condition{}, &model.SentAlert{},
+	&legacyDelivery{}, &model.SchedulerRun{}, &model.RateLimitBucket{}, &model.AuditLog{},
+}
+
+// allModels lists every persisted model; the migrations must provide a column for each field.
+var allModels = []any{
+	&model.Location{}, &model.Subscription{}, &model.Condition{}, &model.SentAlert{},
+	&model.Delivery{}, &model.SchedulerRun{}, &model.RateLimitBucket{}, &model.AuditLog{},
+	&model.OutboxJob{}, &model.SubscriptionEvent{}, &model.Suppression{}, &model.EngagementEvent{},
+	&model.WeatherSnapshot{},
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	return gdb
+}
+
+// TestLoadMigrations verifies that both dialects ship the same versions with up and down scripts.
+func TestLoadMigrations(t *testing.T) {
+	pg, err := LoadMigrations("postgres")
+	require.NoError(t, err)
+	lite, err := LoadMigrations("sqlite")
+	require.NoError(t, err)
+
+	require.NotEmpty(t, pg)
+	require.Len(t, lite, len(pg))
+	for i := range pg {
+		assert.Equal(t, pg[i].Version, lite[i].Version)
+		assert.Equal(t, pg[i].Name, lite[i].Name)
+	}
+
+	_, err = LoadMigrations("mysql")
+	assert.Error(t, err)
+}
+
+// TestMigrate_CoversModels verifies that the migrated schema has a table and column
+// for every model field, and that a second run applies nothing.
+func TestMigrate_CoversModels(t *testing.T) {
+	gdb := openTestDB(t)
+
+	applied, err := Migrate(gdb)
+	require.NoError(t, err)
+	assert.Positive(t, applied)
+
+	for _, m := range allModels {
+		stmt := &gorm.Statement{DB: gdb}
+		require.NoError(t, stmt.Parse(m))
+		require.True(t, gdb.Migrator().HasTable(m), "missing table %s", stmt.Schema.Table)
+		for _, f := range stmt.Schema.Fields {
+			if f.DBName == "" {
+				continue
+			}
+			assert.True(t, gdb.Migrator().HasColumn(m, f.DBName), "missing column %s.%s", stmt.Schema.Table, f.DBName)
+		}
+	}
+
+	applied, err = Migrate(gdb)
+	require.NoError(t, err)
+	assert.Zero(t, applied)
+}
+
+// TestMigrate_AdoptsAutoMigratedSchema runs testAdoptsAutoMigratedSchema on SQLite.
+func TestMigrate_AdoptsAutoMigratedSchema(t *testing.T) {
+	testAdoptsAutoMigratedSchema(t, openTestDB(t))
+}
+
+// testAdoptsAutoMigratedSchema verifies that a database created by the old AutoMigrate
+// boot path is baselined without losing data, that the columns added since then exist
+// afterwards, and that the confirmation flags are converted to statuses with an initial
+// history event each. gdb must be empty.
+func testAdoptsAutoMigratedSchema(t *testing.T, gdb *gorm.DB) {
+	require.NoError(t, gdb.AutoMigrate(legacyModels...))
+	for _, sub := range []legacySubscription{
+		{ID: "sub-1", Email: "a@example.com", City: "Kyiv", Frequency: "daily", Token: "token"},
+		{ID: "sub-2", Email: "b@example.com", City: "Kyiv", Frequency: "daily", Token: "token", IsConfirmed: true},
+		{ID: "sub-3", Email: "c@example.com", City: "Kyiv", Frequency: "daily", Token: "token", IsConfirmed: true, IsUnsubscribed: true},
+	} {
+		sub.CreatedAt = time.Now()
+		require.NoError(t, gdb.Create(&sub).Error)
+	}
+
+	_, err := Migrate(gdb)
+	require.NoError(t, err)
+
+	var subs []model.Subscription
+	require.NoError(t, gdb.Order("id").Find(&subs).Error)
+	require.Len(t, subs, 3)
+	assert.Equal(t, model.StatusPending, subs[0].Status)
+	assert.Equal(t, model.StatusActive, subs[1].Status)
+	assert.Equal(t, model.StatusUnsubscribed, subs[2].Status)
+
+	var events int64
+	gdb.Model(&model.SubscriptionEvent{}).Where("from_status = '' AND actor = ?", model.ActorSystem).Count(&events)
+	assert.Equal(t, int64(3), events)
+
+	for _, column := range []string{"location_id", "channel", "webhook_url", "webhook_secret", "confirmation_sent_at"} {
+		assert.True(t, gdb.Migrator().HasColumn(&model.Subscription{}, column), "missing column %s", column)
+	}
+	assert.Equal(t, "email", subs[0].Channel)
+
+	statuses, err := Status(gdb)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.NotNil(t, s.AppliedAt, "migration %04d_%s not applied", s.Version, s.Name)
+	}
+}
+
+// TestRollback verifies that down scripts revert the schema and that it can be re-applied.
+func TestRollback(t *testing.T) {
+	gdb := openTestDB(t)
+	applied, err := Migrate(gdb)
+	require.NoError(t, err)
+
+	rolledBack, err := Rollback(gdb, applied)
+	require.NoError(t, err)
+	assert.Equal(t, applied, rolledBack)
+	assert.False(t, gdb.Migrator().HasTable(&model.Subscription{}))
+
+	statuses, err := Status(gdb)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.Nil(t, s.AppliedAt)
+	}
+
+	rolledBack, err = Rollback(gdb, 1)
+	require.NoError(t, err)
+	assert.Zero(t, rolledBack)
+
+	_, err = Migrate(gdb)
+	require.NoError(t, err)
+	assert.True(t, gdb.Migrator().HasTable(&model.Subscription{}))
+}
+
+// TestSplitStatements verifies that scripts are split on line-ending semicolons and comments are dropped.
+func TestSplitStatements(t *testing.T) {
+	script := `-- comment
+CREATE TABLE a (
+    id text -- inline
+);
+
+CREATE INDEX idx_a ON a (id);
+UPDATE a SET id = 'x'`
+
+	stmts := splitStatements(script)
+	require.Len(t, stmts, 3)
+	assert.Equal(t, "CREATE TABLE a (\n    id text -- inline\n);", stmts[0])
+	assert.Equal(t, "CREATE INDEX idx_a ON a (id);", stmts[1])
+	assert.Equal(t, "UPDATE a SET id = 'x'", stmts[2])
+}
@@ -0,0 +1,100 @@
+-- Initial schema, matching what GORM AutoMigrate produced before versioned migrations.
+-- IF NOT EXISTS lets databases created by AutoMigrate adopt this version without changes.
+-- Databases from before the location and webhook columns get them from the ALTER TABLEs.
+
+CREATE TABLE IF NOT EXISTS locations (
+    id          text PRIMARY KEY,
+    provider_id bigint NOT NULL,
+    name        text NOT NULL,
+    region      text,
+    country     text NOT NULL,
+    lat         decimal,
+    lon         decimal,
+    timezone    text,
+    created_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_locations_provider_id ON locations (provider_id);
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id                   text PRIMARY KEY,
+    email                text NOT NULL,
+    city                 text NOT NULL,
+    location_id          text,
+    frequency            text NOT NULL,
+    channel              text NOT NULL DEFAULT 'email',
+    webhook_url          text,
+    webhook_secret       text,
+    is_confirmed         boolean DEFAULT false,
+    is_unsubscribed      boolean DEFAULT false,
+    confirmation_sent_at timestamptz,
+    token                text NOT NULL,
+    created_at           timestamptz,
+    CONSTRAINT fk_subscriptions_location FOREIGN KEY (location_id) REFERENCES locations (id)
+);
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS location_id text CONSTRAINT fk_subscriptions_location REFERENCES locations (id);
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS channel text NOT NULL DEFAULT 'email';
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS webhook_url text;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS webhook_secret text;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS confirmation_sent_at timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriptions_email ON subscriptions (email);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_location_id ON subscriptions (location_id);
+
+CREATE TABLE IF NOT EXISTS conditions (
+    id              text PRIMARY KEY,
+    subscription_id text NOT NULL,
+    metric          text NOT NULL,
+    operator        text NOT NULL,
+    value           decimal NOT NULL,
+    CONSTRAINT fk_subscriptions_conditions FOREIGN KEY (subscription_id) REFERENCES subscriptions (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_conditions_subscription_id ON conditions (subscription_id);
+
+CREATE TABLE IF NOT EXISTS sent_alerts (
+    subscription_id text,
+    alert_id        text,
+    sent_at         timestamptz,
+    PRIMARY KEY (subscription_id, alert_id)
+);
+
+CREATE TABLE IF NOT EXISTS deliveries (
+    id              text PRIMARY KEY,
+    subscription_id text NOT NULL,
+    kind            text NOT NULL,
+    status          text NOT NULL,
+    error           text,
+    created_at      timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_deliveries_subscription_id ON deliveries (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_deliveries_created_at ON deliveries (created_at);
+
+CREATE TABLE IF NOT EXISTS scheduler_runs (
+    id          text PRIMARY KEY,
+    kind        text NOT NULL,
+    started_at  timestamptz,
+    finished_at timestamptz,
+    processed   bigint,
+    skipped     bigint,
+    failed      bigint
+);
+CREATE INDEX IF NOT EXISTS idx_scheduler_runs_started_at ON scheduler_runs (started_at);
+
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key        text PRIMARY KEY,
+    tokens     decimal NOT NULL,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id         text PRIMARY KEY,
+    actor      text NOT NULL,
+    role       text NOT NULL,
+    action     text NOT NULL,
+    target_id  text,
+    details    text,
+    ip         text,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor ON audit_logs (actor);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target_id ON audit_logs (target_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
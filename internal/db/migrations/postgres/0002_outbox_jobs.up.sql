@@ -0,0 +1,11 @@
+CREATE TABLE outbox_jobs (
+    id              text PRIMARY KEY,
+    kind            text NOT NULL,
+    subscription_id text NOT NULL,
+    attempts        bigint NOT NULL DEFAULT 0,
+    run_at          timestamptz,
+    last_error      text,
+    created_at      timestamptz
+);
+CREATE INDEX idx_outbox_jobs_subscription_id ON outbox_jobs (subscription_id);
+CREATE INDEX idx_outbox_jobs_run_at ON outbox_jobs (run_at);
@@ -0,0 +1,21 @@
+ALTER TABLE subscriptions ADD COLUMN status text NOT NULL DEFAULT 'pending';
+UPDATE subscriptions SET status = CASE WHEN is_unsubscribed THEN 'unsubscribed' WHEN is_confirmed THEN 'active' ELSE 'pending' END;
+ALTER TABLE subscriptions DROP COLUMN is_confirmed;
+ALTER TABLE subscriptions DROP COLUMN is_unsubscribed;
+CREATE INDEX idx_subscriptions_status ON subscriptions (status);
+
+CREATE TABLE subscription_events (
+    id              text PRIMARY KEY,
+    subscription_id text NOT NULL,
+    from_status     text,
+    to_status       text NOT NULL,
+    actor           text NOT NULL,
+    reason          text,
+    created_at      timestamptz
+);
+CREATE INDEX idx_subscription_events_subscription_id ON subscription_events (subscription_id);
+CREATE INDEX idx_subscription_events_created_at ON subscription_events (created_at);
+
+-- Start every existing subscription's history with its current status
+INSERT INTO subscription_events (id, subscription_id, from_status, to_status, actor, reason, created_at)
+SELECT gen_random_uuid()::text, id, '', status, 'system', 'migrated from confirmation flags', now() FROM subscriptions;
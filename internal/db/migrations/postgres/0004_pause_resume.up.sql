@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions ADD COLUMN resume_on timestamptz;
+CREATE INDEX idx_subscriptions_resume_on ON subscriptions (resume_on);
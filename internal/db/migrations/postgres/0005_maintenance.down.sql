@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions DROP COLUMN anonymized_at;
+ALTER TABLE subscriptions DROP COLUMN reminder_sent_at;
@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions ADD COLUMN reminder_sent_at timestamptz;
+ALTER TABLE subscriptions ADD COLUMN anonymized_at timestamptz;
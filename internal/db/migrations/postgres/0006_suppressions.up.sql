@@ -0,0 +1,5 @@
+CREATE TABLE suppressions (
+    email_hash text PRIMARY KEY,
+    reason     text NOT NULL,
+    created_at timestamptz
+);
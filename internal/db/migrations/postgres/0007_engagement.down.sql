@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS engagement_events;
+ALTER TABLE deliveries DROP COLUMN tracked;
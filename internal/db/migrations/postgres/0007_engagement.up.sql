@@ -0,0 +1,12 @@
+ALTER TABLE deliveries ADD COLUMN tracked boolean NOT NULL DEFAULT false;
+
+CREATE TABLE engagement_events (
+    id              text PRIMARY KEY,
+    delivery_id     text NOT NULL,
+    subscription_id text NOT NULL,
+    kind            text NOT NULL,
+    url             text,
+    created_at      timestamptz
+);
+CREATE INDEX idx_engagement_events_delivery_id ON engagement_events (delivery_id);
+CREATE INDEX idx_engagement_events_subscription_id ON engagement_events (subscription_id);
@@ -0,0 +1,7 @@
+CREATE TABLE weather_snapshots (
+    subscription_id text PRIMARY KEY,
+    temperature     decimal,
+    description     text,
+    precipitation   decimal,
+    sent_at         timestamptz
+);
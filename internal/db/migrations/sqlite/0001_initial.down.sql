@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS rate_limit_buckets;
+DROP TABLE IF EXISTS scheduler_runs;
+DROP TABLE IF EXISTS deliveries;
+DROP TABLE IF EXISTS sent_alerts;
+DROP TABLE IF EXISTS conditions;
+DROP TABLE IF EXISTS subscriptions;
+DROP TABLE IF EXISTS locations;
@@ -0,0 +1,96 @@
+-- Initial schema, matching what GORM AutoMigrate produced before versioned migrations.
+-- IF NOT EXISTS lets databases created by AutoMigrate adopt this version without changes.
+-- Columns missing from databases before the location and webhook columns are added in Go
+-- first (see baselineColumns), since SQLite has no ADD COLUMN IF NOT EXISTS.
+
+CREATE TABLE IF NOT EXISTS locations (
+    id          text PRIMARY KEY,
+    provider_id integer NOT NULL,
+    name        text NOT NULL,
+    region      text,
+    country     text NOT NULL,
+    lat         real,
+    lon         real,
+    timezone    text,
+    created_at  datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_locations_provider_id ON locations (provider_id);
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id                   text PRIMARY KEY,
+    email                text NOT NULL,
+    city                 text NOT NULL,
+    location_id          text,
+    frequency            text NOT NULL,
+    channel              text NOT NULL DEFAULT 'email',
+    webhook_url          text,
+    webhook_secret       text,
+    is_confirmed         numeric DEFAULT false,
+    is_unsubscribed      numeric DEFAULT false,
+    confirmation_sent_at datetime,
+    token                text NOT NULL,
+    created_at           datetime,
+    CONSTRAINT fk_subscriptions_location FOREIGN KEY (location_id) REFERENCES locations (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriptions_email ON subscriptions (email);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_location_id ON subscriptions (location_id);
+
+CREATE TABLE IF NOT EXISTS conditions (
+    id              text PRIMARY KEY,
+    subscription_id text NOT NULL,
+    metric          text NOT NULL,
+    operator        text NOT NULL,
+    value           real NOT NULL,
+    CONSTRAINT fk_subscriptions_conditions FOREIGN KEY (subscription_id) REFERENCES subscriptions (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_conditions_subscription_id ON conditions (subscription_id);
+
+CREATE TABLE IF NOT EXISTS sent_alerts (
+    subscription_id text,
+    alert_id        text,
+    sent_at         datetime,
+    PRIMARY KEY (subscription_id, alert_id)
+);
+
+CREATE TABLE IF NOT EXISTS deliveries (
+    id              text PRIMARY KEY,
+    subscription_id text NOT NULL,
+    kind            text NOT NULL,
+    status          text NOT NULL,
+    error           text,
+    created_at      datetime
+);
+CREATE INDEX IF NOT EXISTS idx_deliveries_subscription_id ON deliveries (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_deliveries_created_at ON deliveries (created_at);
+
+CREATE TABLE IF NOT EXISTS scheduler_runs (
+    id          text PRIMARY KEY,
+    kind        text NOT NULL,
+    started_at  datetime,
+    finished_at datetime,
+    processed   integer,
+    skipped     integer,
+    failed      integer
+);
+CREATE INDEX IF NOT EXISTS idx_scheduler_runs_started_at ON scheduler_runs (started_at);
+
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key        text PRIMARY KEY,
+    tokens     real NOT NULL,
+    updated_at datetime
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id         text PRIMARY KEY,
+    actor      text NOT NULL,
+    role       text NOT NULL,
+    action     text NOT NULL,
+    target_id  text,
+    details    text,
+    ip         text,
+    created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor ON audit_logs (actor);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target_id ON audit_logs (target_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
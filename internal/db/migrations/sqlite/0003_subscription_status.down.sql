@@ -0,0 +1,7 @@
+DROP TABLE subscription_events;
+
+ALTER TABLE subscriptions ADD COLUMN is_confirmed numeric DEFAULT false;
+ALTER TABLE subscriptions ADD COLUMN is_unsubscribed numeric DEFAULT false;
+UPDATE subscriptions SET is_confirmed = status IN ('active', 'paused', 'unsubscribed'), is_unsubscribed = status IN ('unsubscribed', 'bounced', 'expired');
+DROP INDEX idx_subscriptions_status;
+ALTER TABLE subscriptions DROP COLUMN status;
@@ -0,0 +1,2 @@
+DROP INDEX idx_subscriptions_resume_on;
+ALTER TABLE subscriptions DROP COLUMN resume_on;
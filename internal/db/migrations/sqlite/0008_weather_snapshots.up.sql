@@ -0,0 +1,7 @@
+CREATE TABLE weather_snapshots (
+    subscription_id text PRIMARY KEY,
+    temperature     real,
+    description     text,
+    precipitation   real,
+    sent_at         datetime
+);
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// AuditLog records a single action performed through the admin API.
+// Rows are append-only and are never exposed through public endpoints.
+type AuditLog struct {
+	ID        string    `gorm:"primaryKey" json:"id"`         // UUID stored as string for compatibility
+	Actor     string    `gorm:"not null;index" json:"actor"`  // Name of the admin API key used
+	Role      string    `gorm:"not null" json:"role"`         // Role of the key at the time of the action
+	Action    string    `gorm:"not null;index" json:"action"` // e.g. "subscription.confirm"
+	TargetID  string    `gorm:"index" json:"target_id"`       // Affected subscription ID, if any
+	Details   string    `json:"details"`                      // Free-form context (filters, email looked up, ...)
+	IP        string    `json:"ip"`                           // Client IP of the request
+	CreatedAt time.Time `gorm:"index" json:"created_at"`      // When the action happened
+}
@@ -0,0 +1,12 @@
+package model
+
+// City represents a single location candidate returned by the city search.
+// It carries enough detail to tell apart places that share a name (e.g. Paris, France vs. Paris, Texas).
+type City struct {
+	ID      int     `json:"id"`      // Provider-specific location ID
+	Name    string  `json:"name"`    // City name
+	Region  string  `json:"region"`  // Region, state or province
+	Country string  `json:"country"` // Country name
+	Lat     float64 `json:"lat"`     // Latitude in decimal degrees
+	Lon     float64 `json:"lon"`     // Longitude in decimal degrees
+}
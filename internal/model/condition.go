@@ -0,0 +1,103 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Supported condition metrics.
+const (
+	MetricTemperature   = "temperature"   // Degrees Celsius
+	MetricPrecipitation = "precipitation" // Chance of rain in percent
+	MetricWind          = "wind"          // Wind speed in km/h
+)
+
+// Supported condition operators.
+const (
+	OperatorAbove = "above"
+	OperatorBelow = "below"
+)
+
+// Condition is an optional rule attached to a subscription.
+// When a subscription has conditions, updates are sent only if at least one
+// of them matches the forecast (e.g. "precipitation above 60" — tell me if it rains).
+//
+// Conditions are written as "<metric><op><value>", e.g. "temperature>30",
+// "temperature<0", "precipitation>60" or "wind>40".
+type Condition struct {
+	ID             string  `gorm:"primaryKey" json:"-"`                // UUID stored as string for compatibility
+	SubscriptionID string  `gorm:"not null;index" json:"-"`            // Owning subscription
+	Metric         string  `gorm:"type:text;not null" json:"metric"`   // One of the Metric* constants
+	Operator       string  `gorm:"type:text;not null" json:"operator"` // One of the Operator* constants
+	Value          float64 `gorm:"not null" json:"value"`              // Threshold in the metric's unit
+}
+
+// metricRanges defines the accepted threshold range per metric.
+var metricRanges = map[string][2]float64{
+	MetricTemperature:   {-90, 60},
+	MetricPrecipitation: {0, 100},
+	MetricWind:          {0, 400},
+}
+
+// ParseCondition parses a rule such as "temperature>30" into a Condition.
+// Returns an error for unknown metrics, operators or out-of-range values.
+func ParseCondition(raw string) (Condition, error) {
+	raw = strings.ReplaceAll(raw, " ", "")
+
+	idx := strings.IndexAny(raw, "<>")
+	if idx <= 0 || idx == len(raw)-1 {
+		return Condition{}, fmt.Errorf("invalid condition %q", raw)
+	}
+
+	metric := strings.ToLower(raw[:idx])
+	limits, ok := metricRanges[metric]
+	if !ok {
+		return Condition{}, fmt.Errorf("unknown condition metric %q", metric)
+	}
+
+	operator := OperatorAbove
+	if raw[idx] == '<' {
+		operator = OperatorBelow
+	}
+
+	value, err := strconv.ParseFloat(raw[idx+1:], 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return Condition{}, fmt.Errorf("invalid condition value in %q", raw)
+	}
+	if value < limits[0] || value > limits[1] {
+		return Condition{}, fmt.Errorf("condition value for %s must be between %g and %g", metric, limits[0], limits[1])
+	}
+
+	return Condition{Metric: metric, Operator: operator, Value: value}, nil
+}
+
+// Matches reports whether the condition holds for the given forecast hour.
+func (c Condition) Matches(h ForecastHour) bool {
+	var actual float64
+	switch c.Metric {
+	case MetricTemperature:
+		actual = h.TempC
+	case MetricPrecipitation:
+		actual = float64(h.ChanceOfRain)
+	case MetricWind:
+		actual = h.WindKph
+	default:
+		return false
+	}
+
+	if c.Operator == OperatorBelow {
+		return actual < c.Value
+	}
+	return actual > c.Value
+}
+
+// String returns the condition in its "<metric><op><value>" form.
+func (c Condition) String() string {
+	op := ">"
+	if c.Operator == OperatorBelow {
+		op = "<"
+	}
+	return fmt.Sprintf("%s%s%g", c.Metric, op, c.Value)
+}
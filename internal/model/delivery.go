@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Delivery statuses.
+const (
+	DeliveryPending = "pending" // Recorded before sending; stays pending if the process dies mid-send
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+)
+
+// Delivery records a single attempt to deliver a notification to a subscription.
+// Used for the per-subscriber delivery history in the admin dashboard.
+type Delivery struct {
+	ID             string    `gorm:"primaryKey" json:"id"`                  // UUID stored as string for compatibility
+	SubscriptionID string    `gorm:"not null;index" json:"subscription_id"` // Recipient subscription
+	Kind           string    `gorm:"type:text;not null" json:"kind"`        // Notification kind, e.g. "weather.update"
+	Status         string    `gorm:"type:text;not null" json:"status"`      // DeliveryPending, DeliverySent or DeliveryFailed
+	Error          string    `json:"error,omitempty"`                       // Failure reason, if any
+	Tracked        bool      `gorm:"not null;default:false" json:"tracked"` // Sent with open and click tracking
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`               // When the attempt started
+}
+
+// SchedulerRun records one execution of a scheduler job and its outcome counts.
+type SchedulerRun struct {
+	ID         string     `gorm:"primaryKey" json:"id"`           // UUID stored as string for compatibility
+	Kind       string     `gorm:"type:text;not null" json:"kind"` // "hourly", "daily" or "alerts"
+	StartedAt  time.Time  `gorm:"index" json:"started_at"`        // When the run began
+	FinishedAt *time.Time `json:"finished_at"`                    // Nil while the run is in progress
+	Processed  int        `json:"processed"`                      // Subscriptions handled successfully
+	Skipped    int        `json:"skipped"`                        // Subscriptions with nothing to send
+	Failed     int        `json:"failed"`                         // Subscriptions that failed
+}
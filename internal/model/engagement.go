@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Engagement event kinds.
+const (
+	EngagementOpen  = "open"  // The tracking pixel was loaded
+	EngagementClick = "click" // A link was followed through the click redirect
+)
+
+// EngagementEvent records an open or click of a tracked email.
+// Only recorded when engagement tracking is enabled.
+type EngagementEvent struct {
+	ID             string    `gorm:"primaryKey" json:"id"`                  // UUID stored as string for compatibility
+	DeliveryID     string    `gorm:"not null;index" json:"delivery_id"`     // Tracked delivery
+	SubscriptionID string    `gorm:"not null;index" json:"subscription_id"` // Recipient subscription
+	Kind           string    `gorm:"type:text;not null" json:"kind"`        // EngagementOpen or EngagementClick
+	URL            string    `json:"url,omitempty"`                         // Link target of a click
+	CreatedAt      time.Time `json:"created_at"`                            // When it happened
+}
+
+// EngagementScore summarizes how a subscriber reacts to tracked emails.
+type EngagementScore struct {
+	Tracked       int        `json:"tracked"`                   // Tracked emails sent
+	Opened        int        `json:"opened"`                    // Of these, opened or clicked at least once
+	Clicked       int        `json:"clicked"`                   // Of these, with at least one click
+	Score         float64    `json:"score"`                     // Opened / Tracked, 0 without tracked emails
+	LastEngagedAt *time.Time `json:"last_engaged_at,omitempty"` // Latest open or click, if any
+}
@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// Forecast represents a multi-day forecast for a single location,
+// together with any active severe weather alerts issued for it.
+type Forecast struct {
+	Days   []ForecastDay `json:"days"`   // One entry per forecast day, starting today
+	Alerts []Alert       `json:"alerts"` // Active government/provider alerts (may be empty)
+}
+
+// ForecastDay holds the daily summary and hourly breakdown for one day.
+type ForecastDay struct {
+	Date         string         `json:"date"`           // Local date in YYYY-MM-DD format
+	MaxTempC     float64        `json:"max_temp_c"`     // Daily high in degrees Celsius
+	MinTempC     float64        `json:"min_temp_c"`     // Daily low in degrees Celsius
+	ChanceOfRain int            `json:"chance_of_rain"` // Daily chance of rain in percent (0–100)
+	Description  string         `json:"description"`    // Short text description of the day
+	Sunrise      string         `json:"sunrise"`        // Local sunrise time as reported by the provider (e.g. "06:12 AM")
+	Sunset       string         `json:"sunset"`         // Local sunset time as reported by the provider
+	Hours        []ForecastHour `json:"hours"`          // Hourly forecast for the day
+}
+
+// ForecastHour holds the forecast for a single hour.
+type ForecastHour struct {
+	Time         time.Time `json:"time"`           // Start of the hour (UTC)
+	TempC        float64   `json:"temp_c"`         // Temperature in degrees Celsius
+	ChanceOfRain int       `json:"chance_of_rain"` // Chance of rain in percent (0–100)
+	WindKph      float64   `json:"wind_kph"`       // Wind speed in km/h
+	Description  string    `json:"description"`    // Short text description
+}
+
+// Alert represents a severe weather warning (storm, heat, frost, ...) for a location.
+// The provider does not assign stable IDs, so ID is derived from the alert content
+// and is used to avoid sending the same warning twice.
+type Alert struct {
+	ID          string    `json:"id"`          // Content-derived identifier used for deduplication
+	Headline    string    `json:"headline"`    // Short headline
+	Event       string    `json:"event"`       // Event type, e.g. "Severe Thunderstorm Warning"
+	Severity    string    `json:"severity"`    // Severity as reported by the issuer, e.g. "Moderate", "Severe"
+	Areas       string    `json:"areas"`       // Affected areas
+	Description string    `json:"description"` // Full alert text
+	Instruction string    `json:"instruction"` // Recommended actions, if provided
+	Effective   time.Time `json:"effective"`   // Start of validity
+	Expires     time.Time `json:"expires"`     // End of validity (zero if unknown)
+}
+
+// SentAlert records that an alert has been delivered to a subscription.
+// The composite primary key guarantees each alert is sent at most once per subscriber.
+type SentAlert struct {
+	SubscriptionID string    `gorm:"primaryKey" json:"subscription_id"` // Recipient subscription
+	AlertID        string    `gorm:"primaryKey" json:"alert_id"`        // Alert.ID
+	SentAt         time.Time `json:"sent_at"`                           // When the alert email was sent
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Location represents a canonical, provider-resolved place that subscriptions refer to.
+// Free-text input such as "kyiv", "Kyiv " or "Kiev" resolves to the same Location,
+// so caching, batching and reporting can group subscriptions reliably.
+//
+// Notes:
+// - ProviderID is the weather provider's location ID and is unique per row.
+// - Timezone is an IANA name (e.g. "Europe/Kyiv") as reported by the provider.
+type Location struct {
+	ID         string    `gorm:"primaryKey" json:"id"`                    // UUID stored as string for compatibility
+	ProviderID int       `gorm:"not null;uniqueIndex" json:"provider_id"` // Weather provider location ID
+	Name       string    `gorm:"not null" json:"name"`                    // Canonical city name
+	Region     string    `json:"region"`                                  // Region, state or province
+	Country    string    `gorm:"not null" json:"country"`                 // Country name
+	Lat        float64   `json:"lat"`                                     // Latitude in decimal degrees
+	Lon        float64   `json:"lon"`                                     // Longitude in decimal degrees
+	Timezone   string    `json:"timezone"`                                // IANA timezone name
+	CreatedAt  time.Time `json:"created_at"`                              // Timestamp of first resolution
+}
+
+// Query returns the provider query string that addresses this exact location.
+func (l Location) Query() string {
+	return fmt.Sprintf("id:%d", l.ProviderID)
+}
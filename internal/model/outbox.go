@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Outbox job kinds.
+const (
+	JobWelcome = "welcome" // First forecast after a subscription is confirmed
+)
+
+// OutboxJob is a unit of background work written in the same transaction as the
+// state change that caused it, and executed later by the scheduler's outbox worker.
+// Failed jobs are retried with backoff until they run out of attempts.
+type OutboxJob struct {
+	ID             string    `gorm:"primaryKey" json:"id"`                  // UUID stored as string for compatibility
+	Kind           string    `gorm:"type:text;not null" json:"kind"`        // One of the Job* constants
+	SubscriptionID string    `gorm:"not null;index" json:"subscription_id"` // Subscription the job is for
+	Attempts       int       `gorm:"not null" json:"attempts"`              // Executions started so far
+	RunAt          time.Time `gorm:"index" json:"run_at"`                   // Earliest time of the next execution
+	LastError      string    `json:"last_error,omitempty"`                  // Failure reason of the last attempt
+	CreatedAt      time.Time `json:"created_at"`                            // When the job was enqueued
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// RateLimitBucket stores the state of a single token bucket for the DB-backed rate limiter.
+// Shared by all replicas, so limits hold across the whole deployment.
+type RateLimitBucket struct {
+	Key       string    `gorm:"primaryKey" json:"key"`  // e.g. "subscribe:ip:203.0.113.7"
+	Tokens    float64   `gorm:"not null" json:"tokens"` // Tokens left after the last refill
+	UpdatedAt time.Time `json:"updated_at"`             // Time of the last refill
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Subscription statuses.
+const (
+	StatusPending      = "pending"      // Waiting for the subscriber to confirm
+	StatusActive       = "active"       // Confirmed; receives updates
+	StatusPaused       = "paused"       // Confirmed, but updates are on hold
+	StatusUnsubscribed = "unsubscribed" // Opted out by the subscriber or an admin
+	StatusBounced      = "bounced"      // The address stopped accepting email
+	StatusExpired      = "expired"      // Never confirmed in time
+)
+
+// Statuses lists every subscription status.
+var Statuses = []string{StatusPending, StatusActive, StatusPaused, StatusUnsubscribed, StatusBounced, StatusExpired}
+
+// Actors recorded on subscription events. Admin changes use AdminActor.
+const (
+	ActorSubscriber = "subscriber" // Through the public API or an email link
+	ActorScheduler  = "scheduler"  // Background jobs
+	ActorSystem     = "system"     // Migrations and other maintenance
+)
+
+// AdminActor returns the actor recorded for a change made with the named admin key.
+func AdminActor(keyName string) string {
+	return "admin:" + keyName
+}
+
+// ErrInvalidTransition is returned for a status change the state machine doesn't allow.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// transitions maps each status to the statuses it may change to.
+// Pending -> pending is a resubscribe that issues a new confirmation token.
+var transitions = map[string][]string{
+	StatusPending:      {StatusPending, StatusActive, StatusUnsubscribed, StatusBounced, StatusExpired},
+	StatusActive:       {StatusPaused, StatusUnsubscribed, StatusBounced},
+	StatusPaused:       {StatusActive, StatusUnsubscribed, StatusBounced},
+	StatusUnsubscribed: {StatusPending},
+	StatusBounced:      {StatusPending, StatusUnsubscribed},
+	StatusExpired:      {StatusPending, StatusUnsubscribed},
+}
+
+// ValidStatus reports whether status is one of the Status* constants.
+func ValidStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// ValidateTransition returns nil if a subscription may change from one status to the
+// other, or an error wrapping ErrInvalidTransition.
+func ValidateTransition(from, to string) error {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+}
+
+// SubscriptionEvent records one status change of a subscription.
+// Rows are append-only; FromStatus is empty for the event that created the subscription.
+type SubscriptionEvent struct {
+	ID             string    `gorm:"primaryKey" json:"id"`                  // UUID stored as string for compatibility
+	SubscriptionID string    `gorm:"not null;index" json:"subscription_id"` // Subscription that changed
+	FromStatus     string    `gorm:"type:text" json:"from_status"`          // Status before the change
+	ToStatus       string    `gorm:"type:text;not null" json:"to_status"`   // Status after the change
+	Actor          string    `gorm:"not null" json:"actor"`                 // One of the Actor* constants or AdminActor(...)
+	Reason         string    `json:"reason"`                                // Why the status changed, e.g. "confirmed via email link"
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`               // When the change happened
+}
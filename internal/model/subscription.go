@@ -5,19 +5,47 @@ import (
 )
 
 // Subscription represents a user's weather subscription entry.
-// It stores email, city, frequency, status flags, and metadata.
+// It stores email, city, frequency, status, and metadata.
 //
 // Notes for production:
 // - UUID is stored as a string instead of a native UUID type for compatibility (e.g. SQLite).
 // - Frequency validation is handled in application logic (no DB-level CHECK constraint).
 // - Token is not exposed in JSON (used for confirmation/unsubscribe).
+// - City is a denormalized copy of Location.Name kept for display and for rows not yet backfilled.
+// - Status only changes through SubscriptionRepository.Transition, which validates it and records a SubscriptionEvent.
 type Subscription struct {
-	ID             string    `gorm:"primaryKey" json:"id"`                 // UUID stored as string for compatibility
-	Email          string    `gorm:"not null;uniqueIndex" json:"email"`    // Unique per user
-	City           string    `gorm:"not null" json:"city"`                 // Target city for weather updates
-	Frequency      string    `gorm:"type:text;not null" json:"frequency"`  // "daily" or "hourly" — validated in code
-	IsConfirmed    bool      `gorm:"default:false" json:"is_confirmed"`    // True if user confirmed via email
-	IsUnsubscribed bool      `gorm:"default:false" json:"is_unsubscribed"` // True if user opted out
-	Token          string    `gorm:"not null" json:"-"`                    // Used for confirmation & unsubscribe; hidden from API responses
-	CreatedAt      time.Time `json:"created_at"`                           // Timestamp of subscription
+	ID                 string      `gorm:"primaryKey" json:"id"`                                    // UUID stored as string for compatibility
+	Email              string      `gorm:"not null;uniqueIndex" json:"email"`                       // Unique per user
+	City               string      `gorm:"not null" json:"city"`                                    // Target city for weather updates
+	LocationID         *string     `gorm:"index" json:"location_id"`                                // Resolved canonical location (nil until backfilled)
+	Location           *Location   `json:"location,omitempty"`                                      // Loaded via Preload("Location")
+	Conditions         []Condition `gorm:"constraint:OnDelete:CASCADE" json:"conditions,omitempty"` // Optional send-only-if rules
+	Frequency          string      `gorm:"type:text;not null" json:"frequency"`                     // "daily", "hourly" or "alerts" — validated in code
+	Channel            string      `gorm:"type:text;not null;default:email" json:"channel"`         // "email", "webhook" or "both"
+	WebhookURL         string      `json:"webhook_url,omitempty"`                                   // Target for webhook deliveries
+	WebhookSecret      string      `json:"-"`                                                       // Per-endpoint HMAC signing secret
+	Status             string      `gorm:"type:text;not null;default:pending;index" json:"status"`  // One of the Status* constants
+	ResumeOn           *time.Time  `gorm:"index" json:"resume_on,omitempty"`                        // Paused subscriptions are reactivated on this day (UTC)
+	ConfirmationSentAt *time.Time  `json:"confirmation_sent_at,omitempty"`                          // Last confirmation email, used for resend cooldown
+	ReminderSentAt     *time.Time  `json:"reminder_sent_at,omitempty"`                              // Last confirmation reminder; one is sent per confirmation email
+	AnonymizedAt       *time.Time  `json:"anonymized_at,omitempty"`                                 // Set once the retention policy removed personal data
+	Token              string      `gorm:"not null" json:"-"`                                       // Used for confirmation & unsubscribe; hidden from API responses
+	CreatedAt          time.Time   `json:"created_at"`                                              // Timestamp of subscription
+}
+
+// WeatherQuery returns the provider query for this subscription.
+// Prefers the resolved location and falls back to the stored city text.
+func (s Subscription) WeatherQuery() string {
+	if s.Location != nil {
+		return s.Location.Query()
+	}
+	return s.City
+}
+
+// Timezone returns the IANA timezone of the resolved location, or "" if there is none.
+func (s Subscription) Timezone() string {
+	if s.Location != nil {
+		return s.Location.Timezone
+	}
+	return ""
 }
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Suppression reasons. All but SuppressionErased stop delivery to the address.
+const (
+	SuppressionErased     = "erased"     // The subscriber had all their data erased
+	SuppressionBounce     = "bounce"     // The address hard-bounced
+	SuppressionDropped    = "dropped"    // SendGrid refused to send to the address
+	SuppressionSpamReport = "spamreport" // The recipient marked an email as spam
+)
+
+// DeliverySuppressions lists the reasons that stop delivery to an address.
+var DeliverySuppressions = []string{SuppressionBounce, SuppressionDropped, SuppressionSpamReport}
+
+// Suppression records an address that must not be emailed or asked not to be kept on
+// file, identified only by its keyed hash (emailhash.Sum). Without the server's key the
+// hash cannot be matched to an address.
+type Suppression struct {
+	EmailHash string    `gorm:"primaryKey" json:"email_hash"`     // emailhash.Sum of the address
+	Reason    string    `gorm:"type:text;not null" json:"reason"` // One of the Suppression* constants
+	CreatedAt time.Time `json:"created_at"`                       // When the address was suppressed
+}
@@ -1,8 +1,21 @@
 package model
 
+import "time"
+
 // Weather represents simplified weather data returned to the user.
 type Weather struct {
-	Temperature float64 `json:"temperature"` // Temperature in degrees Celsius
-	Humidity    int     `json:"humidity"`    // Relative humidity in percent (0–100)
-	Description string  `json:"description"` // Short text description (e.g. "Clear", "Rainy")
+	Temperature   float64 `json:"temperature"`                // Temperature in degrees Celsius
+	Humidity      int     `json:"humidity"`                   // Relative humidity in percent (0–100)
+	Description   string  `json:"description"`                // Short text description (e.g. "Clear", "Rainy")
+	Precipitation float64 `json:"precipitation_mm,omitempty"` // Current precipitation in mm
+}
+
+// WeatherSnapshot is the weather last emailed to a subscription. Hourly updates are
+// compared against it to skip emails when nothing changed.
+type WeatherSnapshot struct {
+	SubscriptionID string    `gorm:"primaryKey" json:"subscription_id"` // Recipient subscription
+	Temperature    float64   `json:"temperature"`                       // Degrees Celsius
+	Description    string    `json:"description"`                       // Short text description
+	Precipitation  float64   `json:"precipitation_mm"`                  // Precipitation in mm
+	SentAt         time.Time `json:"sent_at"`                           // When it was sent
 }
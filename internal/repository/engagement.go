@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"weatherApi/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Engagement stores opens and clicks of tracked deliveries in the engagement_events table.
+type Engagement struct {
+	DB *gorm.DB
+}
+
+// NewEngagement returns engagement storage backed by the given database.
+func NewEngagement(db *gorm.DB) *Engagement {
+	return &Engagement{DB: db}
+}
+
+// Record stores an open or click of a delivery. It reports false without storing
+// anything if the delivery doesn't exist or was sent without tracking.
+func (e *Engagement) Record(ctx context.Context, deliveryID, kind, url string, at time.Time) (bool, error) {
+	tx := Conn(ctx, e.DB)
+	var d model.Delivery
+	err := tx.Where("id = ? AND tracked = ?", deliveryID, true).First(&d).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Create(&model.EngagementEvent{
+		ID:             uuid.New().String(),
+		DeliveryID:     deliveryID,
+		SubscriptionID: d.SubscriptionID,
+		Kind:           kind,
+		URL:            url,
+		CreatedAt:      at,
+	}).Error
+}
+
+// Score summarizes the subscription's tracked deliveries sent since the given time.
+func (e *Engagement) Score(ctx context.Context, subscriptionID string, since time.Time) (model.EngagementScore, error) {
+	tx := Conn(ctx, e.DB)
+	var score model.EngagementScore
+	var tracked, opened, clicked int64
+
+	if err := tx.Model(&model.Delivery{}).
+		Where("subscription_id = ? AND tracked = ? AND status = ? AND created_at >= ?", subscriptionID, true, model.DeliverySent, since).
+		Count(&tracked).Error; err != nil {
+		return score, err
+	}
+	engaged := func(kinds ...string) *gorm.DB {
+		return tx.Model(&model.EngagementEvent{}).
+			Joins("JOIN deliveries ON deliveries.id = engagement_events.delivery_id").
+			Where("deliveries.subscription_id = ? AND deliveries.created_at >= ? AND engagement_events.kind IN ?", subscriptionID, since, kinds).
+			Distinct("engagement_events.delivery_id")
+	}
+	if err := engaged(model.EngagementOpen, model.EngagementClick).Count(&opened).Error; err != nil {
+		return score, err
+	}
+	if err := engaged(model.EngagementClick).Count(&clicked).Error; err != nil {
+		return score, err
+	}
+
+	var last []model.EngagementEvent
+	if err := tx.Where("subscription_id = ?", subscriptionID).Order("created_at DESC").Limit(1).Find(&last).Error; err != nil {
+		return score, err
+	}
+
+	score.Tracked, score.Opened, score.Clicked = int(tracked), int(opened), int(clicked)
+	if tracked > 0 {
+		score.Score = float64(opened) / float64(tracked)
+	}
+	if len(last) > 0 {
+		score.LastEngagedAt = &last[0].CreatedAt
+	}
+	return score, nil
+}
+
+// Unengaged reports whether none of the subscription's last n tracked deliveries
+// was opened or clicked. Subscriptions with fewer than n tracked deliveries are
+// not unengaged.
+func (e *Engagement) Unengaged(ctx context.Context, subscriptionID string, n int) (bool, error) {
+	tx := Conn(ctx, e.DB)
+	var ids []string
+	if err := tx.Model(&model.Delivery{}).
+		Where("subscription_id = ? AND tracked = ? AND status = ?", subscriptionID, true, model.DeliverySent).
+		Order("created_at DESC").Limit(n).Pluck("id", &ids).Error; err != nil {
+		return false, err
+	}
+	if n <= 0 || len(ids) < n {
+		return false, nil
+	}
+
+	var events int64
+	if err := tx.Model(&model.EngagementEvent{}).Where("delivery_id IN ?", ids).Count(&events).Error; err != nil {
+		return false, err
+	}
+	return events == 0, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"weatherApi/internal/db"
+	"weatherApi/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestEngagement verifies that only tracked deliveries record events, how the score
+// counts them, and that a subscriber is unengaged only after n unopened emails.
+func TestEngagement(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	_, err = db.Migrate(gdb)
+	require.NoError(t, err)
+	e := NewEngagement(gdb)
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, d := range []model.Delivery{
+		{ID: "old", Tracked: true},
+		{ID: "untracked"},
+		{ID: "opened", Tracked: true},
+		{ID: "clicked", Tracked: true},
+		{ID: "ignored", Tracked: true},
+	} {
+		d.SubscriptionID, d.Kind, d.Status = "sub-1", "weather.update", model.DeliverySent
+		d.CreatedAt = now.Add(time.Duration(i-4) * time.Hour)
+		if d.ID == "old" {
+			d.CreatedAt = now.AddDate(0, 0, -40)
+		}
+		require.NoError(t, gdb.Create(&d).Error)
+	}
+
+	unengaged, err := e.Unengaged(ctx, "sub-1", 3)
+	require.NoError(t, err)
+	assert.True(t, unengaged)
+
+	for _, r := range []struct{ delivery, kind string }{
+		{"untracked", model.EngagementOpen},
+		{"missing", model.EngagementOpen},
+		{"opened", model.EngagementOpen},
+		{"opened", model.EngagementOpen},
+		{"clicked", model.EngagementOpen},
+		{"clicked", model.EngagementClick},
+	} {
+		recorded, err := e.Record(ctx, r.delivery, r.kind, "", now)
+		require.NoError(t, err)
+		assert.Equal(t, r.delivery != "untracked" && r.delivery != "missing", recorded, r.delivery)
+	}
+
+	score, err := e.Score(ctx, "sub-1", now.AddDate(0, 0, -30))
+	require.NoError(t, err)
+	assert.Equal(t, 3, score.Tracked)
+	assert.Equal(t, 2, score.Opened)
+	assert.Equal(t, 1, score.Clicked)
+	assert.InDelta(t, 2.0/3, score.Score, 0.001)
+	require.NotNil(t, score.LastEngagedAt)
+
+	for n, want := range map[int]bool{1: true, 2: false, 5: false} {
+		unengaged, err := e.Unengaged(ctx, "sub-1", n)
+		require.NoError(t, err)
+		assert.Equal(t, want, unengaged, "last %d", n)
+	}
+
+	score, err = e.Score(ctx, "sub-2", now.AddDate(0, 0, -30))
+	require.NoError(t, err)
+	assert.Equal(t, model.EngagementScore{}, score)
+}
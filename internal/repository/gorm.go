@@ -0,0 +1,387 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"weatherApi/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// txKey is the context key under which Transaction stores the open *gorm.DB transaction.
+type txKey struct{}
+
+// GormSubscriptionRepository keeps subscriptions in the subscriptions and conditions tables
+// and their status history in subscription_events.
+type GormSubscriptionRepository struct {
+	DB *gorm.DB
+}
+
+// NewGormSubscriptionRepository returns a repository backed by the given database.
+// The schema must be migrated (see db.Migrate).
+func NewGormSubscriptionRepository(db *gorm.DB) *GormSubscriptionRepository {
+	return &GormSubscriptionRepository{DB: db}
+}
+
+// Conn returns the transaction opened by a GormSubscriptionRepository.Transaction
+// higher up in ctx, or db bound to ctx otherwise. Callers use it to write other
+// tables (e.g. the audit log) atomically with subscription changes.
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db.WithContext(ctx)
+}
+
+// conn returns the handle for ctx; queries become spans of the caller's trace.
+func (r *GormSubscriptionRepository) conn(ctx context.Context) *gorm.DB {
+	return Conn(ctx, r.DB)
+}
+
+// loaded preloads the associations every lookup returns.
+func (r *GormSubscriptionRepository) loaded(ctx context.Context) *gorm.DB {
+	return r.conn(ctx).Preload("Location").Preload("Conditions")
+}
+
+// Create implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) Create(ctx context.Context, sub *model.Subscription, change StatusChange) error {
+	if sub.Status == "" {
+		sub.Status = model.StatusPending
+	}
+	if !model.ValidStatus(sub.Status) {
+		return fmt.Errorf("%w: unknown status %q", model.ErrInvalidTransition, sub.Status)
+	}
+
+	return r.Transaction(ctx, func(ctx context.Context) error {
+		// Locations are shared and stored separately (db.FindOrCreateLocation)
+		if err := r.translate(r.conn(ctx).Omit("Location").Create(sub).Error); err != nil {
+			return err
+		}
+		return r.recordEvent(ctx, sub.ID, "", sub.Status, change)
+	})
+}
+
+// Update implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) Update(ctx context.Context, sub *model.Subscription) error {
+	return r.Transaction(ctx, func(ctx context.Context) error {
+		tx := r.conn(ctx)
+		if err := tx.Where("subscription_id = ?", sub.ID).Delete(&model.Condition{}).Error; err != nil {
+			return err
+		}
+		return r.translate(tx.Omit("Location", "Status", "CreatedAt").Save(sub).Error)
+	})
+}
+
+// GetByID implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) GetByID(ctx context.Context, id string) (*model.Subscription, error) {
+	var sub model.Subscription
+	if err := r.loaded(ctx).Where("id = ?", id).First(&sub).Error; err != nil {
+		return nil, r.translate(err)
+	}
+	return &sub, nil
+}
+
+// GetByEmail implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) GetByEmail(ctx context.Context, email string) (*model.Subscription, error) {
+	var sub model.Subscription
+	if err := r.loaded(ctx).Where("email = ?", email).First(&sub).Error; err != nil {
+		return nil, r.translate(err)
+	}
+	return &sub, nil
+}
+
+// GetByEmailForUpdate implements SubscriptionRepository. Only the subscription row
+// is locked; the shared location and the conditions are loaded without locks.
+func (r *GormSubscriptionRepository) GetByEmailForUpdate(ctx context.Context, email string) (*model.Subscription, error) {
+	var ids []string
+	if err := r.conn(ctx).Model(&model.Subscription{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("email = ?", email).Pluck("id", &ids).Error; err != nil {
+		return nil, r.translate(err)
+	}
+	if len(ids) == 0 {
+		return nil, ErrNotFound
+	}
+	return r.GetByID(ctx, ids[0])
+}
+
+// Transition implements SubscriptionRepository. The row is locked while the current
+// status is checked, so concurrent transitions of one subscription are serialized.
+func (r *GormSubscriptionRepository) Transition(ctx context.Context, id, to string, change StatusChange) error {
+	return r.Transaction(ctx, func(ctx context.Context) error {
+		var current []string
+		if err := r.conn(ctx).Model(&model.Subscription{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).Pluck("status", &current).Error; err != nil {
+			return err
+		}
+		if len(current) == 0 {
+			return ErrNotFound
+		}
+		if err := model.ValidateTransition(current[0], to); err != nil {
+			return err
+		}
+		columns := map[string]interface{}{"status": to}
+		if to != model.StatusPaused {
+			columns["resume_on"] = nil
+		}
+		if err := r.updateColumns(ctx, id, columns); err != nil {
+			return err
+		}
+		return r.recordEvent(ctx, id, current[0], to, change)
+	})
+}
+
+// recordEvent appends a status change to the subscription's history.
+func (r *GormSubscriptionRepository) recordEvent(ctx context.Context, id, from, to string, change StatusChange) error {
+	return r.conn(ctx).Create(&model.SubscriptionEvent{
+		ID:             uuid.New().String(),
+		SubscriptionID: id,
+		FromStatus:     from,
+		ToStatus:       to,
+		Actor:          change.Actor,
+		Reason:         change.Reason,
+		CreatedAt:      time.Now(),
+	}).Error
+}
+
+// Events implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) Events(ctx context.Context, id string) ([]model.SubscriptionEvent, error) {
+	var events []model.SubscriptionEvent
+	err := r.conn(ctx).Where("subscription_id = ?", id).Order("created_at").Find(&events).Error
+	return events, err
+}
+
+// SetResumeOn implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) SetResumeOn(ctx context.Context, id string, on *time.Time) error {
+	return r.updateColumns(ctx, id, map[string]interface{}{"resume_on": on})
+}
+
+// MarkConfirmationSent implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) MarkConfirmationSent(ctx context.Context, id string, at time.Time) error {
+	return r.updateColumns(ctx, id, map[string]interface{}{"confirmation_sent_at": at})
+}
+
+// MarkReminderSent implements SubscriptionRepository. The conditional update claims the
+// reminder, so only one of several concurrent callers gets true.
+func (r *GormSubscriptionRepository) MarkReminderSent(ctx context.Context, id string, at time.Time) (bool, error) {
+	res := r.conn(ctx).Model(&model.Subscription{}).
+		Where("id = ? AND (reminder_sent_at IS NULL OR reminder_sent_at < confirmation_sent_at)", id).
+		Update("reminder_sent_at", at)
+	return res.RowsAffected == 1, res.Error
+}
+
+// Anonymize implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) Anonymize(ctx context.Context, id string, at time.Time) error {
+	return r.updateColumns(ctx, id, map[string]interface{}{
+		"email":          anonymizedEmail(id),
+		"token":          "",
+		"webhook_url":    "",
+		"webhook_secret": "",
+		"anonymized_at":  at,
+	})
+}
+
+// updateColumns updates the given columns of one subscription, reporting ErrNotFound if it doesn't exist.
+func (r *GormSubscriptionRepository) updateColumns(ctx context.Context, id string, columns map[string]interface{}) error {
+	res := r.conn(ctx).Model(&model.Subscription{}).Where("id = ?", id).Updates(columns)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete implements SubscriptionRepository. Conditions, alert claims, pending
+// outbox jobs, the delivery and engagement history and the status history of the
+// subscription are deleted with it.
+func (r *GormSubscriptionRepository) Delete(ctx context.Context, id string) error {
+	return r.Transaction(ctx, func(ctx context.Context) error {
+		tx := r.conn(ctx)
+		for _, dependent := range []interface{}{&model.Condition{}, &model.SentAlert{}, &model.Delivery{}, &model.EngagementEvent{}, &model.WeatherSnapshot{}, &model.OutboxJob{}, &model.SubscriptionEvent{}} {
+			if err := tx.Where("subscription_id = ?", id).Delete(dependent).Error; err != nil {
+				return err
+			}
+		}
+		res := tx.Where("id = ?", id).Delete(&model.Subscription{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// ListActive implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) ListActive(ctx context.Context, frequency string) ([]model.Subscription, error) {
+	var subs []model.Subscription
+	err := r.loaded(ctx).Where("status = ? AND frequency = ?", model.StatusActive, frequency).Find(&subs).Error
+	return subs, err
+}
+
+// ListResumeDue implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) ListResumeDue(ctx context.Context, now time.Time) ([]model.Subscription, error) {
+	var subs []model.Subscription
+	err := r.loaded(ctx).Where("status = ? AND resume_on <= ?", model.StatusPaused, now).Find(&subs).Error
+	return subs, err
+}
+
+// ListReminderDue implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) ListReminderDue(ctx context.Context, sentBefore time.Time) ([]model.Subscription, error) {
+	var subs []model.Subscription
+	err := r.loaded(ctx).
+		Where("status = ? AND confirmation_sent_at <= ?", model.StatusPending, sentBefore).
+		Where("reminder_sent_at IS NULL OR reminder_sent_at < confirmation_sent_at").
+		Find(&subs).Error
+	return subs, err
+}
+
+// ListExpireDue implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) ListExpireDue(ctx context.Context, before time.Time) ([]model.Subscription, error) {
+	var subs []model.Subscription
+	err := r.loaded(ctx).
+		Where("status = ? AND COALESCE(confirmation_sent_at, created_at) <= ?", model.StatusPending, before).
+		Find(&subs).Error
+	return subs, err
+}
+
+// ListRetentionDue implements SubscriptionRepository. Every status change is recorded
+// as an event, so the newest event tells when the subscription was unsubscribed.
+func (r *GormSubscriptionRepository) ListRetentionDue(ctx context.Context, before time.Time) ([]model.Subscription, error) {
+	recent := r.conn(ctx).Model(&model.SubscriptionEvent{}).
+		Select("1").Where("subscription_events.subscription_id = subscriptions.id AND subscription_events.created_at > ?", before)
+
+	var subs []model.Subscription
+	err := r.loaded(ctx).
+		Where("status = ? AND anonymized_at IS NULL", model.StatusUnsubscribed).
+		Where("NOT EXISTS (?)", recent).
+		Find(&subs).Error
+	return subs, err
+}
+
+// List implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) List(ctx context.Context, filter SubscriptionFilter, offset, limit int) ([]model.Subscription, int64, error) {
+	q := applyFilter(r.conn(ctx).Model(&model.Subscription{}), filter)
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "created_at DESC"
+	if filter.OldestFirst {
+		order = "created_at"
+	}
+
+	var subs []model.Subscription
+	if err := q.Preload("Location").Preload("Conditions").
+		Order(order).Offset(offset).Limit(limit).
+		Find(&subs).Error; err != nil {
+		return nil, 0, err
+	}
+	return subs, total, nil
+}
+
+// applyFilter adds the filter conditions to q.
+func applyFilter(q *gorm.DB, f SubscriptionFilter) *gorm.DB {
+	if f.City != "" {
+		q = q.Where("LOWER(city) = LOWER(?)", f.City)
+	}
+	if f.Frequency != "" {
+		q = q.Where("frequency = ?", f.Frequency)
+	}
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+	if !f.CreatedFrom.IsZero() {
+		q = q.Where("created_at >= ?", f.CreatedFrom)
+	}
+	if !f.CreatedTo.IsZero() {
+		q = q.Where("created_at <= ?", f.CreatedTo)
+	}
+	return q
+}
+
+// CountByStatus implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := r.conn(ctx).Model(&model.Subscription{}).
+		Select("status, COUNT(*) AS count").Group("status").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]int64, len(model.Statuses))
+	for _, status := range model.Statuses {
+		totals[status] = 0
+	}
+	for _, row := range rows {
+		totals[row.Status] = row.Count
+	}
+	return totals, nil
+}
+
+// CountActiveByCity implements SubscriptionRepository.
+func (r *GormSubscriptionRepository) CountActiveByCity(ctx context.Context) ([]CityCount, error) {
+	var counts []CityCount
+	err := r.conn(ctx).Model(&model.Subscription{}).Where("status = ?", model.StatusActive).
+		Select("city, frequency, COUNT(*) AS count").
+		Group("city, frequency").Order("count DESC, city").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// Transaction implements SubscriptionRepository using a database transaction.
+// SQLite busy and locked errors are returned as ErrConflict.
+func (r *GormSubscriptionRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	if sqliteConflict(err) {
+		return fmt.Errorf("%w: %v", ErrConflict, err)
+	}
+	return err
+}
+
+// sqliteConflict reports whether err is SQLite's SQLITE_BUSY or SQLITE_LOCKED. It matches
+// the messages rather than the driver's error type, which only exists in cgo builds.
+func sqliteConflict(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked")
+}
+
+// translate maps driver errors to the repository's sentinel errors.
+func (r *GormSubscriptionRepository) translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	if t, ok := r.DB.Dialector.(gorm.ErrorTranslator); ok {
+		err = t.Translate(err)
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicateEmail
+	}
+	return err
+}
@@ -0,0 +1,502 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"weatherApi/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// MemorySubscriptionRepository keeps subscriptions in process memory.
+// Intended for tests and local experiments; data is lost on restart.
+type MemorySubscriptionRepository struct {
+	mu     sync.RWMutex
+	subs   map[string]model.Subscription // by ID
+	events []model.SubscriptionEvent     // in insertion order
+
+	txMu sync.Mutex // serializes Transaction calls
+}
+
+// NewMemorySubscriptionRepository returns an empty in-memory repository.
+func NewMemorySubscriptionRepository() *MemorySubscriptionRepository {
+	return &MemorySubscriptionRepository{subs: make(map[string]model.Subscription)}
+}
+
+// Create implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) Create(ctx context.Context, sub *model.Subscription, change StatusChange) error {
+	if sub.Status == "" {
+		sub.Status = model.StatusPending
+	}
+	if !model.ValidStatus(sub.Status) {
+		return fmt.Errorf("%w: unknown status %q", model.ErrInvalidTransition, sub.Status)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subs[sub.ID]; ok || r.emailTaken(sub.Email, "") {
+		return ErrDuplicateEmail
+	}
+	r.save(ctx, sub.ID)
+	r.subs[sub.ID] = clone(*sub)
+	r.recordEvent(sub.ID, "", sub.Status, change)
+	return nil
+}
+
+// Update implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) Update(ctx context.Context, sub *model.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.subs[sub.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if r.emailTaken(sub.Email, sub.ID) {
+		return ErrDuplicateEmail
+	}
+	updated := clone(*sub)
+	updated.Status, updated.CreatedAt = stored.Status, stored.CreatedAt
+	r.save(ctx, sub.ID)
+	r.subs[sub.ID] = updated
+	return nil
+}
+
+// emailTaken reports whether another subscription than exceptID uses the email.
+func (r *MemorySubscriptionRepository) emailTaken(email, exceptID string) bool {
+	for id, s := range r.subs {
+		if s.Email == email && id != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetByID implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) GetByID(_ context.Context, id string) (*model.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.subs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	sub := clone(s)
+	return &sub, nil
+}
+
+// GetByEmail implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) GetByEmail(_ context.Context, email string) (*model.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, s := range r.subs {
+		if s.Email == email {
+			sub := clone(s)
+			return &sub, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// GetByEmailForUpdate implements SubscriptionRepository. Transactions are already
+// serialized, so no row lock is needed.
+func (r *MemorySubscriptionRepository) GetByEmailForUpdate(ctx context.Context, email string) (*model.Subscription, error) {
+	return r.GetByEmail(ctx, email)
+}
+
+// Transition implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) Transition(ctx context.Context, id, to string, change StatusChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.subs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := model.ValidateTransition(s.Status, to); err != nil {
+		return err
+	}
+	r.save(ctx, id)
+	r.recordEvent(id, s.Status, to, change)
+	s.Status = to
+	if to != model.StatusPaused {
+		s.ResumeOn = nil
+	}
+	r.subs[id] = s
+	return nil
+}
+
+// recordEvent appends a status change to the history. The caller holds mu.
+func (r *MemorySubscriptionRepository) recordEvent(id, from, to string, change StatusChange) {
+	r.events = append(r.events, model.SubscriptionEvent{
+		ID:             uuid.New().String(),
+		SubscriptionID: id,
+		FromStatus:     from,
+		ToStatus:       to,
+		Actor:          change.Actor,
+		Reason:         change.Reason,
+		CreatedAt:      time.Now(),
+	})
+}
+
+// Events implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) Events(_ context.Context, id string) ([]model.SubscriptionEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.eventsOf(id), nil
+}
+
+// eventsOf returns a copy of the events of subscription id. The caller holds mu.
+func (r *MemorySubscriptionRepository) eventsOf(id string) []model.SubscriptionEvent {
+	var events []model.SubscriptionEvent
+	for _, e := range r.events {
+		if e.SubscriptionID == id {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// SetResumeOn implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) SetResumeOn(ctx context.Context, id string, on *time.Time) error {
+	return r.modify(ctx, id, func(s *model.Subscription) {
+		s.ResumeOn = nil
+		if on != nil {
+			day := *on
+			s.ResumeOn = &day
+		}
+	})
+}
+
+// MarkConfirmationSent implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) MarkConfirmationSent(ctx context.Context, id string, at time.Time) error {
+	return r.modify(ctx, id, func(s *model.Subscription) {
+		s.ConfirmationSentAt = &at
+	})
+}
+
+// MarkReminderSent implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) MarkReminderSent(ctx context.Context, id string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.subs[id]
+	if !ok || !reminderOutstanding(s) {
+		return false, nil
+	}
+	r.save(ctx, id)
+	s.ReminderSentAt = &at
+	r.subs[id] = s
+	return true, nil
+}
+
+// reminderOutstanding reports whether the current confirmation email has no reminder yet.
+func reminderOutstanding(s model.Subscription) bool {
+	return s.ConfirmationSentAt != nil && (s.ReminderSentAt == nil || s.ReminderSentAt.Before(*s.ConfirmationSentAt))
+}
+
+// Anonymize implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) Anonymize(ctx context.Context, id string, at time.Time) error {
+	return r.modify(ctx, id, func(s *model.Subscription) {
+		s.Email, s.Token = anonymizedEmail(id), ""
+		s.WebhookURL, s.WebhookSecret = "", ""
+		s.AnonymizedAt = &at
+	})
+}
+
+// modify applies fn to the stored subscription.
+func (r *MemorySubscriptionRepository) modify(ctx context.Context, id string, fn func(s *model.Subscription)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.subs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	r.save(ctx, id)
+	fn(&s)
+	r.subs[id] = s
+	return nil
+}
+
+// Delete implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subs[id]; !ok {
+		return ErrNotFound
+	}
+	r.save(ctx, id)
+	delete(r.subs, id)
+	r.events = slices.DeleteFunc(r.events, func(e model.SubscriptionEvent) bool {
+		return e.SubscriptionID == id
+	})
+	return nil
+}
+
+// ListActive implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) ListActive(_ context.Context, frequency string) ([]model.Subscription, error) {
+	return r.find(SubscriptionFilter{Status: model.StatusActive, Frequency: frequency, OldestFirst: true}), nil
+}
+
+// ListResumeDue implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) ListResumeDue(_ context.Context, now time.Time) ([]model.Subscription, error) {
+	var due []model.Subscription
+	for _, s := range r.find(SubscriptionFilter{Status: model.StatusPaused, OldestFirst: true}) {
+		if s.ResumeOn != nil && !s.ResumeOn.After(now) {
+			due = append(due, s)
+		}
+	}
+	return due, nil
+}
+
+// ListReminderDue implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) ListReminderDue(_ context.Context, sentBefore time.Time) ([]model.Subscription, error) {
+	var due []model.Subscription
+	for _, s := range r.find(SubscriptionFilter{Status: model.StatusPending, OldestFirst: true}) {
+		if reminderOutstanding(s) && !s.ConfirmationSentAt.After(sentBefore) {
+			due = append(due, s)
+		}
+	}
+	return due, nil
+}
+
+// ListExpireDue implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) ListExpireDue(_ context.Context, before time.Time) ([]model.Subscription, error) {
+	var due []model.Subscription
+	for _, s := range r.find(SubscriptionFilter{Status: model.StatusPending, OldestFirst: true}) {
+		last := s.CreatedAt
+		if s.ConfirmationSentAt != nil {
+			last = *s.ConfirmationSentAt
+		}
+		if !last.After(before) {
+			due = append(due, s)
+		}
+	}
+	return due, nil
+}
+
+// ListRetentionDue implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) ListRetentionDue(_ context.Context, before time.Time) ([]model.Subscription, error) {
+	subs := r.find(SubscriptionFilter{Status: model.StatusUnsubscribed, OldestFirst: true})
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	recent := map[string]bool{}
+	for _, e := range r.events {
+		if e.CreatedAt.After(before) {
+			recent[e.SubscriptionID] = true
+		}
+	}
+	var due []model.Subscription
+	for _, s := range subs {
+		if s.AnonymizedAt == nil && !recent[s.ID] {
+			due = append(due, s)
+		}
+	}
+	return due, nil
+}
+
+// List implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) List(_ context.Context, filter SubscriptionFilter, offset, limit int) ([]model.Subscription, int64, error) {
+	subs := r.find(filter)
+	total := int64(len(subs))
+
+	if offset > len(subs) {
+		offset = len(subs)
+	}
+	subs = subs[offset:]
+	if limit >= 0 && limit < len(subs) {
+		subs = subs[:limit]
+	}
+	return subs, total, nil
+}
+
+// find returns copies of all subscriptions matching the filter in creation order.
+func (r *MemorySubscriptionRepository) find(f SubscriptionFilter) []model.Subscription {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subs := make([]model.Subscription, 0, len(r.subs))
+	for _, s := range r.subs {
+		if matches(s, f) {
+			subs = append(subs, clone(s))
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if f.OldestFirst {
+			return subs[i].CreatedAt.Before(subs[j].CreatedAt)
+		}
+		return subs[i].CreatedAt.After(subs[j].CreatedAt)
+	})
+	return subs
+}
+
+// matches reports whether s passes every set field of the filter.
+func matches(s model.Subscription, f SubscriptionFilter) bool {
+	switch {
+	case f.City != "" && !strings.EqualFold(s.City, f.City):
+		return false
+	case f.Frequency != "" && s.Frequency != f.Frequency:
+		return false
+	case f.Status != "" && s.Status != f.Status:
+		return false
+	case !f.CreatedFrom.IsZero() && s.CreatedAt.Before(f.CreatedFrom):
+		return false
+	case !f.CreatedTo.IsZero() && s.CreatedAt.After(f.CreatedTo):
+		return false
+	}
+	return true
+}
+
+// CountByStatus implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) CountByStatus(_ context.Context) (map[string]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	totals := make(map[string]int64, len(model.Statuses))
+	for _, status := range model.Statuses {
+		totals[status] = 0
+	}
+	for _, s := range r.subs {
+		totals[s.Status]++
+	}
+	return totals, nil
+}
+
+// CountActiveByCity implements SubscriptionRepository.
+func (r *MemorySubscriptionRepository) CountActiveByCity(_ context.Context) ([]CityCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	index := map[[2]string]int{}
+	var counts []CityCount
+	for _, s := range r.subs {
+		if s.Status != model.StatusActive {
+			continue
+		}
+		key := [2]string{s.City, s.Frequency}
+		i, ok := index[key]
+		if !ok {
+			i = len(counts)
+			index[key] = i
+			counts = append(counts, CityCount{City: s.City, Frequency: s.Frequency})
+		}
+		counts[i].Count++
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		if counts[i].City != counts[j].City {
+			return counts[i].City < counts[j].City
+		}
+		return counts[i].Frequency < counts[j].Frequency
+	})
+	return counts, nil
+}
+
+// Transaction implements SubscriptionRepository. Transactions are serialized
+// with each other, and if fn fails every subscription it wrote, including its
+// events, is restored. Writes made outside the transaction are kept.
+func (r *MemorySubscriptionRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, _ := ctx.Value(memoryTxKey{}).(*memoryTx); tx != nil && tx.repo == r {
+		return fn(ctx)
+	}
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	tx := &memoryTx{repo: r, saved: map[string]savedSubscription{}}
+	if err := fn(context.WithValue(ctx, memoryTxKey{}, tx)); err != nil {
+		r.rollback(tx)
+		return err
+	}
+	return nil
+}
+
+// memoryTx records the state of each subscription before a transaction first wrote it.
+type memoryTx struct {
+	repo  *MemorySubscriptionRepository
+	saved map[string]savedSubscription
+}
+
+// savedSubscription is a subscription and its events as they were before a transaction.
+type savedSubscription struct {
+	sub    model.Subscription
+	exists bool
+	events []model.SubscriptionEvent
+}
+
+// save records the current state of subscription id if ctx is in a transaction
+// that has not written it yet. The caller holds mu.
+func (r *MemorySubscriptionRepository) save(ctx context.Context, id string) {
+	tx, _ := ctx.Value(memoryTxKey{}).(*memoryTx)
+	if tx == nil || tx.repo != r {
+		return
+	}
+	if _, ok := tx.saved[id]; ok {
+		return
+	}
+	s, exists := r.subs[id]
+	tx.saved[id] = savedSubscription{sub: clone(s), exists: exists, events: r.eventsOf(id)}
+}
+
+// rollback restores every subscription saved by tx.
+func (r *MemorySubscriptionRepository) rollback(tx *memoryTx) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, saved := range tx.saved {
+		if saved.exists {
+			r.subs[id] = saved.sub
+		} else {
+			delete(r.subs, id)
+		}
+		r.events = append(slices.DeleteFunc(r.events, func(e model.SubscriptionEvent) bool {
+			return e.SubscriptionID == id
+		}), saved.events...)
+	}
+}
+
+// memoryTxKey carries the *memoryTx of a context inside a MemorySubscriptionRepository transaction.
+type memoryTxKey struct{}
+
+// clone returns a copy of s that shares no mutable state with it.
+func clone(s model.Subscription) model.Subscription {
+	if s.Location != nil {
+		loc := *s.Location
+		s.Location = &loc
+	}
+	if s.LocationID != nil {
+		id := *s.LocationID
+		s.LocationID = &id
+	}
+	if s.ConfirmationSentAt != nil {
+		at := *s.ConfirmationSentAt
+		s.ConfirmationSentAt = &at
+	}
+	if s.ResumeOn != nil {
+		on := *s.ResumeOn
+		s.ResumeOn = &on
+	}
+	if s.ReminderSentAt != nil {
+		at := *s.ReminderSentAt
+		s.ReminderSentAt = &at
+	}
+	if s.AnonymizedAt != nil {
+		at := *s.AnonymizedAt
+		s.AnonymizedAt = &at
+	}
+	s.Conditions = append([]model.Condition(nil), s.Conditions...)
+	return s
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"weatherApi/internal/model"
+)
+
+// ErrNotFound is returned when no subscription matches the lookup.
+var ErrNotFound = errors.New("subscription not found")
+
+// ErrDuplicateEmail is returned by Create when the email is already subscribed.
+var ErrDuplicateEmail = errors.New("email already subscribed")
+
+// ErrConflict is returned by Transaction when it lost a write conflict with a concurrent
+// transaction, e.g. SQLite refusing to upgrade a read transaction to a write. Retrying
+// the whole transaction is safe.
+var ErrConflict = errors.New("concurrent transaction conflict")
+
+// anonymizedEmail is the unique placeholder address stored by Anonymize.
+func anonymizedEmail(id string) string {
+	return "anonymized-" + id + "@invalid"
+}
+
+// StatusChange describes who changed a subscription's status and why.
+// It is stored with the resulting model.SubscriptionEvent.
+type StatusChange struct {
+	Actor  string // One of the model.Actor* constants or model.AdminActor(...)
+	Reason string // Human-readable cause, e.g. "confirmed via email link"
+}
+
+// SubscriptionFilter narrows List results. Zero fields are ignored.
+type SubscriptionFilter struct {
+	City        string    // Case-insensitive exact match
+	Frequency   string    // "daily", "hourly" or "alerts"
+	Status      string    // One of the model.Status* constants
+	CreatedFrom time.Time // Inclusive lower bound
+	CreatedTo   time.Time // Inclusive upper bound
+	OldestFirst bool      // Sort by creation time ascending instead of newest first
+}
+
+// CityCount is the number of active subscriptions for a city and frequency.
+type CityCount struct {
+	City      string
+	Frequency string
+	Count     int64
+}
+
+// SubscriptionRepository stores subscriptions together with their conditions.
+// Lookups return the subscription with Location and Conditions loaded.
+// Implementations must be safe for concurrent use.
+type SubscriptionRepository interface {
+	// Create inserts a new subscription with its conditions and records its initial status.
+	// An empty sub.Status is stored as model.StatusPending.
+	Create(ctx context.Context, sub *model.Subscription, change StatusChange) error
+
+	// Update overwrites a stored subscription and replaces its conditions with sub.Conditions.
+	// The status and creation time are left unchanged; use Transition for status changes.
+	Update(ctx context.Context, sub *model.Subscription) error
+
+	// GetByID returns the subscription with the given ID, or ErrNotFound.
+	GetByID(ctx context.Context, id string) (*model.Subscription, error)
+
+	// GetByEmail returns the subscription for the given address, or ErrNotFound.
+	GetByEmail(ctx context.Context, email string) (*model.Subscription, error)
+
+	// GetByEmailForUpdate is GetByEmail that also locks the row until the surrounding
+	// Transaction ends, so concurrent read-then-write flows on one email are serialized.
+	GetByEmailForUpdate(ctx context.Context, email string) (*model.Subscription, error)
+
+	// Transition moves the subscription to status to and records the change as an event.
+	// Changes the state machine doesn't allow return an error wrapping model.ErrInvalidTransition.
+	// Leaving model.StatusPaused clears the resume date.
+	Transition(ctx context.Context, id, to string, change StatusChange) error
+
+	// SetResumeOn sets the day a paused subscription is reactivated; nil pauses indefinitely.
+	SetResumeOn(ctx context.Context, id string, on *time.Time) error
+
+	// Events returns the status history of a subscription, oldest first.
+	Events(ctx context.Context, id string) ([]model.SubscriptionEvent, error)
+
+	// MarkConfirmationSent records when the confirmation email was last sent.
+	MarkConfirmationSent(ctx context.Context, id string, at time.Time) error
+
+	// MarkReminderSent records a confirmation reminder sent at. It returns false without
+	// changing anything if a reminder for the current confirmation email was already
+	// recorded, so concurrent schedulers send at most one.
+	MarkReminderSent(ctx context.Context, id string, at time.Time) (bool, error)
+
+	// Anonymize replaces the email, token and webhook details of a subscription with
+	// placeholders. City, frequency and status history are kept for statistics.
+	Anonymize(ctx context.Context, id string, at time.Time) error
+
+	// Delete removes the subscription and everything recorded for it.
+	Delete(ctx context.Context, id string) error
+
+	// ListActive returns all active subscriptions with the given frequency.
+	ListActive(ctx context.Context, frequency string) ([]model.Subscription, error)
+
+	// ListResumeDue returns paused subscriptions whose resume date is at or before now.
+	ListResumeDue(ctx context.Context, now time.Time) ([]model.Subscription, error)
+
+	// ListReminderDue returns pending subscriptions whose confirmation email was sent at or
+	// before sentBefore and has not been followed by a reminder yet.
+	ListReminderDue(ctx context.Context, sentBefore time.Time) ([]model.Subscription, error)
+
+	// ListExpireDue returns pending subscriptions whose last confirmation email (or creation,
+	// if none was sent) is at or before before.
+	ListExpireDue(ctx context.Context, before time.Time) ([]model.Subscription, error)
+
+	// ListRetentionDue returns unsubscribed, not yet anonymized subscriptions whose status
+	// last changed at or before before.
+	ListRetentionDue(ctx context.Context, before time.Time) ([]model.Subscription, error)
+
+	// List returns up to limit subscriptions matching the filter, skipping the first offset,
+	// along with the total number of matches.
+	List(ctx context.Context, filter SubscriptionFilter, offset, limit int) ([]model.Subscription, int64, error)
+
+	// CountByStatus returns the number of subscriptions per model.Status* value.
+	CountByStatus(ctx context.Context) (map[string]int64, error)
+
+	// CountActiveByCity groups active subscriptions by city and frequency, largest groups first.
+	CountActiveByCity(ctx context.Context) ([]CityCount, error)
+
+	// Transaction runs fn atomically: repository calls made with the context passed to fn
+	// either all take effect or none do. Nested calls join the outer transaction.
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
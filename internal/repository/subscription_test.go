@@ -0,0 +1,393 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"weatherApi/internal/db"
+	"weatherApi/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// implementations returns a fresh instance of every SubscriptionRepository,
+// so the same contract tests run against each of them.
+func implementations(t *testing.T) map[string]SubscriptionRepository {
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	_, err = db.Migrate(gdb)
+	require.NoError(t, err)
+
+	return map[string]SubscriptionRepository{
+		"gorm":   NewGormSubscriptionRepository(gdb),
+		"memory": NewMemorySubscriptionRepository(),
+	}
+}
+
+// testChange attributes status changes made by the tests.
+var testChange = StatusChange{Actor: model.ActorSystem, Reason: "test"}
+
+// seed stores subscriptions in different states, created one day apart.
+func seed(t *testing.T, repo SubscriptionRepository) time.Time {
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for i, sub := range []model.Subscription{
+		{ID: "pending-1", Email: "pending@example.com", City: "Kyiv", Frequency: "daily"},
+		{ID: "active-1", Email: "active@example.com", City: "Kyiv", Frequency: "hourly", Status: model.StatusActive},
+		{ID: "active-2", Email: "paris@example.com", City: "Paris", Frequency: "daily", Status: model.StatusActive},
+		{ID: "active-3", Email: "kyiv2@example.com", City: "kyiv", Frequency: "hourly", Status: model.StatusActive},
+		{ID: "gone-1", Email: "gone@example.com", City: "Kyiv", Frequency: "daily", Status: model.StatusUnsubscribed},
+	} {
+		sub.Token = "token-" + sub.ID
+		sub.CreatedAt = base.AddDate(0, 0, i)
+		require.NoError(t, repo.Create(context.Background(), &sub, testChange))
+	}
+	return base
+}
+
+// ids returns the IDs of subs in order.
+func ids(subs []model.Subscription) []string {
+	out := make([]string, len(subs))
+	for i, s := range subs {
+		out[i] = s.ID
+	}
+	return out
+}
+
+// TestRepository_CreateAndGet verifies lookups, conditions round-trip and the sentinel errors.
+func TestRepository_CreateAndGet(t *testing.T) {
+	for name, repo := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			sub := model.Subscription{
+				ID: "sub-1", Email: "a@example.com", City: "Kyiv", Frequency: "alerts", Token: "t",
+				Conditions: []model.Condition{{ID: "c-1", SubscriptionID: "sub-1", Metric: model.MetricPrecipitation, Operator: model.OperatorAbove, Value: 60}},
+			}
+			require.NoError(t, repo.Create(ctx, &sub, testChange))
+
+			got, err := repo.GetByEmail(ctx, "a@example.com")
+			require.NoError(t, err)
+			assert.Equal(t, "sub-1", got.ID)
+			require.Len(t, got.Conditions, 1)
+			assert.Equal(t, model.MetricPrecipitation, got.Conditions[0].Metric)
+
+			require.NoError(t, repo.Transaction(ctx, func(ctx context.Context) error {
+				locked, err := repo.GetByEmailForUpdate(ctx, "a@example.com")
+				require.NoError(t, err)
+				assert.Equal(t, "sub-1", locked.ID)
+				assert.Len(t, locked.Conditions, 1)
+
+				_, err = repo.GetByEmailForUpdate(ctx, "missing@example.com")
+				assert.ErrorIs(t, err, ErrNotFound)
+				return nil
+			}))
+
+			_, err = repo.GetByID(ctx, "missing")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			dup := model.Subscription{ID: "sub-2", Email: "a@example.com", City: "Lviv", Frequency: "daily", Token: "t2"}
+			assert.ErrorIs(t, repo.Create(ctx, &dup, testChange), ErrDuplicateEmail)
+		})
+	}
+}
+
+// TestRepository_UpdateReplacesConditions verifies that Update overwrites fields and conditions
+// but keeps the status and creation time.
+func TestRepository_UpdateReplacesConditions(t *testing.T) {
+	for name, repo := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			sub := model.Subscription{
+				ID: "sub-1", Email: "a@example.com", City: "Kyiv", Frequency: "alerts", Token: "t",
+				Conditions: []model.Condition{{ID: "c-1", SubscriptionID: "sub-1", Metric: model.MetricPrecipitation, Operator: model.OperatorAbove, Value: 60}},
+			}
+			require.NoError(t, repo.Create(ctx, &sub, testChange))
+
+			sub.City = "Lviv"
+			sub.Conditions = []model.Condition{{ID: "c-2", SubscriptionID: "sub-1", Metric: model.MetricWind, Operator: model.OperatorAbove, Value: 40}}
+			require.NoError(t, repo.Update(ctx, &sub))
+
+			got, err := repo.GetByID(ctx, "sub-1")
+			require.NoError(t, err)
+			assert.Equal(t, "Lviv", got.City)
+			require.Len(t, got.Conditions, 1)
+			assert.Equal(t, model.MetricWind, got.Conditions[0].Metric)
+		})
+	}
+}
+
+// TestRepository_StateChanges verifies Transition with its event history, MarkConfirmationSent and Delete.
+func TestRepository_StateChanges(t *testing.T) {
+	for name, repo := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			seed(t, repo)
+
+			require.NoError(t, repo.Transition(ctx, "pending-1", model.StatusActive, StatusChange{Actor: model.ActorSubscriber, Reason: "confirmed"}))
+			sentAt := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+			require.NoError(t, repo.MarkConfirmationSent(ctx, "pending-1", sentAt))
+
+			got, err := repo.GetByID(ctx, "pending-1")
+			require.NoError(t, err)
+			assert.Equal(t, model.StatusActive, got.Status)
+			require.NotNil(t, got.ConfirmationSentAt)
+			assert.True(t, sentAt.Equal(*got.ConfirmationSentAt))
+
+			err = repo.Transition(ctx, "pending-1", model.StatusExpired, testChange)
+			assert.ErrorIs(t, err, model.ErrInvalidTransition)
+			assert.ErrorIs(t, repo.Transition(ctx, "missing", model.StatusActive, testChange), ErrNotFound)
+
+			events, err := repo.Events(ctx, "pending-1")
+			require.NoError(t, err)
+			require.Len(t, events, 2, "rejected transitions are not recorded")
+			assert.Equal(t, "", events[0].FromStatus)
+			assert.Equal(t, model.StatusPending, events[0].ToStatus)
+			assert.Equal(t, model.StatusPending, events[1].FromStatus)
+			assert.Equal(t, model.StatusActive, events[1].ToStatus)
+			assert.Equal(t, model.ActorSubscriber, events[1].Actor)
+			assert.Equal(t, "confirmed", events[1].Reason)
+
+			require.NoError(t, repo.Delete(ctx, "pending-1"))
+			_, err = repo.GetByID(ctx, "pending-1")
+			assert.ErrorIs(t, err, ErrNotFound)
+			assert.ErrorIs(t, repo.Delete(ctx, "pending-1"), ErrNotFound)
+			events, err = repo.Events(ctx, "pending-1")
+			require.NoError(t, err)
+			assert.Empty(t, events)
+		})
+	}
+}
+
+// TestRepository_ResumeDue verifies that only paused subscriptions past their resume
+// date are due, and that leaving the paused status clears the date.
+func TestRepository_ResumeDue(t *testing.T) {
+	for name, repo := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			seed(t, repo)
+
+			now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+			past, future := now.AddDate(0, 0, -1), now.AddDate(0, 0, 1)
+			for id, on := range map[string]*time.Time{"active-1": &past, "active-2": &future, "active-3": nil} {
+				require.NoError(t, repo.Transition(ctx, id, model.StatusPaused, testChange))
+				require.NoError(t, repo.SetResumeOn(ctx, id, on))
+			}
+
+			due, err := repo.ListResumeDue(ctx, now)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"active-1"}, ids(due))
+			require.NotNil(t, due[0].ResumeOn)
+			assert.True(t, past.Equal(*due[0].ResumeOn))
+
+			require.NoError(t, repo.Transition(ctx, "active-2", model.StatusActive, testChange))
+			got, err := repo.GetByID(ctx, "active-2")
+			require.NoError(t, err)
+			assert.Nil(t, got.ResumeOn)
+
+			assert.ErrorIs(t, repo.SetResumeOn(ctx, "missing", nil), ErrNotFound)
+		})
+	}
+}
+
+// TestRepository_Maintenance verifies the queries behind reminders, expiry and retention,
+// that only one reminder is recorded per confirmation email, and anonymization.
+func TestRepository_Maintenance(t *testing.T) {
+	for name, repo := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			base := seed(t, repo)
+
+			sentAt := base.Add(time.Hour)
+			require.NoError(t, repo.MarkConfirmationSent(ctx, "pending-1", sentAt))
+
+			due, err := repo.ListReminderDue(ctx, sentAt.Add(-time.Minute))
+			require.NoError(t, err)
+			assert.Empty(t, due)
+			due, err = repo.ListReminderDue(ctx, sentAt)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"pending-1"}, ids(due))
+
+			claimed, err := repo.MarkReminderSent(ctx, "pending-1", sentAt.Add(24*time.Hour))
+			require.NoError(t, err)
+			assert.True(t, claimed)
+			claimed, err = repo.MarkReminderSent(ctx, "pending-1", sentAt.Add(25*time.Hour))
+			require.NoError(t, err)
+			assert.False(t, claimed, "one reminder per confirmation email")
+			due, err = repo.ListReminderDue(ctx, sentAt.Add(48*time.Hour))
+			require.NoError(t, err)
+			assert.Empty(t, due)
+
+			// A new confirmation email makes the subscription eligible for a reminder again
+			require.NoError(t, repo.MarkConfirmationSent(ctx, "pending-1", sentAt.Add(72*time.Hour)))
+			due, err = repo.ListReminderDue(ctx, sentAt.Add(96*time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, []string{"pending-1"}, ids(due))
+
+			expire, err := repo.ListExpireDue(ctx, sentAt.Add(71*time.Hour))
+			require.NoError(t, err)
+			assert.Empty(t, expire, "expiry counts from the last confirmation email")
+			expire, err = repo.ListExpireDue(ctx, sentAt.Add(72*time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, []string{"pending-1"}, ids(expire))
+
+			// Status history is recorded with the current time
+			retain, err := repo.ListRetentionDue(ctx, time.Now().Add(-time.Hour))
+			require.NoError(t, err)
+			assert.Empty(t, retain)
+			retain, err = repo.ListRetentionDue(ctx, time.Now().Add(time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, []string{"gone-1"}, ids(retain))
+
+			require.NoError(t, repo.Anonymize(ctx, "gone-1", base))
+			got, err := repo.GetByID(ctx, "gone-1")
+			require.NoError(t, err)
+			assert.Equal(t, "anonymized-gone-1@invalid", got.Email)
+			assert.Empty(t, got.Token)
+			assert.Equal(t, "Kyiv", got.City)
+			require.NotNil(t, got.AnonymizedAt)
+			events, err := repo.Events(ctx, "gone-1")
+			require.NoError(t, err)
+			assert.Len(t, events, 1, "history is kept")
+
+			retain, err = repo.ListRetentionDue(ctx, time.Now().Add(time.Hour))
+			require.NoError(t, err)
+			assert.Empty(t, retain, "anonymized subscriptions are not listed again")
+			assert.ErrorIs(t, repo.Anonymize(ctx, "missing", base), ErrNotFound)
+		})
+	}
+}
+
+// TestRepository_ListAndCounts verifies filtering, ordering, paging and the aggregate counts.
+func TestRepository_ListAndCounts(t *testing.T) {
+	for name, repo := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			base := seed(t, repo)
+
+			active, err := repo.ListActive(ctx, "hourly")
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []string{"active-1", "active-3"}, ids(active))
+
+			subs, total, err := repo.List(ctx, SubscriptionFilter{City: "KYIV"}, 0, 2)
+			require.NoError(t, err)
+			assert.Equal(t, int64(4), total)
+			assert.Equal(t, []string{"gone-1", "active-3"}, ids(subs))
+
+			subs, total, err = repo.List(ctx, SubscriptionFilter{Status: model.StatusActive, OldestFirst: true}, 1, 10)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), total)
+			assert.Equal(t, []string{"active-2", "active-3"}, ids(subs))
+
+			subs, _, err = repo.List(ctx, SubscriptionFilter{CreatedFrom: base.AddDate(0, 0, 1), CreatedTo: base.AddDate(0, 0, 2)}, 0, 10)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"active-2", "active-1"}, ids(subs))
+
+			totals, err := repo.CountByStatus(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]int64{
+				model.StatusPending: 1, model.StatusActive: 3, model.StatusUnsubscribed: 1,
+				model.StatusPaused: 0, model.StatusBounced: 0, model.StatusExpired: 0,
+			}, totals)
+
+			byCity, err := repo.CountActiveByCity(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, []CityCount{
+				{City: "Kyiv", Frequency: "hourly", Count: 1},
+				{City: "Paris", Frequency: "daily", Count: 1},
+				{City: "kyiv", Frequency: "hourly", Count: 1},
+			}, byCity)
+		})
+	}
+}
+
+// TestRepository_TransactionRollsBack verifies that a failing transaction
+// discards every change made through its context.
+func TestRepository_TransactionRollsBack(t *testing.T) {
+	for name, repo := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			seed(t, repo)
+
+			errBoom := errors.New("boom")
+			err := repo.Transaction(ctx, func(ctx context.Context) error {
+				require.NoError(t, repo.Transition(ctx, "pending-1", model.StatusActive, testChange))
+				require.NoError(t, repo.Delete(ctx, "active-1"))
+				return errBoom
+			})
+			assert.ErrorIs(t, err, errBoom)
+
+			got, err := repo.GetByID(ctx, "pending-1")
+			require.NoError(t, err)
+			assert.Equal(t, model.StatusPending, got.Status)
+			_, err = repo.GetByID(ctx, "active-1")
+			assert.NoError(t, err)
+			events, err := repo.Events(ctx, "pending-1")
+			require.NoError(t, err)
+			assert.Len(t, events, 1)
+		})
+	}
+}
+
+// TestMemoryRepository_RollbackKeepsOtherWrites verifies that a failing transaction
+// restores only what it wrote, not writes committed outside it meanwhile.
+func TestMemoryRepository_RollbackKeepsOtherWrites(t *testing.T) {
+	repo := NewMemorySubscriptionRepository()
+	ctx := context.Background()
+	seed(t, repo)
+
+	errBoom := errors.New("boom")
+	err := repo.Transaction(ctx, func(txCtx context.Context) error {
+		require.NoError(t, repo.Transition(txCtx, "pending-1", model.StatusActive, testChange))
+		require.NoError(t, repo.Transition(ctx, "active-1", model.StatusPaused, testChange))
+		return errBoom
+	})
+	assert.ErrorIs(t, err, errBoom)
+
+	got, err := repo.GetByID(ctx, "pending-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusPending, got.Status)
+	got, err = repo.GetByID(ctx, "active-1")
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusPaused, got.Status)
+	events, err := repo.Events(ctx, "active-1")
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+}
+
+// TestGormRepository_TransactionConflict verifies that a transaction which read before a
+// concurrent write committed fails with ErrConflict on SQLite, so callers can retry it.
+func TestGormRepository_TransactionConflict(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "weather.db") + "?_journal_mode=WAL&_busy_timeout=100"
+	gdb, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := gdb.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	_, err = db.Migrate(gdb)
+	require.NoError(t, err)
+	repo := NewGormSubscriptionRepository(gdb)
+	ctx := context.Background()
+
+	err = repo.Transaction(ctx, func(ctx context.Context) error {
+		_, err := repo.GetByEmailForUpdate(ctx, "race@example.com")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		// A concurrent request on another connection inserts first
+		require.NoError(t, repo.Create(context.Background(), &model.Subscription{
+			ID: "rival", Email: "race@example.com", City: "Kyiv", Frequency: "daily", Token: "rival",
+		}, testChange))
+
+		return repo.Create(ctx, &model.Subscription{
+			ID: "late", Email: "race@example.com", City: "Kyiv", Frequency: "daily", Token: "late",
+		}, testChange)
+	})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	_, err = repo.GetByID(ctx, "late")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/pkg/emailhash"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Suppressions stores hashed addresses in the suppressions table.
+// Writes join a surrounding SubscriptionRepository.Transaction.
+type Suppressions struct {
+	DB *gorm.DB
+}
+
+// NewSuppressions returns a suppression list backed by the given database.
+func NewSuppressions(db *gorm.DB) *Suppressions {
+	return &Suppressions{DB: db}
+}
+
+// Add records the address with a model.Suppression* reason. A delivery reason replaces
+// an existing record, so a bounce after an erasure still stops delivery; an erasure
+// never replaces an existing record.
+func (s *Suppressions) Add(ctx context.Context, email, reason string, at time.Time) error {
+	onConflict := clause.OnConflict{DoNothing: true}
+	if slices.Contains(model.DeliverySuppressions, reason) {
+		onConflict = clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"reason", "created_at"})}
+	}
+	return Conn(ctx, s.DB).Clauses(onConflict).Create(&model.Suppression{
+		EmailHash: emailhash.Sum(email),
+		Reason:    reason,
+		CreatedAt: at,
+	}).Error
+}
+
+// Blocked reports whether delivery to the address is suppressed.
+func (s *Suppressions) Blocked(ctx context.Context, email string) (bool, error) {
+	var count int64
+	err := Conn(ctx, s.DB).Model(&model.Suppression{}).
+		Where("email_hash = ? AND reason IN ?", emailhash.Sum(email), model.DeliverySuppressions).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"weatherApi/internal/db"
+	"weatherApi/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestSuppressions verifies that only delivery problems block an address, that a
+// bounce replaces an erasure record and that an erasure never replaces a bounce.
+func TestSuppressions(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	_, err = db.Migrate(gdb)
+	require.NoError(t, err)
+	s := NewSuppressions(gdb)
+	ctx := context.Background()
+	now := time.Now()
+
+	blocked, err := s.Blocked(ctx, "user@example.com")
+	require.NoError(t, err)
+	assert.False(t, blocked)
+
+	require.NoError(t, s.Add(ctx, "user@example.com", model.SuppressionErased, now))
+	blocked, err = s.Blocked(ctx, "user@example.com")
+	require.NoError(t, err)
+	assert.False(t, blocked, "erased addresses may subscribe again")
+
+	require.NoError(t, s.Add(ctx, "User@Example.com", model.SuppressionBounce, now))
+	require.NoError(t, s.Add(ctx, "user@example.com", model.SuppressionErased, now))
+	blocked, err = s.Blocked(ctx, "user@example.com ")
+	require.NoError(t, err)
+	assert.True(t, blocked)
+
+	var stored []model.Suppression
+	require.NoError(t, gdb.Find(&stored).Error)
+	require.Len(t, stored, 1)
+	assert.Equal(t, model.SuppressionBounce, stored[0].Reason)
+}
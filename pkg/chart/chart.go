@@ -0,0 +1,131 @@
+// Package chart renders small PNG charts for emails using only the standard library.
+// Labels use a built-in pixel font that covers digits, signs and a few letters.
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+// Point is one value of a line chart.
+type Point struct {
+	Label string  // X axis label drawn under the point; empty for none
+	Value float64 // Y value
+}
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	gridColor  = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+	textColor  = color.RGBA{0x55, 0x55, 0x55, 0xff}
+	lineColor  = color.RGBA{0xe6, 0x5c, 0x1e, 0xff}
+)
+
+// Layout of the plot area inside the image, in pixels.
+const (
+	marginLeft   = 44
+	marginRight  = 12
+	marginTop    = 12
+	marginBottom = 24
+	gridLines    = 4
+)
+
+// Line renders the points as a line chart of the given size in pixels. The y axis
+// spans the rounded minimum and maximum values and is labelled with unit appended,
+// e.g. "12°C".
+func Line(points []Point, width, height int, unit string) ([]byte, error) {
+	if len(points) < 2 {
+		return nil, errors.New("chart: need at least two points")
+	}
+	plotW, plotH := width-marginLeft-marginRight, height-marginTop-marginBottom
+	if plotW <= 0 || plotH <= 0 {
+		return nil, fmt.Errorf("chart: %dx%d is too small", width, height)
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		lo, hi = math.Min(lo, p.Value), math.Max(hi, p.Value)
+	}
+	// Whole-number grid steps; flat series still get a visible range
+	lo = math.Floor(lo)
+	step := math.Max(1, math.Ceil((math.Ceil(hi)-lo)/gridLines))
+	hi = lo + step*gridLines
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+
+	x := func(i int) int { return marginLeft + i*plotW/(len(points)-1) }
+	y := func(v float64) int { return marginTop + int(math.Round((hi-v)/(hi-lo)*float64(plotH))) }
+
+	for i := 0; i <= gridLines; i++ {
+		v := lo + (hi-lo)*float64(i)/gridLines
+		gy := y(v)
+		drawLine(img, marginLeft, gy, marginLeft+plotW, gy, gridColor)
+		label := fmt.Sprintf("%.0f%s", v, unit)
+		drawText(img, marginLeft-6-textWidth(label), gy-glyphHeight*scale/2, label, textColor)
+	}
+
+	for i, p := range points {
+		if p.Label != "" {
+			drawLine(img, x(i), marginTop, x(i), marginTop+plotH, gridColor)
+			drawText(img, x(i)-textWidth(p.Label)/2, marginTop+plotH+6, p.Label, textColor)
+		}
+	}
+
+	for i := 1; i < len(points); i++ {
+		x0, y0, x1, y1 := x(i-1), y(points[i-1].Value), x(i), y(points[i].Value)
+		// Two pixels wide
+		drawLine(img, x0, y0, x1, y1, lineColor)
+		drawLine(img, x0, y0+1, x1, y1+1, lineColor)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawLine draws a one pixel line with Bresenham's algorithm.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	err := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
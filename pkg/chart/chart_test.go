@@ -0,0 +1,47 @@
+package chart
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLine verifies that the chart is a PNG of the requested size with the line drawn
+// from the first to the last point.
+func TestLine(t *testing.T) {
+	points := []Point{{Label: "12:00", Value: -4}, {Value: 0}, {Label: "14:00", Value: 7.2}}
+	data, err := Line(points, 300, 120, "°C")
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 300, img.Bounds().Dx())
+	assert.Equal(t, 120, img.Bounds().Dy())
+
+	// The minimum is at the bottom of the plot, at the first x position
+	assert.Equal(t, lineColor, color.RGBAModel.Convert(img.At(marginLeft, 120-marginBottom)))
+
+	// The y axis is labelled left of the plot
+	labelled := false
+	for x := 0; x < marginLeft && !labelled; x++ {
+		for y := 0; y < 120 && !labelled; y++ {
+			labelled = color.RGBAModel.Convert(img.At(x, y)) == textColor
+		}
+	}
+	assert.True(t, labelled)
+}
+
+// TestLine_Invalid verifies the rejected inputs and that flat series still render.
+func TestLine_Invalid(t *testing.T) {
+	_, err := Line([]Point{{Value: 1}}, 300, 120, "")
+	assert.Error(t, err)
+	_, err = Line([]Point{{Value: 1}, {Value: 2}}, 40, 20, "")
+	assert.Error(t, err)
+
+	_, err = Line([]Point{{Value: 5}, {Value: 5}}, 300, 120, "%")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,66 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+)
+
+// Glyphs are 3x5 pixel bitmaps drawn at scale, one row per string.
+const (
+	glyphWidth  = 3
+	glyphHeight = 5
+	scale       = 2
+	advance     = (glyphWidth + 1) * scale
+)
+
+var glyphs = map[rune][glyphHeight]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", "###", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", ".#.", ".#.", ".#."},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	'-': {"...", "...", "###", "...", "..."},
+	'+': {"...", ".#.", "###", ".#.", "..."},
+	':': {"...", ".#.", "...", ".#.", "..."},
+	'.': {"...", "...", "...", "...", ".#."},
+	'%': {"#.#", "..#", ".#.", "#..", "#.#"},
+	'°': {".#.", "#.#", ".#.", "...", "..."},
+	'C': {"###", "#..", "#..", "#..", "###"},
+	'h': {"#..", "#..", "###", "#.#", "#.#"},
+	' ': {"...", "...", "...", "...", "..."},
+}
+
+// textWidth returns the width of s in pixels when drawn with drawText.
+func textWidth(s string) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return n*advance - scale
+}
+
+// drawText draws s with its top left corner at (x, y). Characters without a glyph
+// are left blank.
+func drawText(img *image.RGBA, x, y int, s string, c color.Color) {
+	for _, r := range s {
+		glyph := glyphs[r]
+		for row, bits := range glyph {
+			for col, bit := range bits {
+				if bit != '#' {
+					continue
+				}
+				for dy := range scale {
+					for dx := range scale {
+						img.Set(x+col*scale+dx, y+row*scale+dy, c)
+					}
+				}
+			}
+		}
+		x += advance
+	}
+}
@@ -0,0 +1,165 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+	"weatherApi/pkg/chart"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Digest layout.
+const (
+	digestHours        = 24 // Hours covered by the chart and the precipitation timeline
+	digestRainStep     = 3  // Hours between precipitation timeline entries
+	digestLabelStep    = 6  // Hours between chart axis labels
+	digestChartWidth   = 560
+	digestChartHeight  = 200
+	digestChartContent = "temperature-chart"
+)
+
+// weekdays are Ukrainian short weekday names, indexed by time.Weekday.
+var weekdays = [...]string{"Нд", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"}
+
+// digest is a rendered daily digest email.
+type digest struct {
+	Subject string
+	Plain   string
+	HTML    string
+	Chart   []byte // PNG shown inline as cid:temperature-chart; nil without hourly data
+}
+
+// SendDigestEmail sends the daily digest: today's high and low, sunrise and sunset,
+// a precipitation timeline and temperature chart for the next 24 hours, and the
+// outlook for every forecast day. Hours are shown in timezone (an IANA name), or in
+// UTC if it is empty or unknown. The chart is attached as an inline PNG; the plain
+// text part carries the same data for clients that don't show images.
+func SendDigestEmail(ctx context.Context, toEmail string, weather *model.Weather, forecast *model.Forecast, city, timezone, token string) error {
+	d := buildDigest(weather, forecast, city, token, time.Now().In(TimeZone(ctx, timezone)))
+
+	var images []InlineImage
+	if d.Chart != nil {
+		images = append(images, InlineImage{
+			ContentID: digestChartContent, Filename: "temperature.png", ContentType: "image/png", Data: d.Chart,
+		})
+	}
+	return SendEmailWithImages(ctx, toEmail, d.Subject, d.Plain, d.HTML, UnsubscribeHeaders(token), images)
+}
+
+// buildDigest renders the digest for the forecast as seen at now, with hours shown
+// in now's location. weather is the current weather and may be nil.
+func buildDigest(weather *model.Weather, forecast *model.Forecast, city, token string, now time.Time) digest {
+	caser := cases.Title(language.English)
+	cityName := caser.String(city)
+	unsubscribeURL := fmt.Sprintf("%s/api/unsubscribe/%s", config.C.BaseURL, token)
+	pauseURL := fmt.Sprintf("%s/pause/%s", config.C.BaseURL, token)
+
+	var plain, body strings.Builder
+	fmt.Fprintf(&plain, "Щоденний прогноз погоди для %s\n\n", cityName)
+	fmt.Fprintf(&body, "<h2>Щоденний прогноз погоди для %s</h2>", html.EscapeString(cityName))
+
+	if weather != nil {
+		fmt.Fprintf(&plain, "Зараз: %.1f°C, %s\n", weather.Temperature, weather.Description)
+		fmt.Fprintf(&body, "<p><strong>Зараз:</strong> %.1f°C, %s</p>", weather.Temperature, html.EscapeString(weather.Description))
+	}
+	if len(forecast.Days) > 0 {
+		today := forecast.Days[0]
+		fmt.Fprintf(&plain, "Сьогодні: від %.1f°C до %.1f°C, %s\nСхід сонця: %s, захід: %s\n",
+			today.MinTempC, today.MaxTempC, today.Description, today.Sunrise, today.Sunset)
+		fmt.Fprintf(&body, "<p><strong>Сьогодні:</strong> від %.1f°C до %.1f°C, %s</p><p><strong>Схід сонця:</strong> %s, <strong>захід:</strong> %s</p>",
+			today.MinTempC, today.MaxTempC, html.EscapeString(today.Description), html.EscapeString(today.Sunrise), html.EscapeString(today.Sunset))
+	}
+
+	zone := now.Location().String()
+	hours := upcomingHours(forecast, now, digestHours)
+	var d digest
+	if len(hours) > 0 {
+		points := make([]chart.Point, len(hours))
+		for i, h := range hours {
+			points[i].Value = h.TempC
+			if i%digestLabelStep == 0 {
+				points[i].Label = h.Time.In(now.Location()).Format("15:04")
+			}
+		}
+		if png, err := renderTemperatureChart(points); err != nil {
+			slog.Warn("failed to render temperature chart", "error", err)
+		} else if png != nil {
+			d.Chart = png
+			fmt.Fprintf(&body, `<h3>Температура на %d год. (%s)</h3><p><img src="cid:%s" alt="Графік температури" width="%d" height="%d"></p>`,
+				len(hours), html.EscapeString(zone), digestChartContent, digestChartWidth, digestChartHeight)
+		}
+
+		fmt.Fprintf(&plain, "\nЙмовірність опадів (%s):\n", zone)
+		fmt.Fprintf(&body, `<h3>Ймовірність опадів (%s)</h3><table cellpadding="6" style="border-collapse:collapse;text-align:center"><tr>`, html.EscapeString(zone))
+		var cells strings.Builder
+		for i := 0; i < len(hours); i += digestRainStep {
+			h := hours[i]
+			at := h.Time.In(now.Location()).Format("15:04")
+			fmt.Fprintf(&plain, "%s — %d%%\n", at, h.ChanceOfRain)
+			fmt.Fprintf(&body, "<td>%s</td>", at)
+			fmt.Fprintf(&cells, `<td style="background:rgba(30,120,230,%.2f)">%d%%</td>`, float64(h.ChanceOfRain)/100, h.ChanceOfRain)
+		}
+		body.WriteString("</tr><tr>" + cells.String() + "</tr></table>")
+	}
+
+	if len(forecast.Days) > 0 {
+		fmt.Fprintf(&plain, "\nПрогноз на %d дн.:\n", len(forecast.Days))
+		fmt.Fprintf(&body, `<h3>Прогноз на %d дн.</h3><table cellpadding="6" style="border-collapse:collapse">`, len(forecast.Days))
+		for _, day := range forecast.Days {
+			date := formatDigestDate(day.Date)
+			fmt.Fprintf(&plain, "%s: %.0f…%.0f°C, опади %d%%, %s\n", date, day.MinTempC, day.MaxTempC, day.ChanceOfRain, day.Description)
+			fmt.Fprintf(&body, "<tr><td><strong>%s</strong></td><td>%.0f…%.0f°C</td><td>☔ %d%%</td><td>%s</td></tr>",
+				date, day.MinTempC, day.MaxTempC, day.ChanceOfRain, html.EscapeString(day.Description))
+		}
+		body.WriteString("</table>")
+	}
+
+	fmt.Fprintf(&plain, "\nЇдете у відпустку? Призупинити розсилку: %s\nЯкщо бажаєте скасувати підписку, перейдіть за посиланням: %s", pauseURL, unsubscribeURL)
+	fmt.Fprintf(&body, `<hr>
+		<p style="font-size:small">Їдете у відпустку? <a href="%s">Призупинити розсилку</a></p>
+		<p style="font-size:small">Не хочете більше отримувати? <a href="%s">Відписатися</a></p>`, pauseURL, unsubscribeURL)
+
+	d.Subject = fmt.Sprintf("Щоденний прогноз погоди для %s", cityName)
+	d.Plain = plain.String()
+	d.HTML = body.String()
+	return d
+}
+
+// renderTemperatureChart renders the digest chart, or nil if there is too little to plot.
+func renderTemperatureChart(points []chart.Point) ([]byte, error) {
+	if len(points) < 2 {
+		return nil, nil
+	}
+	return chart.Line(points, digestChartWidth, digestChartHeight, "°C")
+}
+
+// upcomingHours returns up to n forecast hours starting with the current hour.
+func upcomingHours(forecast *model.Forecast, now time.Time, n int) []model.ForecastHour {
+	from := now.Truncate(time.Hour)
+	var hours []model.ForecastHour
+	for _, day := range forecast.Days {
+		for _, h := range day.Hours {
+			if !h.Time.Before(from) && len(hours) < n {
+				hours = append(hours, h)
+			}
+		}
+	}
+	return hours
+}
+
+// formatDigestDate formats a provider date (YYYY-MM-DD) as e.g. "Пт 24.10".
+func formatDigestDate(date string) string {
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return date
+	}
+	return weekdays[t.Weekday()] + " " + t.Format("02.01")
+}
@@ -0,0 +1,98 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"os"
+	"testing"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	config.C = &config.Config{BaseURL: "https://weather.example.com"}
+	os.Exit(m.Run())
+}
+
+// testForecast returns a three-day forecast with hourly data from midnight UTC on 2026-10-19.
+func testForecast() *model.Forecast {
+	start := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	forecast := &model.Forecast{}
+	for d := range 3 {
+		day := model.ForecastDay{
+			Date: start.AddDate(0, 0, d).Format(time.DateOnly), MinTempC: 4 + float64(d), MaxTempC: 15 + float64(d),
+			ChanceOfRain: 10 * d, Description: "Patchy <rain>", Sunrise: "07:21 AM", Sunset: "06:02 PM",
+		}
+		for h := range 24 {
+			day.Hours = append(day.Hours, model.ForecastHour{
+				Time: start.AddDate(0, 0, d).Add(time.Duration(h) * time.Hour), TempC: float64(h % 12), ChanceOfRain: 4 * h,
+			})
+		}
+		forecast.Days = append(forecast.Days, day)
+	}
+	return forecast
+}
+
+// TestBuildDigest verifies the digest sections in both parts and the inline chart.
+func TestBuildDigest(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+	d := buildDigest(&model.Weather{Temperature: 11.5, Description: "Cloudy"}, testForecast(), "kyiv", "tok", now)
+
+	assert.Equal(t, "Щоденний прогноз погоди для Kyiv", d.Subject)
+	for _, part := range []string{d.Plain, d.HTML} {
+		assert.Contains(t, part, "11.5°C")
+		assert.Contains(t, part, "від 4.0°C до 15.0°C", "today's low and high")
+		assert.Contains(t, part, "07:21 AM")
+		assert.Contains(t, part, "06:02 PM")
+		assert.Contains(t, part, "12:00", "the timeline starts with the current hour")
+		assert.Contains(t, part, "Пн 19.10")
+		assert.Contains(t, part, "Ср 21.10", "the outlook covers every forecast day")
+		assert.Contains(t, part, "https://weather.example.com/api/unsubscribe/tok")
+	}
+	assert.Contains(t, d.Plain, "12:00 — 48%")
+	assert.Contains(t, d.HTML, `src="cid:temperature-chart"`)
+	assert.Contains(t, d.HTML, "Patchy &lt;rain&gt;")
+	assert.NotContains(t, d.HTML, "<rain>")
+
+	require.NotNil(t, d.Chart)
+	img, err := png.Decode(bytes.NewReader(d.Chart))
+	require.NoError(t, err)
+	assert.Equal(t, digestChartWidth, img.Bounds().Dx())
+}
+
+// TestBuildDigest_NoHourlyData verifies that the digest is still sent as text without a chart.
+func TestBuildDigest_NoHourlyData(t *testing.T) {
+	forecast := &model.Forecast{Days: []model.ForecastDay{{Date: "2026-10-19", MinTempC: 1, MaxTempC: 5}}}
+	d := buildDigest(nil, forecast, "kyiv", "tok", time.Now())
+
+	assert.Nil(t, d.Chart)
+	assert.NotContains(t, d.HTML, "cid:")
+	assert.Contains(t, d.Plain, "від 1.0°C до 5.0°C")
+}
+
+// TestBuildDigest_LocalTimezone verifies that the timeline and chart hours are shown in
+// the location's timezone rather than UTC.
+func TestBuildDigest_LocalTimezone(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	require.NoError(t, err)
+	now := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC).In(kyiv)
+	d := buildDigest(nil, testForecast(), "kyiv", "tok", now)
+
+	assert.Contains(t, d.Plain, "Ймовірність опадів (Europe/Kyiv)")
+	assert.Contains(t, d.Plain, "15:00 — 48%", "12:00 UTC is 15:00 in Kyiv")
+	assert.Contains(t, d.HTML, "Температура на 24 год. (Europe/Kyiv)")
+	assert.NotContains(t, d.Plain, "(UTC)")
+}
+
+// TestTimeZone verifies the UTC fallback for missing and unknown timezones.
+func TestTimeZone(t *testing.T) {
+	assert.Equal(t, time.UTC, TimeZone(context.Background(), ""))
+	assert.Equal(t, time.UTC, TimeZone(context.Background(), "Mars/Olympus_Mons"))
+	assert.Equal(t, "Europe/Kyiv", TimeZone(context.Background(), "Europe/Kyiv").String())
+}
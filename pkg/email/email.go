@@ -1,44 +1,156 @@
 package email
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+	"time"
 
 	"weatherApi/config"
 
 	"weatherApi/internal/model"
+	"weatherApi/pkg/engagement"
+	"weatherApi/pkg/logging"
+	"weatherApi/pkg/metrics"
+	"weatherApi/pkg/tracing"
 
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrSuppressed is returned when the recipient is on the suppression list,
+// e.g. after a hard bounce or spam report.
+var ErrSuppressed = errors.New("recipient address is suppressed")
+
+// SuppressionCheck reports whether delivery to an address is suppressed.
+type SuppressionCheck func(ctx context.Context, email string) (bool, error)
+
+// CheckSuppression returns ErrSuppressed if check reports toEmail as suppressed. A nil
+// check allows every address. If the check itself fails the email is sent (fail open),
+// so a database hiccup does not stop all mail.
+func CheckSuppression(ctx context.Context, check SuppressionCheck, toEmail string) error {
+	if check == nil {
+		return nil
+	}
+	blocked, err := check(ctx, toEmail)
+	if err != nil {
+		slog.WarnContext(ctx, "suppression check failed, sending anyway", logging.Email(toEmail), "error", err)
+		return nil
+	}
+	if !blocked {
+		return nil
+	}
+	metrics.EmailSends.WithLabelValues(metrics.OutcomeSuppressed).Inc()
+	slog.InfoContext(ctx, "email not sent to suppressed address", logging.Email(toEmail))
+	return ErrSuppressed
+}
+
 // SendEmail sends an email via SendGrid using environment variables:
 // - SENDGRID_API_KEY: API key for authentication
 // - EMAIL_FROM: sender email address
 // Fails if SendGrid responds with status code >= 400.
-func SendEmail(toEmail, subject, plainTextContent, htmlContent string) error {
+func SendEmail(ctx context.Context, toEmail, subject, plainTextContent, htmlContent string) error {
+	return SendEmailWithHeaders(ctx, toEmail, subject, plainTextContent, htmlContent, nil)
+}
+
+// SendEmailWithHeaders is SendEmail with additional message headers.
+// If ctx carries a tracked delivery (engagement.WithDelivery), the HTML body's
+// links and an open pixel are routed through the tracking endpoints.
+func SendEmailWithHeaders(ctx context.Context, toEmail, subject, plainTextContent, htmlContent string, headers map[string]string) error {
+	return SendEmailWithImages(ctx, toEmail, subject, plainTextContent, htmlContent, headers, nil)
+}
+
+// InlineImage is an image attached to an email and shown in its HTML body
+// with <img src="cid:ContentID">.
+type InlineImage struct {
+	ContentID   string
+	Filename    string
+	ContentType string // e.g. "image/png"
+	Data        []byte
+}
+
+// SendEmailWithImages is SendEmailWithHeaders with inline images.
+func SendEmailWithImages(ctx context.Context, toEmail, subject, plainTextContent, htmlContent string, headers map[string]string, images []InlineImage) (err error) {
+	_, span := tracing.Start(ctx, "sendgrid.send", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { tracing.End(span, err) }()
+
+	if deliveryID := engagement.DeliveryID(ctx); deliveryID != "" {
+		htmlContent = engagement.Rewrite(htmlContent, deliveryID)
+	}
+
 	from := mail.NewEmail("weatherApp", config.C.EmailFrom)
 	to := mail.NewEmail("User", toEmail)
 	message := mail.NewSingleEmail(from, subject, to, plainTextContent, htmlContent)
+	for key, value := range headers {
+		message.SetHeader(key, value)
+	}
+	for _, img := range images {
+		message.AddAttachment(mail.NewAttachment().
+			SetContent(base64.StdEncoding.EncodeToString(img.Data)).
+			SetType(img.ContentType).
+			SetFilename(img.Filename).
+			SetDisposition("inline").
+			SetContentID(img.ContentID))
+	}
 
 	client := sendgrid.NewSendClient(config.C.SendGridKey)
 	response, err := client.Send(message)
 	if err != nil {
+		metrics.EmailSends.WithLabelValues(metrics.OutcomeTransportError).Inc()
+		slog.WarnContext(ctx, "email send failed", logging.Email(toEmail), "error", err)
 		return err
 	}
 
+	span.SetAttributes(attribute.Int("http.response.status_code", response.StatusCode))
 	if response.StatusCode >= 400 {
+		metrics.EmailSends.WithLabelValues(metrics.OutcomeRejected).Inc()
+		slog.WarnContext(ctx, "email rejected by SendGrid", logging.Email(toEmail), "status", response.StatusCode)
 		return fmt.Errorf("SendGrid failed with status %d: %s", response.StatusCode, response.Body)
 	}
 
+	metrics.EmailSends.WithLabelValues(metrics.OutcomeOK).Inc()
+	slog.InfoContext(ctx, "email sent", logging.Email(toEmail))
 	return nil
 }
 
+// UnsubscribeHeaders returns the RFC 2369 List-Unsubscribe and RFC 8058
+// List-Unsubscribe-Post headers for a subscription token. Mail clients that support
+// one-click unsubscribe POST "List-Unsubscribe=One-Click" to the URL without
+// showing a page.
+func UnsubscribeHeaders(token string) map[string]string {
+	return map[string]string{
+		"List-Unsubscribe":      fmt.Sprintf("<%s/api/unsubscribe/%s>", config.C.BaseURL, token),
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+}
+
+// TimeZone loads the IANA timezone of a subscription's location, in which emails show
+// forecast and alert times. Subscriptions without a resolved location, or with a name
+// this system doesn't know, get UTC.
+func TimeZone(ctx context.Context, timezone string) *time.Location {
+	if timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		slog.WarnContext(ctx, "unknown location timezone, using UTC", "timezone", timezone, "error", err)
+		return time.UTC
+	}
+	return loc
+}
+
 // SendConfirmationEmail sends a confirmation link to the user's email.
 // The token is embedded as part of a URL and used for verifying the subscription.
-func SendConfirmationEmail(toEmail, token string) error {
+func SendConfirmationEmail(ctx context.Context, toEmail, token string) error {
 	subject := "Підтвердіть вашу підписку на погодні сповіщення"
 
 	confirmURL := fmt.Sprintf("%s/api/confirm/%s", config.C.BaseURL, token)
@@ -48,20 +160,60 @@ func SendConfirmationEmail(toEmail, token string) error {
 		confirmURL,
 	)
 
-	return SendEmail(toEmail, subject, plainText, htmlContent)
+	return SendEmail(ctx, toEmail, subject, plainText, htmlContent)
 }
 
-// SendWeatherEmail sends a weather update to the user with an unsubscribe link.
-// The token is used in the unsubscribe URL and must be securely generated.
-func SendWeatherEmail(toEmail string, weather *model.Weather, city string, token string) error {
+// SendConfirmationReminderEmail reminds the user of an unconfirmed subscription,
+// repeating the confirmation link and when the subscription expires.
+func SendConfirmationReminderEmail(ctx context.Context, toEmail, token string, expiresInDays int) error {
+	subject := "Нагадування: підтвердіть вашу підписку на погодні сповіщення"
+
+	confirmURL := fmt.Sprintf("%s/api/confirm/%s", config.C.BaseURL, token)
+	expiry := ""
+	if expiresInDays > 0 {
+		expiry = fmt.Sprintf(" Без підтвердження підписка буде скасована через %d дн. після листа з підтвердженням.", expiresInDays)
+	}
+	plainText := "Ви ще не підтвердили підписку на погодні сповіщення." + expiry + "\n\nПідтвердити: " + confirmURL
+	htmlContent := fmt.Sprintf(
+		`<p>Ви ще не підтвердили підписку на погодні сповіщення.%s</p><p><a href="%s">Підтвердити підписку</a></p>`,
+		expiry, confirmURL,
+	)
+
+	return SendEmail(ctx, toEmail, subject, plainText, htmlContent)
+}
+
+// SendPrivacyLinkEmail sends the magic link to the page where the user can export
+// or erase their data. The link expires after validFor.
+func SendPrivacyLinkEmail(ctx context.Context, toEmail, token string, validFor time.Duration) error {
+	subject := "Ваші дані у погодних сповіщеннях"
+
+	privacyURL := fmt.Sprintf("%s/privacy/%s", config.C.BaseURL, token)
+	minutes := int(validFor.Minutes())
+	plainText := fmt.Sprintf(
+		"Ви запросили доступ до своїх даних. За посиланням можна завантажити їх або остаточно видалити (діє %d хв.): %s\n\nЯкщо це були не ви, просто проігноруйте цей лист.",
+		minutes, privacyURL,
+	)
+	htmlContent := fmt.Sprintf(
+		`<p>Ви запросили доступ до своїх даних. За посиланням можна завантажити їх або остаточно видалити (діє %d хв.):</p><p><a href="%s">Керувати моїми даними</a></p><p style="font-size:small">Якщо це були не ви, просто проігноруйте цей лист.</p>`,
+		minutes, privacyURL,
+	)
+
+	return SendEmail(ctx, toEmail, subject, plainText, htmlContent)
+}
+
+// SendWeatherEmail sends a weather update to the user with pause and unsubscribe links
+// and one-click List-Unsubscribe headers. The token is used in all URLs and must be
+// securely generated.
+func SendWeatherEmail(ctx context.Context, toEmail string, weather *model.Weather, city string, token string) error {
 	caser := cases.Title(language.English)
 	subject := fmt.Sprintf("Ваше оновлення погоди для %s", caser.String(city))
 
 	unsubscribeURL := fmt.Sprintf("%s/api/unsubscribe/%s", config.C.BaseURL, token)
+	pauseURL := fmt.Sprintf("%s/pause/%s", config.C.BaseURL, token)
 
 	plainText := fmt.Sprintf(
-		"Вітаємо!\n\nПоточна погода в %s:\nТемпература: %.1f°C\nВологість: %d%%\nОпис: %s\n\nЯкщо бажаєте скасувати підписку, перейдіть за посиланням: %s",
-		caser.String(city), weather.Temperature, weather.Humidity, weather.Description, unsubscribeURL,
+		"Вітаємо!\n\nПоточна погода в %s:\nТемпература: %.1f°C\nВологість: %d%%\nОпис: %s\n\nЇдете у відпустку? Призупинити розсилку: %s\nЯкщо бажаєте скасувати підписку, перейдіть за посиланням: %s",
+		caser.String(city), weather.Temperature, weather.Humidity, weather.Description, pauseURL, unsubscribeURL,
 	)
 
 	htmlContent := fmt.Sprintf(
@@ -70,9 +222,85 @@ func SendWeatherEmail(toEmail string, weather *model.Weather, city string, token
 		<p><strong>Вологість:</strong> %d%%</p>
 		<p><strong>Опис:</strong> %s</p>
 		<hr>
+		<p style="font-size:small">Їдете у відпустку? <a href="%s">Призупинити розсилку</a></p>
+		<p style="font-size:small">Не хочете більше отримувати? <a href="%s">Відписатися</a></p>`,
+		caser.String(city), weather.Temperature, weather.Humidity, weather.Description, pauseURL, unsubscribeURL,
+	)
+
+	return SendEmailWithHeaders(ctx, toEmail, subject, plainText, htmlContent, UnsubscribeHeaders(token))
+}
+
+// SendAlertEmail sends a severe weather alert to the user with pause and unsubscribe links.
+// Sent immediately when a new warning appears for the subscribed location, with
+// one-click List-Unsubscribe headers. The expiry is shown in the location's timezone.
+func SendAlertEmail(ctx context.Context, toEmail string, alert *model.Alert, city, timezone, token string) error {
+	caser := cases.Title(language.English)
+	subject := fmt.Sprintf("⚠️ Погодне попередження для %s: %s", caser.String(city), alert.Event)
+
+	unsubscribeURL := fmt.Sprintf("%s/api/unsubscribe/%s", config.C.BaseURL, token)
+	pauseURL := fmt.Sprintf("%s/pause/%s", config.C.BaseURL, token)
+
+	validUntil := "невідомо"
+	if !alert.Expires.IsZero() {
+		validUntil = alert.Expires.In(TimeZone(ctx, timezone)).Format("02.01.2006 15:04 MST")
+	}
+
+	plainText := fmt.Sprintf(
+		"Увага!\n\n%s\n\nМісто: %s\nТип: %s\nРівень: %s\nДіє до: %s\n\n%s\n\n%s\n\nЇдете у відпустку? Призупинити розсилку: %s\nЯкщо бажаєте скасувати підписку, перейдіть за посиланням: %s",
+		alert.Headline, caser.String(city), alert.Event, alert.Severity, validUntil,
+		alert.Description, alert.Instruction, pauseURL, unsubscribeURL,
+	)
+
+	htmlContent := fmt.Sprintf(
+		`<h2>⚠️ %s</h2>
+		<p><strong>Місто:</strong> %s</p>
+		<p><strong>Тип:</strong> %s</p>
+		<p><strong>Рівень:</strong> %s</p>
+		<p><strong>Діє до:</strong> %s</p>
+		<p>%s</p>
+		<p><em>%s</em></p>
+		<hr>
+		<p style="font-size:small">Їдете у відпустку? <a href="%s">Призупинити розсилку</a></p>
+		<p style="font-size:small">Не хочете більше отримувати? <a href="%s">Відписатися</a></p>`,
+		html.EscapeString(alert.Headline), caser.String(city), html.EscapeString(alert.Event),
+		html.EscapeString(alert.Severity), validUntil, html.EscapeString(alert.Description),
+		html.EscapeString(alert.Instruction), pauseURL, unsubscribeURL,
+	)
+
+	return SendEmailWithHeaders(ctx, toEmail, subject, plainText, htmlContent, UnsubscribeHeaders(token))
+}
+
+// SendConditionEmail sends a weather update triggered by the user's own conditions
+// (e.g. "tell me if it rains tomorrow"), listing which conditions matched and when.
+// Carries one-click List-Unsubscribe headers like SendWeatherEmail.
+func SendConditionEmail(ctx context.Context, toEmail string, weather *model.Weather, matched []string, city string, token string) error {
+	caser := cases.Title(language.English)
+	subject := fmt.Sprintf("Спрацювала умова погоди для %s", caser.String(city))
+
+	unsubscribeURL := fmt.Sprintf("%s/api/unsubscribe/%s", config.C.BaseURL, token)
+	pauseURL := fmt.Sprintf("%s/pause/%s", config.C.BaseURL, token)
+
+	plainText := fmt.Sprintf(
+		"Вітаємо!\n\nСпрацювали ваші умови для %s:\n- %s\n\nПоточна погода:\nТемпература: %.1f°C\nВологість: %d%%\nОпис: %s\n\nЇдете у відпустку? Призупинити розсилку: %s\nЯкщо бажаєте скасувати підписку, перейдіть за посиланням: %s",
+		caser.String(city), strings.Join(matched, "\n- "), weather.Temperature, weather.Humidity, weather.Description, pauseURL, unsubscribeURL,
+	)
+
+	items := make([]string, 0, len(matched))
+	for _, m := range matched {
+		items = append(items, "<li>"+html.EscapeString(m)+"</li>")
+	}
+
+	htmlContent := fmt.Sprintf(
+		`<h2>Спрацювала умова погоди для %s</h2>
+		<ul>%s</ul>
+		<p><strong>Температура зараз:</strong> %.1f°C</p>
+		<p><strong>Вологість:</strong> %d%%</p>
+		<p><strong>Опис:</strong> %s</p>
+		<hr>
+		<p style="font-size:small">Їдете у відпустку? <a href="%s">Призупинити розсилку</a></p>
 		<p style="font-size:small">Не хочете більше отримувати? <a href="%s">Відписатися</a></p>`,
-		caser.String(city), weather.Temperature, weather.Humidity, weather.Description, unsubscribeURL,
+		caser.String(city), strings.Join(items, ""), weather.Temperature, weather.Humidity, weather.Description, pauseURL, unsubscribeURL,
 	)
 
-	return SendEmail(toEmail, subject, plainText, htmlContent)
+	return SendEmailWithHeaders(ctx, toEmail, subject, plainText, htmlContent, UnsubscribeHeaders(token))
 }
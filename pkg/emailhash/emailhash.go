@@ -0,0 +1,30 @@
+// Package emailhash identifies email addresses without storing them. Hashes are keyed
+// with a server-side secret (config.C.EmailHashKey), so they cannot be reversed by
+// hashing a list of known addresses.
+package emailhash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"weatherApi/config"
+)
+
+// Sum returns the hex HMAC-SHA256 of the normalized (trimmed, lowercased) address.
+// Suppression records store it in full; logs use a prefix.
+func Sum(address string) string {
+	mac := hmac.New(sha256.New, key())
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(address))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// key returns the configured secret. Packages whose tests run without configuration
+// still log hashed addresses, keyed with an empty secret.
+func key() []byte {
+	if config.C == nil {
+		return nil
+	}
+	return []byte(config.C.EmailHashKey)
+}
@@ -0,0 +1,23 @@
+package emailhash
+
+import (
+	"testing"
+
+	"weatherApi/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSum verifies that hashes ignore case and surrounding spaces, and depend on the key.
+func TestSum(t *testing.T) {
+	config.C = &config.Config{EmailHashKey: "k1"}
+	t.Cleanup(func() { config.C = nil })
+
+	assert.Equal(t, Sum("a@example.com"), Sum(" A@Example.COM "))
+	assert.NotEqual(t, Sum("a@example.com"), Sum("b@example.com"))
+	assert.Len(t, Sum("a@example.com"), 64)
+
+	first := Sum("a@example.com")
+	config.C.EmailHashKey = "k2"
+	assert.NotEqual(t, first, Sum("a@example.com"))
+}
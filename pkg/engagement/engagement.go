@@ -0,0 +1,88 @@
+// Package engagement adds open and click tracking to outgoing emails.
+// Tracking URLs carry an HMAC so they cannot be forged for other deliveries
+// or turned into an open redirect.
+package engagement
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"weatherApi/config"
+)
+
+type ctxKey int
+
+const deliveryIDKey ctxKey = iota
+
+// WithDelivery returns a context marking the email sent with it as the tracked
+// delivery id. Emails sent without one are not tracked.
+func WithDelivery(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, deliveryIDKey, id)
+}
+
+// DeliveryID returns the tracked delivery of ctx, or "" if there is none.
+func DeliveryID(ctx context.Context) string {
+	id, _ := ctx.Value(deliveryIDKey).(string)
+	return id
+}
+
+// OpenURL returns the URL of the tracking pixel for a delivery.
+func OpenURL(deliveryID string) string {
+	return fmt.Sprintf("%s/track/open/%s?sig=%s", config.C.BaseURL, url.PathEscape(deliveryID), Sign(deliveryID, ""))
+}
+
+// ClickURL returns a URL that records a click of the delivery's link to target
+// and redirects there.
+func ClickURL(deliveryID, target string) string {
+	return fmt.Sprintf("%s/track/click/%s?url=%s&sig=%s",
+		config.C.BaseURL, url.PathEscape(deliveryID), url.QueryEscape(target), Sign(deliveryID, target))
+}
+
+// Sign returns the signature of a delivery's pixel (empty target) or link.
+func Sign(deliveryID, target string) string {
+	mac := hmac.New(sha256.New, []byte(config.C.JWTSecret))
+	mac.Write([]byte("engagement:" + deliveryID + "\n" + target))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Valid reports whether sig was produced by Sign for the same delivery and target.
+func Valid(deliveryID, target, sig string) bool {
+	return hmac.Equal([]byte(sig), []byte(Sign(deliveryID, target)))
+}
+
+var hrefPattern = regexp.MustCompile(`href="(https?://[^"]+)"`)
+
+// untrackedPaths are the paths under config.C.BaseURL whose links are never rewritten:
+// unsubscribing or pausing must work even when the tracking redirect is blocked, and
+// does not count as engagement.
+var untrackedPaths = []string{"/api/unsubscribe/", "/pause/", "/api/pause/"}
+
+// tracked reports whether a link to target is routed through click tracking.
+func tracked(target string) bool {
+	for _, p := range untrackedPaths {
+		if strings.HasPrefix(target, config.C.BaseURL+p) {
+			return false
+		}
+	}
+	return true
+}
+
+// Rewrite routes the http(s) links of an HTML email body through ClickURL, except
+// unsubscribe and pause links, and appends the tracking pixel.
+func Rewrite(body, deliveryID string) string {
+	body = hrefPattern.ReplaceAllStringFunc(body, func(attr string) string {
+		target := html.UnescapeString(hrefPattern.FindStringSubmatch(attr)[1])
+		if !tracked(target) {
+			return attr
+		}
+		return `href="` + html.EscapeString(ClickURL(deliveryID, target)) + `"`
+	})
+	return body + `<img src="` + html.EscapeString(OpenURL(deliveryID)) + `" width="1" height="1" alt="" style="display:none">`
+}
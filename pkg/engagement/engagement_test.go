@@ -0,0 +1,57 @@
+package engagement
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"weatherApi/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	config.C = &config.Config{BaseURL: "https://weather.example.com", JWTSecret: "test-secret"}
+	os.Exit(m.Run())
+}
+
+// TestRewrite verifies that links are routed through signed click redirects, except
+// unsubscribe and pause links, and that the open pixel is appended.
+func TestRewrite(t *testing.T) {
+	body := `<p><a href="https://weather.example.com/subscribe?a=1&amp;b=2">Subscribe</a> <a href="mailto:x@example.com">Mail</a>` +
+		` <a href="https://weather.example.com/pause/tok">Pause</a> <a href="https://weather.example.com/api/unsubscribe/tok">Unsubscribe</a></p>`
+	out := Rewrite(body, "d-1")
+
+	assert.Contains(t, out, `href="mailto:x@example.com"`, "only http(s) links are tracked")
+	assert.Contains(t, out, `href="https://weather.example.com/pause/tok"`, "pause links are not tracked")
+	assert.Contains(t, out, `href="https://weather.example.com/api/unsubscribe/tok"`, "unsubscribe links are not tracked")
+	assert.True(t, strings.HasSuffix(out, `width="1" height="1" alt="" style="display:none">`))
+	assert.Contains(t, out, `src="https://weather.example.com/track/open/d-1?sig=`+Sign("d-1", "")+`"`)
+
+	start := strings.Index(out, `href="`) + len(`href="`)
+	link, err := url.Parse(strings.ReplaceAll(out[start:start+strings.Index(out[start:], `"`)], "&amp;", "&"))
+	require.NoError(t, err)
+	assert.Equal(t, "/track/click/d-1", link.Path)
+	target := link.Query().Get("url")
+	assert.Equal(t, "https://weather.example.com/subscribe?a=1&b=2", target)
+	assert.True(t, Valid("d-1", target, link.Query().Get("sig")))
+}
+
+// TestValid verifies that signatures are bound to the delivery and the target.
+func TestValid(t *testing.T) {
+	sig := Sign("d-1", "https://example.com")
+	assert.True(t, Valid("d-1", "https://example.com", sig))
+	assert.False(t, Valid("d-2", "https://example.com", sig))
+	assert.False(t, Valid("d-1", "https://evil.example.com", sig))
+	assert.False(t, Valid("d-1", "", sig))
+	assert.False(t, Valid("d-1", "https://example.com", ""))
+}
+
+// TestDeliveryID verifies the context round trip.
+func TestDeliveryID(t *testing.T) {
+	assert.Empty(t, DeliveryID(context.Background()))
+	assert.Equal(t, "d-1", DeliveryID(WithDelivery(context.Background(), "d-1")))
+}
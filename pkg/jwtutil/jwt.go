@@ -1,6 +1,9 @@
 package jwtutil
 
 import (
+	"errors"
+	"time"
+
 	"weatherApi/config"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -19,25 +22,73 @@ func Generate(email string) (string, error) {
 	return token.SignedString([]byte(config.C.JWTSecret))
 }
 
+// GenerateScoped creates a JWT token with an email claim that is only accepted by
+// ParseScoped with the same scope and expires after ttl. Used for magic links that
+// grant more than a subscription token, e.g. access to a user's data.
+func GenerateScoped(email, scope string, ttl time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"email": email,
+		"scope": scope,
+		"exp":   jwt.NewNumericDate(time.Now().Add(ttl)),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	return token.SignedString([]byte(config.C.JWTSecret))
+}
+
 // Parse validates the JWT token signature and extracts the email claim.
-// It returns an error if the token is invalid, malformed, or missing the email.
+// It returns an error if the token is invalid, malformed, missing the email,
+// or scoped (see GenerateScoped).
 func Parse(tokenStr string) (string, error) {
+	claims, err := parseClaims(tokenStr)
+	if err != nil {
+		return "", err
+	}
+	if _, scoped := claims["scope"]; scoped {
+		return "", ErrWrongScope
+	}
+	return emailClaim(claims)
+}
+
+// ErrWrongScope is returned when a token was issued for a different purpose.
+var ErrWrongScope = errors.New("token scope mismatch")
+
+// ParseScoped validates a token created by GenerateScoped for scope, including its
+// expiry, and extracts the email claim.
+func ParseScoped(tokenStr, scope string) (string, error) {
+	claims, err := parseClaims(tokenStr)
+	if err != nil {
+		return "", err
+	}
+	if s, _ := claims["scope"].(string); s != scope {
+		return "", ErrWrongScope
+	}
+	if _, ok := claims["exp"]; !ok {
+		return "", jwt.ErrTokenRequiredClaimMissing
+	}
+	return emailClaim(claims)
+}
+
+func parseClaims(tokenStr string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
 		return []byte(config.C.JWTSecret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil || !token.Valid {
-		return "", err
+		return nil, err
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", jwt.ErrTokenMalformed
+		return nil, jwt.ErrTokenMalformed
 	}
+	return claims, nil
+}
 
+func emailClaim(claims jwt.MapClaims) (string, error) {
 	email, ok := claims["email"].(string)
 	if !ok {
 		return "", jwt.ErrTokenMalformed
 	}
-
 	return email, nil
 }
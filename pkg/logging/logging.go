@@ -0,0 +1,121 @@
+// Package logging configures structured JSON logging via log/slog and carries
+// correlation IDs (request, scheduler run, subscription) in the context.
+// Trace and span IDs of the active OpenTelemetry span are added as well.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"weatherApi/pkg/emailhash"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	runIDKey
+	subscriptionIDKey
+)
+
+// Setup installs a JSON logger writing to stdout as the slog and log default.
+// level is one of debug, info, warn or error; anything else means info.
+func Setup(level string) {
+	slog.SetDefault(New(os.Stdout, level))
+}
+
+// New returns a JSON logger that adds the correlation IDs found in the context
+// of each record.
+func New(w io.Writer, level string) *slog.Logger {
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: ParseLevel(level)})
+	return slog.New(contextHandler{h})
+}
+
+// ParseLevel maps a LOG_LEVEL value to a slog level, defaulting to info.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithRequestID returns a context carrying the HTTP request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request ID stored in ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithRunID returns a context carrying the scheduler run ID.
+func WithRunID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, runIDKey, id)
+}
+
+// WithSubscriptionID returns a context carrying the subscription being processed.
+func WithSubscriptionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, subscriptionIDKey, id)
+}
+
+// Email returns a log attribute identifying an address without exposing it.
+func Email(address string) slog.Attr {
+	return slog.String("email_hash", HashEmail(address))
+}
+
+// HashEmail returns a short keyed hash of the normalized address, so log queries
+// can follow one subscriber without storing the address itself.
+func HashEmail(address string) string {
+	return emailhash.Sum(address)[:16]
+}
+
+// contextFields lists the context values added to log records, in output order.
+var contextFields = []struct {
+	key  ctxKey
+	name string
+}{
+	{requestIDKey, "request_id"},
+	{runIDKey, "run_id"},
+	{subscriptionIDKey, "subscription_id"},
+}
+
+// contextHandler adds correlation IDs stored in the context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		for _, f := range contextFields {
+			if id, ok := ctx.Value(f.key).(string); ok && id != "" {
+				r.AddAttrs(slog.String(f.name, id))
+			}
+		}
+		// Link log lines to the active trace, if tracing is enabled
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNew_AddsContextIDs verifies that correlation IDs in the context end up
+// as JSON fields and that the level filter applies.
+func TestNew_AddsContextIDs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "info")
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = WithRunID(ctx, "run-1")
+	ctx = WithSubscriptionID(ctx, "sub-1")
+
+	logger.DebugContext(ctx, "hidden")
+	logger.InfoContext(ctx, "email sent", Email("User@Example.com"))
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry), "only the info record is written")
+	assert.Equal(t, "email sent", entry["msg"])
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Equal(t, "run-1", entry["run_id"])
+	assert.Equal(t, "sub-1", entry["subscription_id"])
+	assert.Equal(t, HashEmail("user@example.com"), entry["email_hash"])
+	assert.NotContains(t, buf.String(), "example.com")
+}
+
+// TestHashEmail verifies that hashes ignore case and surrounding spaces.
+func TestHashEmail(t *testing.T) {
+	assert.Equal(t, HashEmail("a@example.com"), HashEmail(" A@Example.COM "))
+	assert.NotEqual(t, HashEmail("a@example.com"), HashEmail("b@example.com"))
+	assert.Len(t, HashEmail("a@example.com"), 16)
+}
+
+// TestParseLevel verifies LOG_LEVEL parsing with the info fallback.
+func TestParseLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelDebug, ParseLevel("DEBUG"))
+	assert.Equal(t, slog.LevelWarn, ParseLevel("warn"))
+	assert.Equal(t, slog.LevelError, ParseLevel("error"))
+	assert.Equal(t, slog.LevelInfo, ParseLevel("verbose"))
+}
@@ -0,0 +1,179 @@
+// Package metrics defines the Prometheus collectors exposed on /metrics.
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "weatherapi"
+
+// Registry holds every collector of the application together with the Go runtime
+// and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts served requests per gin route template and status code.
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration observes request latency per gin route template and status code.
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// ProviderRequests counts weather provider calls per endpoint and outcome.
+	ProviderRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "provider_requests_total",
+		Help:      "Weather provider calls by endpoint and outcome.",
+	}, []string{"endpoint", "outcome"})
+
+	// ProviderDuration observes weather provider call latency per endpoint.
+	ProviderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "provider_request_duration_seconds",
+		Help:      "Weather provider call latency by endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	// EmailSends counts SendGrid send attempts per outcome.
+	EmailSends = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "email_sends_total",
+		Help:      "Email send attempts by outcome.",
+	}, []string{"outcome"})
+
+	// SchedulerTickDuration observes how long a scheduler job takes per kind.
+	SchedulerTickDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scheduler_tick_duration_seconds",
+		Help:      "Scheduler job duration by kind (hourly, daily, alerts).",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300, 600},
+	}, []string{"kind"})
+
+	// SchedulerProcessed counts subscriptions handled by scheduler jobs.
+	SchedulerProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduler_subscriptions_processed_total",
+		Help:      "Subscriptions processed by scheduler jobs by kind.",
+	}, []string{"kind"})
+
+	// SchedulerFailures counts subscriptions a scheduler job failed to deliver to.
+	SchedulerFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduler_subscription_failures_total",
+		Help:      "Subscriptions that failed during scheduler jobs by kind.",
+	}, []string{"kind"})
+
+	// MaintenanceActions counts subscriptions changed by the maintenance job per action.
+	MaintenanceActions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "maintenance_actions_total",
+		Help:      "Subscriptions handled by the maintenance job by action (reminded, expired, anonymized, deleted, downgraded).",
+	}, []string{"action"})
+
+	// EngagementEvents counts recorded opens and clicks of tracked emails.
+	EngagementEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "engagement_events_total",
+		Help:      "Opens and clicks of tracked emails by kind.",
+	}, []string{"kind"})
+)
+
+// Outcome labels shared by provider and email metrics.
+const (
+	OutcomeOK             = "ok"
+	OutcomeBadRequest     = "bad_request"
+	OutcomeNotFound       = "not_found"
+	OutcomeUpstreamError  = "upstream_error"
+	OutcomeTransportError = "transport_error"
+	OutcomeDecodeError    = "decode_error"
+	OutcomeConfigError    = "config_error"
+	OutcomeRejected       = "rejected"
+	OutcomeSuppressed     = "suppressed"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests, HTTPDuration,
+		ProviderRequests, ProviderDuration,
+		EmailSends,
+		SchedulerTickDuration, SchedulerProcessed, SchedulerFailures,
+		MaintenanceActions, EngagementEvents,
+		subscriptions,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveProvider records the outcome and latency of one provider call started at start.
+func ObserveProvider(endpoint, outcome string, start time.Time) {
+	ProviderRequests.WithLabelValues(endpoint, outcome).Inc()
+	ProviderDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+}
+
+// SubscriptionCounter returns the number of subscriptions per status.
+type SubscriptionCounter func() (map[string]int64, error)
+
+// SetSubscriptionCounter installs the function queried on every scrape for the
+// subscriptions gauge. Without one the gauge is not exported.
+func SetSubscriptionCounter(fn SubscriptionCounter) {
+	subscriptions.mu.Lock()
+	defer subscriptions.mu.Unlock()
+	subscriptions.count = fn
+}
+
+var subscriptions = &subscriptionCollector{
+	desc: prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "subscriptions"),
+		"Current number of subscriptions by status.",
+		[]string{"status"}, nil,
+	),
+}
+
+// subscriptionCollector reads subscription counts from the database at scrape time,
+// so the gauge never drifts from the table.
+type subscriptionCollector struct {
+	desc  *prometheus.Desc
+	mu    sync.Mutex
+	count SubscriptionCounter
+}
+
+func (s *subscriptionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.desc
+}
+
+func (s *subscriptionCollector) Collect(ch chan<- prometheus.Metric) {
+	s.mu.Lock()
+	count := s.count
+	s.mu.Unlock()
+	if count == nil {
+		return
+	}
+
+	counts, err := count()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(s.desc, err)
+		return
+	}
+	for status, n := range counts {
+		ch <- prometheus.MustNewConstMetric(s.desc, prometheus.GaugeValue, float64(n), status)
+	}
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"weatherApi/internal/model"
+	"weatherApi/pkg/email"
+)
+
+// EmailChannel delivers notifications as emails via SendGrid.
+// The send functions are fields so they can be replaced in tests.
+type EmailChannel struct {
+	SendWeather   func(ctx context.Context, toEmail string, weather *model.Weather, city string, token string) error
+	SendDigest    func(ctx context.Context, toEmail string, weather *model.Weather, forecast *model.Forecast, city, timezone, token string) error
+	SendCondition func(ctx context.Context, toEmail string, weather *model.Weather, matched []string, city string, token string) error
+	SendAlert     func(ctx context.Context, toEmail string, alert *model.Alert, city, timezone, token string) error
+
+	Suppressed email.SuppressionCheck // Consulted before every send; nil allows every address
+}
+
+// NewEmailChannel returns an EmailChannel backed by the pkg/email senders that skips
+// addresses reported by suppressed.
+func NewEmailChannel(suppressed email.SuppressionCheck) *EmailChannel {
+	return &EmailChannel{
+		SendWeather:   email.SendWeatherEmail,
+		SendDigest:    email.SendDigestEmail,
+		SendCondition: email.SendConditionEmail,
+		SendAlert:     email.SendAlertEmail,
+		Suppressed:    suppressed,
+	}
+}
+
+// Send picks the email template matching the notification kind.
+// Weather updates with a forecast are sent as the daily digest. Suppressed addresses
+// fail with email.ErrSuppressed.
+func (c *EmailChannel) Send(ctx context.Context, sub model.Subscription, n Notification) error {
+	if err := email.CheckSuppression(ctx, c.Suppressed, sub.Email); err != nil {
+		return err
+	}
+	switch n.Kind {
+	case KindWeather:
+		if n.Forecast != nil {
+			return c.SendDigest(ctx, sub.Email, n.Weather, n.Forecast, sub.City, sub.Timezone(), sub.Token)
+		}
+		return c.SendWeather(ctx, sub.Email, n.Weather, sub.City, sub.Token)
+	case KindCondition:
+		return c.SendCondition(ctx, sub.Email, n.Weather, n.Matched, sub.City, sub.Token)
+	case KindAlert:
+		return c.SendAlert(ctx, sub.Email, n.Alert, sub.City, sub.Timezone(), sub.Token)
+	default:
+		return fmt.Errorf("unsupported notification kind %q", n.Kind)
+	}
+}
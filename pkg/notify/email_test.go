@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"weatherApi/internal/model"
+	"weatherApi/pkg/email"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEmailChannel_Suppressed verifies that suppressed addresses are not emailed and
+// that a failing suppression check lets the email through.
+func TestEmailChannel_Suppressed(t *testing.T) {
+	sent := 0
+	ch := &EmailChannel{
+		SendWeather: func(context.Context, string, *model.Weather, string, string) error {
+			sent++
+			return nil
+		},
+	}
+	n := Notification{Kind: KindWeather, Weather: &model.Weather{Temperature: 10}}
+	ctx := context.Background()
+
+	ch.Suppressed = func(_ context.Context, address string) (bool, error) {
+		return address == "bounced@example.com", nil
+	}
+	err := ch.Send(ctx, model.Subscription{Email: "bounced@example.com"}, n)
+	assert.ErrorIs(t, err, email.ErrSuppressed)
+	require.NoError(t, ch.Send(ctx, model.Subscription{Email: "ok@example.com"}, n))
+	assert.Equal(t, 1, sent)
+
+	ch.Suppressed = func(context.Context, string) (bool, error) {
+		return false, errors.New("database down")
+	}
+	require.NoError(t, ch.Send(ctx, model.Subscription{Email: "bounced@example.com"}, n))
+	assert.Equal(t, 2, sent, "a failing check fails open")
+}
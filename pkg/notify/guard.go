@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when a webhook endpoint resolves to a loopback,
+// private, link-local or otherwise internal address.
+var ErrForbiddenAddress = errors.New("webhook endpoint is not a public address")
+
+// internalPrefixes are ranges not covered by the netip.Addr predicates that must not be
+// reachable from webhooks: "this network", carrier-grade NAT, IETF protocol assignments
+// and benchmarking.
+var internalPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// publicAddr reports whether ip is a public unicast address. Loopback, private,
+// link-local (including the cloud metadata endpoint 169.254.169.254), multicast and
+// unspecified addresses are not.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range internalPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateWebhookURL checks that raw is an https URL whose host is not obviously internal.
+// Host names are resolved only when connecting, where the webhook client checks every
+// address it dials, so DNS rebinding cannot get around it.
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return errors.New("webhook URL must be an https URL")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenAddress
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !publicAddr(ip) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// newWebhookClient returns an HTTP client that only connects to addresses allowed by
+// allow, checked after DNS resolution for every connection. Redirects are not followed,
+// so a 3xx answer is a failed delivery rather than a hop to an unchecked URL.
+func newWebhookClient(allow func(netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil || !allow(addr.Addr()) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			// No proxy: it would make the dial check apply to the proxy instead of the endpoint
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
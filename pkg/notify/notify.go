@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"weatherApi/internal/model"
+	"weatherApi/pkg/email"
+)
+
+// Notification kinds sent to subscribers.
+const (
+	KindWeather   = "weather.update"    // Periodic weather snapshot
+	KindCondition = "weather.condition" // Update triggered by the subscriber's conditions
+	KindAlert     = "weather.alert"     // Severe weather warning
+)
+
+// Delivery channels a subscription can choose.
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelBoth    = "both"
+)
+
+// Notification is a single message for a subscriber, independent of how it is delivered.
+// Only the fields relevant to Kind are set.
+type Notification struct {
+	Kind     string          // One of the Kind* constants
+	Weather  *model.Weather  // Current weather (weather and condition kinds)
+	Forecast *model.Forecast // Multi-day forecast; turns a weather update into the daily digest
+	Matched  []string        // Human-readable matched conditions (condition kind)
+	Alert    *model.Alert    // Warning details (alert kind)
+}
+
+// Sender delivers a notification to a subscription. Channels (email, webhook) send
+// over one transport; a Dispatcher sends over the subscription's chosen channels.
+type Sender interface {
+	Send(ctx context.Context, sub model.Subscription, n Notification) error
+}
+
+// Dispatcher routes notifications to the channels selected by each subscription.
+type Dispatcher struct {
+	Email   Sender
+	Webhook Sender
+}
+
+// NewDispatcher returns a Dispatcher using the SendGrid email channel, which skips
+// addresses reported by suppressed, and the default webhook channel.
+func NewDispatcher(suppressed email.SuppressionCheck) *Dispatcher {
+	return &Dispatcher{
+		Email:   NewEmailChannel(suppressed),
+		Webhook: NewWebhookChannel(),
+	}
+}
+
+// Send delivers the notification over every channel the subscription selected.
+// Delivery counts as successful if at least one channel succeeded, so a failing
+// webhook does not cause an already delivered email to be re-sent on retry.
+// Failures of individual channels are logged.
+func (d *Dispatcher) Send(ctx context.Context, sub model.Subscription, n Notification) error {
+	channels := d.channelsFor(sub)
+
+	var errs []error
+	for name, ch := range channels {
+		if err := ch.Send(ctx, sub, n); err != nil {
+			slog.WarnContext(ctx, "channel delivery failed", "channel", name, "kind", n.Kind, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if len(errs) == len(channels) {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// channelsFor returns the channels selected by the subscription, keyed by name.
+// An empty selection defaults to email.
+func (d *Dispatcher) channelsFor(sub model.Subscription) map[string]Sender {
+	switch sub.Channel {
+	case ChannelWebhook:
+		return map[string]Sender{ChannelWebhook: d.Webhook}
+	case ChannelBoth:
+		return map[string]Sender{ChannelEmail: d.Email, ChannelWebhook: d.Webhook}
+	default:
+		return map[string]Sender{ChannelEmail: d.Email}
+	}
+}
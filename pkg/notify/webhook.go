@@ -0,0 +1,159 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/pkg/tracing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Headers set on every webhook request.
+const (
+	HeaderEvent     = "X-Webhook-Event"     // Notification kind, e.g. "weather.update"
+	HeaderTimestamp = "X-Webhook-Timestamp" // Unix seconds when the request was signed
+	HeaderSignature = "X-Webhook-Signature" // "sha256=" + hex HMAC of "<timestamp>.<body>"
+)
+
+// WebhookPayload is the JSON body POSTed to subscriber endpoints.
+type WebhookPayload struct {
+	Event          string          `json:"event"`
+	SubscriptionID string          `json:"subscription_id"`
+	City           string          `json:"city"`
+	SentAt         time.Time       `json:"sent_at"`
+	Weather        *model.Weather  `json:"weather,omitempty"`
+	Forecast       *model.Forecast `json:"forecast,omitempty"` // Daily updates only
+	Matched        []string        `json:"matched,omitempty"`
+	Alert          *model.Alert    `json:"alert,omitempty"`
+}
+
+// WebhookChannel delivers notifications as signed JSON POST requests
+// to the subscription's webhook URL, using its per-endpoint secret.
+//
+// Network errors, 429 and 5xx responses are retried with exponential backoff;
+// other 4xx responses, redirects and non-public endpoints are permanent failures.
+type WebhookChannel struct {
+	Client      *http.Client
+	MaxAttempts int           // Total attempts including the first one
+	Backoff     time.Duration // Delay before the first retry; doubled for each next retry
+}
+
+// NewWebhookChannel returns a WebhookChannel with production defaults.
+// Its client refuses to connect to internal addresses and does not follow redirects.
+func NewWebhookChannel() *WebhookChannel {
+	return &WebhookChannel{
+		Client:      newWebhookClient(publicAddr),
+		MaxAttempts: 3,
+		Backoff:     time.Second,
+	}
+}
+
+// Send POSTs the signed notification payload to the subscription's webhook URL.
+func (c *WebhookChannel) Send(ctx context.Context, sub model.Subscription, n Notification) (err error) {
+	ctx, span := tracing.Start(ctx, "webhook.deliver", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { tracing.End(span, err) }()
+
+	if sub.WebhookURL == "" {
+		return fmt.Errorf("subscription has no webhook URL")
+	}
+
+	body, err := json.Marshal(WebhookPayload{
+		Event:          n.Kind,
+		SubscriptionID: sub.ID,
+		City:           sub.City,
+		SentAt:         time.Now().UTC(),
+		Weather:        n.Weather,
+		Forecast:       n.Forecast,
+		Matched:        n.Matched,
+		Alert:          n.Alert,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	delay := c.Backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := c.post(ctx, sub, n.Kind, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= c.MaxAttempts {
+			return fmt.Errorf("webhook delivery failed after %d attempt(s): %w", attempt, err)
+		}
+
+		slog.WarnContext(ctx, "webhook attempt failed, retrying", "attempt", attempt, "delay", delay.String(), "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("webhook delivery canceled after %d attempt(s): %w", attempt, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// post performs a single signed delivery attempt.
+// Returns whether a failure is worth retrying.
+func (c *WebhookChannel) post(ctx context.Context, sub model.Subscription, kind string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("invalid webhook request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, kind)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(sub.WebhookSecret, timestamp, body))
+
+	resp, err := c.Client.Do(req)
+	if errors.Is(err, ErrForbiddenAddress) {
+		return false, ErrForbiddenAddress
+	}
+	if err != nil {
+		return true, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if cerr := resp.Body.Close(); cerr != nil {
+			slog.WarnContext(ctx, "failed to close response body", "error", cerr)
+		}
+	}()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("endpoint responded with %s", resp.Status)
+	default:
+		return false, fmt.Errorf("endpoint responded with %s", resp.Status)
+	}
+}
+
+// Sign computes the webhook signature for a timestamp and body:
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+// Receivers recompute it with their secret and compare in constant time.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is valid for the given secret, timestamp and body.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
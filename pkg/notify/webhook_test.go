@@ -0,0 +1,185 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"weatherApi/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestWebhookChannel returns a WebhookChannel with no backoff delay.
+func newTestWebhookChannel() *WebhookChannel {
+	return &WebhookChannel{Client: http.DefaultClient, MaxAttempts: 3, Backoff: time.Millisecond}
+}
+
+// TestWebhookChannel_SignedPayload verifies that the receiver gets a JSON payload
+// whose HMAC signature validates with the subscription's secret.
+func TestWebhookChannel_SignedPayload(t *testing.T) {
+	var payload WebhookPayload
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, KindWeather, r.Header.Get(HeaderEvent))
+		assert.True(t, Verify("s3cret", r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)))
+		assert.False(t, Verify("wrong", r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)))
+
+		require.NoError(t, json.Unmarshal(body, &payload))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	sub := model.Subscription{ID: "sub-1", City: "Kyiv", WebhookURL: receiver.URL, WebhookSecret: "s3cret"}
+	weather := &model.Weather{Temperature: 21.5, Humidity: 60, Description: "Sunny"}
+
+	err := newTestWebhookChannel().Send(context.Background(), sub, Notification{Kind: KindWeather, Weather: weather})
+	require.NoError(t, err)
+
+	assert.Equal(t, KindWeather, payload.Event)
+	assert.Equal(t, "sub-1", payload.SubscriptionID)
+	assert.Equal(t, "Kyiv", payload.City)
+	assert.Equal(t, weather, payload.Weather)
+}
+
+// TestWebhookChannel_RetriesServerErrors verifies that 5xx responses are retried
+// until the endpoint accepts the delivery.
+func TestWebhookChannel_RetriesServerErrors(t *testing.T) {
+	var calls int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	sub := model.Subscription{ID: "sub-2", WebhookURL: receiver.URL, WebhookSecret: "s3cret"}
+	err := newTestWebhookChannel().Send(context.Background(), sub, Notification{Kind: KindAlert, Alert: &model.Alert{ID: "storm"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+// TestWebhookChannel_NoRetryOnClientError verifies that 4xx responses fail immediately.
+func TestWebhookChannel_NoRetryOnClientError(t *testing.T) {
+	var calls int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer receiver.Close()
+
+	sub := model.Subscription{ID: "sub-3", WebhookURL: receiver.URL, WebhookSecret: "s3cret"}
+	err := newTestWebhookChannel().Send(context.Background(), sub, Notification{Kind: KindWeather, Weather: &model.Weather{}})
+
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestWebhookChannel_RefusesInternalAddresses verifies that the production client does
+// not connect to loopback endpoints and that the failure is not retried.
+func TestWebhookChannel_RefusesInternalAddresses(t *testing.T) {
+	var calls int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer receiver.Close()
+
+	c := NewWebhookChannel()
+	c.Backoff = time.Millisecond
+	sub := model.Subscription{ID: "sub-4", WebhookURL: receiver.URL, WebhookSecret: "s3cret"}
+	err := c.Send(context.Background(), sub, Notification{Kind: KindWeather, Weather: &model.Weather{}})
+
+	require.ErrorIs(t, err, ErrForbiddenAddress)
+	assert.Contains(t, err.Error(), "after 1 attempt")
+	assert.Zero(t, atomic.LoadInt32(&calls))
+}
+
+// TestWebhookChannel_NoRedirects verifies that redirects are not followed and fail the delivery.
+func TestWebhookChannel_NoRedirects(t *testing.T) {
+	var followed int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&followed, 1)
+	}))
+	defer target.Close()
+	receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer receiver.Close()
+
+	c := newTestWebhookChannel()
+	c.Client = newWebhookClient(func(netip.Addr) bool { return true })
+	sub := model.Subscription{ID: "sub-5", WebhookURL: receiver.URL, WebhookSecret: "s3cret"}
+	err := c.Send(context.Background(), sub, Notification{Kind: KindWeather, Weather: &model.Weather{}})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "307")
+	assert.Zero(t, atomic.LoadInt32(&followed))
+}
+
+// TestValidateWebhookURL verifies that only https URLs without internal address literals are accepted.
+func TestValidateWebhookURL(t *testing.T) {
+	for raw, ok := range map[string]bool{
+		"https://hooks.example.com/weather":  true,
+		"https://93.184.216.34/hook":         true,
+		"http://hooks.example.com/weather":   false,
+		"https://user:pw@hooks.example.com/": false,
+		"https://localhost/hook":             false,
+		"https://api.localhost./hook":        false,
+		"https://127.0.0.1/hook":             false,
+		"https://169.254.169.254/latest":     false,
+		"https://192.168.1.10/hook":          false,
+		"https://100.64.0.1/hook":            false,
+		"https://[fd00:ec2::254]/hook":       false,
+		"https://[::ffff:10.0.0.1]/hook":     false,
+		"ftp://hooks.example.com/":           false,
+	} {
+		err := ValidateWebhookURL(raw)
+		assert.Equal(t, ok, err == nil, raw)
+	}
+}
+
+// channelFunc adapts a function to the Sender interface.
+type channelFunc func(sub model.Subscription, n Notification) error
+
+func (f channelFunc) Send(_ context.Context, sub model.Subscription, n Notification) error {
+	return f(sub, n)
+}
+
+// TestDispatcher_RoutesByChannel verifies that subscriptions receive notifications
+// only over the channels they selected, defaulting to email.
+func TestDispatcher_RoutesByChannel(t *testing.T) {
+	var used []string
+	d := &Dispatcher{
+		Email: channelFunc(func(sub model.Subscription, n Notification) error {
+			used = append(used, ChannelEmail)
+			return nil
+		}),
+		Webhook: channelFunc(func(sub model.Subscription, n Notification) error {
+			used = append(used, ChannelWebhook)
+			return assert.AnError
+		}),
+	}
+
+	require.NoError(t, d.Send(context.Background(), model.Subscription{}, Notification{Kind: KindWeather}))
+	assert.Equal(t, []string{ChannelEmail}, used)
+
+	used = nil
+	require.Error(t, d.Send(context.Background(), model.Subscription{Channel: ChannelWebhook}, Notification{Kind: KindWeather}))
+	assert.Equal(t, []string{ChannelWebhook}, used)
+
+	used = nil
+	require.NoError(t, d.Send(context.Background(), model.Subscription{Channel: ChannelBoth}, Notification{Kind: KindWeather}),
+		"a failing webhook must not fail a delivered email")
+	assert.ElementsMatch(t, []string{ChannelEmail, ChannelWebhook}, used)
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"time"
+
+	"weatherApi/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStore keeps buckets in the rate_limit_buckets table,
+// so limits are shared by every replica using the same database.
+type GormStore struct {
+	DB *gorm.DB
+}
+
+// NewGormStore returns a store backed by the given database.
+// The model.RateLimitBucket table must be migrated.
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{DB: db}
+}
+
+// Take implements Store. The bucket row is locked for the duration of the
+// transaction (SELECT ... FOR UPDATE on Postgres), so concurrent requests
+// cannot both spend the last token.
+func (s *GormStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	var allowed bool
+	var wait time.Duration
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		// Make sure the row exists so it can be locked
+		fresh := model.RateLimitBucket{Key: key, Tokens: float64(limit.Burst), UpdatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&fresh).Error; err != nil {
+			return err
+		}
+
+		var b model.RateLimitBucket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&b).Error; err != nil {
+			return err
+		}
+
+		var tokens float64
+		tokens, allowed, wait = take(b.Tokens, b.UpdatedAt, now, limit)
+
+		return tx.Model(&model.RateLimitBucket{}).Where("key = ?", key).Updates(map[string]interface{}{
+			"tokens":     tokens,
+			"updated_at": now,
+		}).Error
+	})
+	if err != nil {
+		return false, 0, err
+	}
+
+	return allowed, wait, nil
+}
+
+// DeleteIdle deletes the buckets that have not been used since before and returns
+// how many were deleted. A bucket idle for longer than its limit's period is full
+// again, so deleting it does not change any limit.
+func (s *GormStore) DeleteIdle(before time.Time) (int64, error) {
+	res := s.DB.Where("updated_at < ?", before).Delete(&model.RateLimitBucket{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepEvery controls how often idle buckets are evicted from a MemoryStore.
+const sweepEvery = 1000
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	period time.Duration
+}
+
+// MemoryStore keeps buckets in process memory.
+// Limits are per replica; use GormStore to share them across replicas.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// Take implements Store.
+func (s *MemoryStore) Take(key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.takes++
+	if s.takes%sweepEvery == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	tokens, allowed, wait := take(b.tokens, b.last, now, limit)
+	b.tokens, b.last, b.period = tokens, now, limit.Period
+
+	return allowed, wait, nil
+}
+
+// sweep drops buckets that have been idle long enough to be full again.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.last) > b.period {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Limit describes a token bucket: up to Burst requests at once,
+// refilled at a rate of Burst tokens per Period.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// PerHour returns a Limit allowing n requests per hour.
+func PerHour(n int) Limit {
+	return Limit{Burst: n, Period: time.Hour}
+}
+
+// rate returns the refill rate in tokens per second.
+func (l Limit) rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+// Store consumes tokens from named buckets.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Take tries to consume one token from the bucket identified by key.
+	// When the bucket is empty it returns allowed=false and how long
+	// the caller has to wait until the next token is available.
+	Take(key string, limit Limit, now time.Time) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// take applies the token bucket algorithm to a stored state.
+// Returns the new token count, whether the request is allowed and the retry delay.
+func take(tokens float64, last, now time.Time, limit Limit) (float64, bool, time.Duration) {
+	if limit.Burst <= 0 || limit.Period <= 0 {
+		return tokens, true, 0 // limiting disabled
+	}
+
+	elapsed := now.Sub(last).Seconds()
+	if elapsed > 0 {
+		tokens = math.Min(float64(limit.Burst), tokens+elapsed*limit.rate())
+	}
+
+	if tokens >= 1 {
+		return tokens - 1, true, 0
+	}
+
+	wait := time.Duration((1 - tokens) / limit.rate() * float64(time.Second))
+	return tokens, false, wait
+}
@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/pkg/logging"
+	"weatherApi/pkg/notify"
+	"weatherApi/pkg/tracing"
+
+	"gorm.io/gorm/clause"
+)
+
+// startAlertPoller checks "alerts" subscriptions for new warnings at the given interval.
+// Unlike periodic snapshots, alerts are delivered as soon as they are noticed.
+func (s *Scheduler) startAlertPoller(interval time.Duration) {
+	slog.Info("alert poller started", "interval", interval.String())
+
+	ticker := time.NewTicker(interval)
+	for {
+		s.sendAlertUpdates()
+		s.recordTick(LoopAlerts, time.Now())
+		<-ticker.C
+	}
+}
+
+// sendAlertUpdates fetches all active alert subscriptions and delivers any
+// warnings they have not received yet. The forecast is fetched once per location.
+func (s *Scheduler) sendAlertUpdates() {
+	ctx, span := tracing.Start(context.Background(), "scheduler.alerts")
+	defer span.End()
+
+	run := s.beginRun(ctx, "alerts")
+	ctx = logging.WithRunID(ctx, run.ID)
+	defer s.finishRun(ctx, run)
+
+	subs, err := s.Subs.ListActive(ctx, "alerts")
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to query alert subscriptions", "error", err)
+		run.Failed++
+		return
+	}
+
+	forecasts := make(map[string]*model.Forecast)
+
+	for _, sub := range subs {
+		subCtx := logging.WithSubscriptionID(ctx, sub.ID)
+		query := sub.WeatherQuery()
+
+		forecast, ok := forecasts[query]
+		if !ok {
+			var err error
+			forecast, _, err = s.FetchForecast(ctx, query, 1)
+			if err != nil {
+				slog.ErrorContext(subCtx, "failed to fetch alerts", "query", query, "error", err)
+				run.Failed++
+				continue
+			}
+			forecasts[query] = forecast
+		}
+
+		sent, err := s.deliverAlerts(subCtx, sub, forecast.Alerts, time.Now())
+		switch {
+		case err != nil:
+			slog.ErrorContext(subCtx, "failed to send alert", "error", err)
+			run.Failed++
+		case sent > 0:
+			slog.InfoContext(subCtx, "alerts sent", "count", sent)
+			run.Processed++
+		default:
+			run.Skipped++
+		}
+	}
+}
+
+// deliverAlerts sends every active alert the subscription has not received yet.
+// Each alert is claimed in sent_alerts before sending, so concurrent pollers
+// (e.g. several replicas) never send the same warning twice. The claim is
+// released if sending fails, so the alert is retried on the next poll.
+// Returns the number of alerts sent.
+func (s *Scheduler) deliverAlerts(ctx context.Context, sub model.Subscription, alerts []model.Alert, now time.Time) (int, error) {
+	sent := 0
+
+	for _, alert := range alerts {
+		if !alert.Expires.IsZero() && alert.Expires.Before(now) {
+			continue
+		}
+
+		claim := model.SentAlert{SubscriptionID: sub.ID, AlertID: alert.ID, SentAt: now}
+		res := s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&claim)
+		if res.Error != nil {
+			return sent, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue // already sent
+		}
+
+		if err := s.deliver(ctx, sub, notify.Notification{Kind: notify.KindAlert, Alert: &alert}); err != nil {
+			if derr := s.DB.WithContext(ctx).Delete(&claim).Error; derr != nil {
+				slog.ErrorContext(ctx, "failed to release alert claim", "alert_id", alert.ID, "error", derr)
+			}
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
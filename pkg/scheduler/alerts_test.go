@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"weatherApi/internal/db"
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// senderFunc adapts a function to the notify.Sender interface.
+type senderFunc func(sub model.Subscription, n notify.Notification) error
+
+func (f senderFunc) Send(_ context.Context, sub model.Subscription, n notify.Notification) error {
+	return f(sub, n)
+}
+
+// newTestScheduler creates a scheduler on its own migrated in-memory SQLite database,
+// so tests don't share state and can run in parallel.
+func newTestScheduler(t *testing.T) *Scheduler {
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	_, err = db.Migrate(gdb)
+	require.NoError(t, err)
+	return New(repository.NewGormSubscriptionRepository(gdb), gdb)
+}
+
+// TestDeliverAlerts_Deduplicates verifies that each alert is sent once per subscription,
+// that expired alerts are skipped, and that a new alert is still delivered later.
+func TestDeliverAlerts_Deduplicates(t *testing.T) {
+	t.Parallel()
+	s := newTestScheduler(t)
+
+	var sent []string
+	s.Notifier = senderFunc(func(sub model.Subscription, n notify.Notification) error {
+		sent = append(sent, n.Alert.ID)
+		return nil
+	})
+
+	now := time.Now()
+	sub := model.Subscription{ID: "sub-1", Email: "alerts@example.com", City: "Kyiv", Frequency: "alerts"}
+	storm := model.Alert{ID: "storm", Event: "Storm Warning", Expires: now.Add(time.Hour)}
+	expired := model.Alert{ID: "old-frost", Event: "Frost Warning", Expires: now.Add(-time.Hour)}
+
+	n, err := s.deliverAlerts(context.Background(), sub, []model.Alert{storm, expired}, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = s.deliverAlerts(context.Background(), sub, []model.Alert{storm}, now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n, "the same alert must not be sent twice")
+
+	heat := model.Alert{ID: "heat", Event: "Heat Advisory"}
+	n, err = s.deliverAlerts(context.Background(), sub, []model.Alert{storm, heat}, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.Equal(t, []string{"storm", "heat"}, sent)
+}
+
+// TestDeliverAlerts_RetriesAfterSendFailure verifies that a failed send releases
+// the deduplication claim so the alert is retried on the next poll.
+func TestDeliverAlerts_RetriesAfterSendFailure(t *testing.T) {
+	t.Parallel()
+	s := newTestScheduler(t)
+
+	sub := model.Subscription{ID: "sub-2", Email: "retry@example.com", City: "Kyiv", Frequency: "alerts"}
+	storm := model.Alert{ID: "storm"}
+
+	s.Notifier = senderFunc(func(sub model.Subscription, n notify.Notification) error {
+		return errors.New("sendgrid down")
+	})
+	_, err := s.deliverAlerts(context.Background(), sub, []model.Alert{storm}, time.Now())
+	require.Error(t, err)
+
+	s.Notifier = senderFunc(func(sub model.Subscription, n notify.Notification) error {
+		return nil
+	})
+	n, err := s.deliverAlerts(context.Background(), sub, []model.Alert{storm}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
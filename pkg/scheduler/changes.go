@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Reasons for sending an update under a ChangePolicy, used as log fields.
+const (
+	changeFirst         = "first"
+	changeTemperature   = "temperature"
+	changeCondition     = "condition"
+	changePrecipitation = "precipitation"
+	changeHeartbeat     = "heartbeat"
+	changeAlways        = "always"
+)
+
+// ErrUnchanged is returned when an hourly update is skipped because the weather has not
+// changed significantly since the last email.
+var ErrUnchanged = errors.New("weather unchanged since last update")
+
+// ChangePolicy decides whether an hourly update is worth sending compared to the weather
+// last sent to the subscription. The zero value sends every update.
+type ChangePolicy struct {
+	MinTempDelta         float64       // Send when the temperature moved at least this many °C (0 ignores temperature)
+	OnConditionChange    bool          // Send when the weather description changed
+	OnPrecipitationStart bool          // Send when precipitation started
+	Heartbeat            time.Duration // Send anyway when the last email is older than this (0 disables)
+}
+
+// changePolicyFromConfig builds the hourly policy from config.C. With change-only
+// updates disabled every hourly update is sent.
+func changePolicyFromConfig() ChangePolicy {
+	if !config.C.HourlyChangeOnly {
+		return ChangePolicy{}
+	}
+	return ChangePolicy{
+		MinTempDelta:         config.C.HourlyTempDelta,
+		OnConditionChange:    config.C.HourlyOnConditionChange,
+		OnPrecipitationStart: config.C.HourlyOnPrecipitation,
+		Heartbeat:            config.C.HourlyHeartbeat,
+	}
+}
+
+// Active reports whether the policy filters updates at all.
+func (p ChangePolicy) Active() bool {
+	return p != ChangePolicy{}
+}
+
+// Significant reports whether w differs enough from the last sent snapshot to be sent,
+// and why. Without a snapshot the update is always sent.
+func (p ChangePolicy) Significant(last *model.WeatherSnapshot, w *model.Weather, now time.Time) (bool, string) {
+	switch {
+	case !p.Active():
+		return true, changeAlways
+	case last == nil:
+		return true, changeFirst
+	case p.MinTempDelta > 0 && math.Abs(w.Temperature-last.Temperature) >= p.MinTempDelta:
+		return true, changeTemperature
+	case p.OnConditionChange && !strings.EqualFold(strings.TrimSpace(w.Description), strings.TrimSpace(last.Description)):
+		return true, changeCondition
+	case p.OnPrecipitationStart && last.Precipitation == 0 && w.Precipitation > 0:
+		return true, changePrecipitation
+	case p.Heartbeat > 0 && now.Sub(last.SentAt) >= p.Heartbeat:
+		return true, changeHeartbeat
+	}
+	return false, ""
+}
+
+// lastSnapshot returns the weather last sent to the subscription, or nil if none was recorded.
+func (s *Scheduler) lastSnapshot(ctx context.Context, subscriptionID string) (*model.WeatherSnapshot, error) {
+	var snap model.WeatherSnapshot
+	err := s.DB.WithContext(ctx).First(&snap, "subscription_id = ?", subscriptionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// saveSnapshot records w as the weather last sent to the subscription.
+func (s *Scheduler) saveSnapshot(ctx context.Context, subscriptionID string, w *model.Weather, at time.Time) error {
+	snap := model.WeatherSnapshot{
+		SubscriptionID: subscriptionID,
+		Temperature:    w.Temperature,
+		Description:    w.Description,
+		Precipitation:  w.Precipitation,
+		SentAt:         at,
+	}
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&snap).Error
+}
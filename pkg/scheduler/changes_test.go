@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChangePolicy_Significant verifies each trigger of the change policy and that the
+// zero policy sends every update.
+func TestChangePolicy_Significant(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)
+	last := &model.WeatherSnapshot{Temperature: 10, Description: "Cloudy", SentAt: now.Add(-2 * time.Hour)}
+	policy := ChangePolicy{MinTempDelta: 2, OnConditionChange: true, OnPrecipitationStart: true, Heartbeat: 6 * time.Hour}
+
+	tests := []struct {
+		name    string
+		policy  ChangePolicy
+		last    *model.WeatherSnapshot
+		weather model.Weather
+		now     time.Time
+		send    bool
+		reason  string
+	}{
+		{"zero policy", ChangePolicy{}, last, model.Weather{Temperature: 10, Description: "Cloudy"}, now, true, changeAlways},
+		{"no snapshot", policy, nil, model.Weather{Temperature: 10, Description: "Cloudy"}, now, true, changeFirst},
+		{"unchanged", policy, last, model.Weather{Temperature: 11.5, Description: " cloudy"}, now, false, ""},
+		{"temperature drop", policy, last, model.Weather{Temperature: 8, Description: "Cloudy"}, now, true, changeTemperature},
+		{"temperature ignored", ChangePolicy{OnConditionChange: true}, last, model.Weather{Temperature: 20, Description: "Cloudy"}, now, false, ""},
+		{"condition", policy, last, model.Weather{Temperature: 10, Description: "Light rain"}, now, true, changeCondition},
+		{"precipitation starts", policy, last, model.Weather{Temperature: 10, Description: "Cloudy", Precipitation: 0.2}, now, true, changePrecipitation},
+		{"heartbeat", policy, last, model.Weather{Temperature: 10, Description: "Cloudy"}, now.Add(4 * time.Hour), true, changeHeartbeat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			send, reason := tt.policy.Significant(tt.last, &tt.weather, tt.now)
+			assert.Equal(t, tt.send, send)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+
+	rainy := *last
+	rainy.Precipitation = 1
+	send, _ := policy.Significant(&rainy, &model.Weather{Temperature: 10, Description: "Cloudy", Precipitation: 3}, now)
+	assert.False(t, send, "continuing precipitation is not a change")
+}
+
+// TestSendWeatherUpdates_ChangeOnly verifies that hourly updates are skipped while the
+// weather stays the same and sent again once it changes, while direct sends ignore the policy.
+func TestSendWeatherUpdates_ChangeOnly(t *testing.T) {
+	t.Parallel()
+	s := newTestScheduler(t)
+	ctx := context.Background()
+	require.NoError(t, s.Subs.Create(ctx, &model.Subscription{
+		ID: "hourly-1", Email: "a@example.com", City: "Kyiv", Frequency: "hourly", Token: "t", Status: model.StatusActive,
+	}, repository.StatusChange{Actor: model.ActorSystem, Reason: "test"}))
+
+	weather := model.Weather{Temperature: 12, Description: "Cloudy"}
+	s.FetchWeather = func(_ context.Context, query string) (*model.Weather, int, error) {
+		w := weather
+		return &w, 200, nil
+	}
+	sent := 0
+	s.Notifier = senderFunc(func(sub model.Subscription, n notify.Notification) error {
+		sent++
+		return nil
+	})
+
+	policy := ChangePolicy{MinTempDelta: 2, OnConditionChange: true}
+	s.sendWeatherUpdates("hourly", policy)
+	s.sendWeatherUpdates("hourly", policy)
+	assert.Equal(t, 1, sent, "the second update is unchanged")
+
+	sub, err := s.Subs.GetByID(ctx, "hourly-1")
+	require.NoError(t, err)
+	require.NoError(t, s.ProcessSubscription(ctx, *sub))
+	assert.Equal(t, 2, sent, "direct sends are not filtered")
+
+	weather.Temperature = 15
+	s.sendWeatherUpdates("hourly", policy)
+	assert.Equal(t, 3, sent)
+
+	var snap model.WeatherSnapshot
+	require.NoError(t, s.DB.First(&snap, "subscription_id = ?", "hourly-1").Error)
+	assert.InDelta(t, 15, snap.Temperature, 0.001)
+
+	var runs []model.SchedulerRun
+	require.NoError(t, s.DB.Where("kind = ?", "hourly").Order("started_at").Find(&runs).Error)
+	require.Len(t, runs, 3)
+	assert.Equal(t, 1, runs[1].Skipped)
+	assert.Equal(t, 1, runs[2].Processed)
+}
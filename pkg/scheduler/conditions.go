@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"time"
+
+	"weatherApi/internal/model"
+)
+
+// ConditionMatch describes a subscription condition that holds for a forecast hour.
+type ConditionMatch struct {
+	Condition model.Condition
+	Hour      model.ForecastHour
+}
+
+// conditionWindow returns how far ahead conditions are evaluated for a frequency:
+// hourly subscribers care about the coming hour, daily subscribers about the next day.
+func conditionWindow(frequency string) time.Duration {
+	if frequency == "hourly" {
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// evaluateConditions returns the first matching forecast hour for each condition
+// within [from, from+window). Conditions are OR-ed: any match triggers a send.
+func evaluateConditions(conditions []model.Condition, forecast *model.Forecast, from time.Time, window time.Duration) []ConditionMatch {
+	var matches []ConditionMatch
+
+	// Include the hour already in progress, e.g. 14:00 for a 14:20 run
+	start := from.Truncate(time.Hour)
+	end := from.Add(window)
+
+	for _, cond := range conditions {
+	days:
+		for _, day := range forecast.Days {
+			for _, hour := range day.Hours {
+				if hour.Time.Before(start) || !hour.Time.Before(end) {
+					continue
+				}
+				if cond.Matches(hour) {
+					matches = append(matches, ConditionMatch{Condition: cond, Hour: hour})
+					break days
+				}
+			}
+		}
+	}
+
+	return matches
+}
@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"weatherApi/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEvaluateConditions verifies that conditions are OR-ed, evaluated only
+// within the frequency window, and report the first matching hour.
+func TestEvaluateConditions(t *testing.T) {
+	now := time.Date(2026, 10, 19, 14, 20, 0, 0, time.UTC)
+	hour := func(offset int, temp float64, rain int) model.ForecastHour {
+		return model.ForecastHour{Time: now.Truncate(time.Hour).Add(time.Duration(offset) * time.Hour), TempC: temp, ChanceOfRain: rain}
+	}
+
+	forecast := &model.Forecast{Days: []model.ForecastDay{
+		{Hours: []model.ForecastHour{hour(-1, 15, 90), hour(0, 12, 10), hour(5, 10, 70), hour(6, 9, 80)}},
+		{Hours: []model.ForecastHour{hour(20, -2, 0), hour(30, -5, 0)}},
+	}}
+
+	rain, err := model.ParseCondition("precipitation>60")
+	require.NoError(t, err)
+	frost, err := model.ParseCondition("temperature<0")
+	require.NoError(t, err)
+	heat, err := model.ParseCondition("temperature>30")
+	require.NoError(t, err)
+
+	daily := evaluateConditions([]model.Condition{rain, frost, heat}, forecast, now, conditionWindow("daily"))
+	require.Len(t, daily, 2)
+	assert.Equal(t, rain, daily[0].Condition)
+	assert.Equal(t, 70, daily[0].Hour.ChanceOfRain, "first matching hour should be reported")
+	assert.Equal(t, frost, daily[1].Condition)
+
+	hourly := evaluateConditions([]model.Condition{rain, frost}, forecast, now, conditionWindow("hourly"))
+	assert.Empty(t, hourly, "past hours and hours outside the window must be ignored")
+}
+
+// TestDescribeMatches verifies that matched hours are shown in the location's timezone.
+func TestDescribeMatches(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	require.NoError(t, err)
+	rain, err := model.ParseCondition("precipitation>60")
+	require.NoError(t, err)
+	match := ConditionMatch{
+		Condition: rain,
+		Hour:      model.ForecastHour{Time: time.Date(2026, 6, 14, 14, 0, 0, 0, time.UTC), ChanceOfRain: 80},
+	}
+
+	assert.Equal(t, []string{"precipitation>60 — 14.06 17:00 EEST (80%)"}, describeMatches([]ConditionMatch{match}, kyiv))
+	assert.Equal(t, []string{"precipitation>60 — 14.06 14:00 UTC (80%)"}, describeMatches([]ConditionMatch{match}, time.UTC))
+}
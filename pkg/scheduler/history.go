@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/pkg/engagement"
+	"weatherApi/pkg/metrics"
+	"weatherApi/pkg/notify"
+	"weatherApi/pkg/tracing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// deliver sends the notification via s.Notifier and records it in the deliveries
+// table for the admin dashboard: as pending before sending, so opens of a tracked email
+// always find their delivery, and with the outcome afterwards. With TrackEngagement the
+// email is sent with open and click tracking for the recorded delivery; if it could not
+// be recorded the email is sent untracked.
+func (s *Scheduler) deliver(ctx context.Context, sub model.Subscription, n notify.Notification) error {
+	d := model.Delivery{
+		ID:             uuid.New().String(),
+		SubscriptionID: sub.ID,
+		Kind:           n.Kind,
+		Status:         model.DeliveryPending,
+		Tracked:        s.TrackEngagement && sub.Channel != notify.ChannelWebhook,
+		CreatedAt:      time.Now(),
+	}
+	if err := s.DB.WithContext(ctx).Create(&d).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to record delivery", "error", err)
+		d.Tracked = false
+	}
+	if d.Tracked {
+		ctx = engagement.WithDelivery(ctx, d.ID)
+	}
+
+	ctx, span := tracing.Start(ctx, "notify.Send", trace.WithAttributes(
+		attribute.String("notification.kind", n.Kind),
+		attribute.String("subscription.channel", sub.Channel),
+	))
+	err := s.Notifier.Send(ctx, sub, n)
+	tracing.End(span, err)
+
+	outcome := map[string]any{"status": model.DeliverySent, "error": ""}
+	if err != nil {
+		outcome = map[string]any{"status": model.DeliveryFailed, "error": err.Error()}
+	}
+	if derr := s.DB.WithContext(ctx).Model(&model.Delivery{}).Where("id = ?", d.ID).Updates(outcome).Error; derr != nil {
+		slog.ErrorContext(ctx, "failed to record delivery outcome", "error", derr)
+	}
+
+	return err
+}
+
+// beginRun records the start of a scheduler job.
+func (s *Scheduler) beginRun(ctx context.Context, kind string) *model.SchedulerRun {
+	run := &model.SchedulerRun{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		StartedAt: time.Now(),
+	}
+	if err := s.DB.WithContext(ctx).Create(run).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to record scheduler run", "kind", kind, "error", err)
+	}
+	return run
+}
+
+// finishRun stores the final counts of a scheduler job and exports them as metrics.
+func (s *Scheduler) finishRun(ctx context.Context, run *model.SchedulerRun) {
+	now := time.Now()
+	run.FinishedAt = &now
+
+	metrics.SchedulerTickDuration.WithLabelValues(run.Kind).Observe(now.Sub(run.StartedAt).Seconds())
+	metrics.SchedulerProcessed.WithLabelValues(run.Kind).Add(float64(run.Processed))
+	metrics.SchedulerFailures.WithLabelValues(run.Kind).Add(float64(run.Failed))
+
+	if err := s.DB.WithContext(ctx).Save(run).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to record scheduler run result", "kind", run.Kind, "error", err)
+	}
+	slog.InfoContext(ctx, "scheduler run finished", "kind", run.Kind,
+		"processed", run.Processed, "skipped", run.Skipped, "failed", run.Failed,
+		"duration_ms", now.Sub(run.StartedAt).Milliseconds())
+}
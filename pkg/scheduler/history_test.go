@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"weatherApi/internal/model"
+	"weatherApi/pkg/engagement"
+	"weatherApi/pkg/metrics"
+	"weatherApi/pkg/notify"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFinishRun_RecordsAndExports verifies that a finished run is stored for the
+// dashboard and its counts are added to the scheduler metrics.
+func TestFinishRun_RecordsAndExports(t *testing.T) {
+	s := newTestScheduler(t)
+
+	processed := testutil.ToFloat64(metrics.SchedulerProcessed.WithLabelValues("hourly"))
+	failed := testutil.ToFloat64(metrics.SchedulerFailures.WithLabelValues("hourly"))
+
+	run := s.beginRun(context.Background(), "hourly")
+	run.Processed = 3
+	run.Failed = 1
+	s.finishRun(context.Background(), run)
+
+	var stored model.SchedulerRun
+	require.NoError(t, s.DB.First(&stored, "id = ?", run.ID).Error)
+	assert.NotNil(t, stored.FinishedAt)
+	assert.Equal(t, 3, stored.Processed)
+
+	assert.Equal(t, processed+3, testutil.ToFloat64(metrics.SchedulerProcessed.WithLabelValues("hourly")))
+	assert.Equal(t, failed+1, testutil.ToFloat64(metrics.SchedulerFailures.WithLabelValues("hourly")))
+}
+
+// trackedSender records the tracked delivery carried by each send's context.
+type trackedSender struct{ deliveryIDs []string }
+
+func (s *trackedSender) Send(ctx context.Context, _ model.Subscription, _ notify.Notification) error {
+	s.deliveryIDs = append(s.deliveryIDs, engagement.DeliveryID(ctx))
+	return nil
+}
+
+// TestDeliver_TracksEngagement verifies that email deliveries are tracked only with
+// TrackEngagement, under the ID of the recorded delivery.
+func TestDeliver_TracksEngagement(t *testing.T) {
+	t.Parallel()
+	s := newTestScheduler(t)
+	sender := &trackedSender{}
+	s.Notifier = sender
+	ctx := context.Background()
+	n := notify.Notification{Kind: notify.KindWeather, Weather: &model.Weather{}}
+
+	require.NoError(t, s.deliver(ctx, model.Subscription{ID: "untracked"}, n))
+	s.TrackEngagement = true
+	require.NoError(t, s.deliver(ctx, model.Subscription{ID: "email", Channel: notify.ChannelBoth}, n))
+	require.NoError(t, s.deliver(ctx, model.Subscription{ID: "webhook", Channel: notify.ChannelWebhook}, n))
+
+	var tracked model.Delivery
+	require.NoError(t, s.DB.First(&tracked, "subscription_id = ?", "email").Error)
+	assert.True(t, tracked.Tracked)
+	assert.Equal(t, []string{"", tracked.ID, ""}, sender.deliveryIDs)
+
+	var count int64
+	require.NoError(t, s.DB.Model(&model.Delivery{}).Where("tracked = ?", true).Count(&count).Error)
+	assert.EqualValues(t, 1, count)
+}
+
+// statusSender captures the status of the delivery row while the send is in progress.
+type statusSender struct {
+	t      *testing.T
+	s      *Scheduler
+	err    error
+	during string
+}
+
+func (c *statusSender) Send(ctx context.Context, sub model.Subscription, _ notify.Notification) error {
+	var d model.Delivery
+	require.NoError(c.t, c.s.DB.First(&d, "subscription_id = ?", sub.ID).Error)
+	c.during = d.Status
+	return c.err
+}
+
+// TestDeliver_RecordsPendingFirst verifies that the delivery is stored as pending before
+// the send and updated with the outcome afterwards.
+func TestDeliver_RecordsPendingFirst(t *testing.T) {
+	t.Parallel()
+	s := newTestScheduler(t)
+	sender := &statusSender{t: t, s: s}
+	s.Notifier = sender
+	ctx := context.Background()
+	n := notify.Notification{Kind: notify.KindWeather, Weather: &model.Weather{}}
+
+	require.NoError(t, s.deliver(ctx, model.Subscription{ID: "ok"}, n))
+	assert.Equal(t, model.DeliveryPending, sender.during)
+
+	sender.err = errors.New("smtp down")
+	require.Error(t, s.deliver(ctx, model.Subscription{ID: "fails"}, n))
+
+	var ok, failed model.Delivery
+	require.NoError(t, s.DB.First(&ok, "subscription_id = ?", "ok").Error)
+	assert.Equal(t, model.DeliverySent, ok.Status)
+	require.NoError(t, s.DB.First(&failed, "subscription_id = ?", "fails").Error)
+	assert.Equal(t, model.DeliveryFailed, failed.Status)
+	assert.Equal(t, "smtp down", failed.Error)
+}
+
+// TestDeliver_UntrackedWithoutRecord verifies that an email whose delivery could not be
+// recorded is sent without tracking, so no open or click points at a missing row.
+func TestDeliver_UntrackedWithoutRecord(t *testing.T) {
+	t.Parallel()
+	s := newTestScheduler(t)
+	sender := &trackedSender{}
+	s.Notifier = sender
+	s.TrackEngagement = true
+	require.NoError(t, s.DB.Migrator().DropTable(&model.Delivery{}))
+
+	n := notify.Notification{Kind: notify.KindWeather, Weather: &model.Weather{}}
+	require.NoError(t, s.deliver(context.Background(), model.Subscription{ID: "email"}, n))
+	assert.Equal(t, []string{""}, sender.deliveryIDs)
+}
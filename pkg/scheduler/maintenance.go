@@ -0,0 +1,228 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/logging"
+	"weatherApi/pkg/metrics"
+	"weatherApi/pkg/ratelimit"
+	"weatherApi/pkg/tracing"
+)
+
+// Maintenance actions, used as metric labels and log fields.
+const (
+	actionReminded   = "reminded"
+	actionExpired    = "expired"
+	actionAnonymized = "anonymized"
+	actionDeleted    = "deleted"
+	actionDowngraded = "downgraded"
+)
+
+// MaintenancePolicy configures the maintenance job. A zero duration disables its step.
+type MaintenancePolicy struct {
+	ReminderAfter      time.Duration // Remind pending subscriptions this long after their confirmation email
+	ExpireAfter        time.Duration // Expire pending subscriptions this long after their confirmation email
+	RetainUnsubscribed time.Duration // Clean up subscriptions this long after they were unsubscribed
+	RetentionAction    string        // config.RetentionAnonymize or config.RetentionDelete
+	DowngradeAfter     int           // Move hourly subscriptions to daily after this many unopened tracked emails
+	RateLimitIdle      time.Duration // Delete rate limit buckets unused for this long
+}
+
+// maintenancePolicyFromConfig builds the policy from config.C. Downgrades need
+// engagement tracking, so they are disabled without it.
+func maintenancePolicyFromConfig() MaintenancePolicy {
+	policy := MaintenancePolicy{
+		ReminderAfter:      config.C.ConfirmationReminderAfter,
+		ExpireAfter:        days(config.C.PendingExpiryDays),
+		RetainUnsubscribed: days(config.C.UnsubscribedRetentionDays),
+		RetentionAction:    config.C.RetentionAction,
+		RateLimitIdle:      time.Hour, // Every limit refills within an hour (ratelimit.PerHour)
+	}
+	if config.C.EngagementTracking {
+		policy.DowngradeAfter = config.C.EngagementDowngradeAfter
+	}
+	return policy
+}
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// startMaintenance applies the policy at the given interval.
+func (s *Scheduler) startMaintenance(interval time.Duration, policy MaintenancePolicy) {
+	slog.Info("maintenance job started", "interval", interval.String(),
+		"reminder_after", policy.ReminderAfter.String(), "expire_after", policy.ExpireAfter.String(),
+		"retain_unsubscribed", policy.RetainUnsubscribed.String(), "retention_action", policy.RetentionAction,
+		"downgrade_after", policy.DowngradeAfter)
+
+	ticker := time.NewTicker(interval)
+	for {
+		s.runMaintenance(time.Now(), policy)
+		s.recordTick(LoopMaintenance, time.Now())
+		<-ticker.C
+	}
+}
+
+// runMaintenance expires stale pending subscriptions, reminds the remaining ones once,
+// anonymizes or deletes long-unsubscribed ones, moves hourly subscribers who never
+// open their emails to daily and deletes idle rate limit buckets. It returns the
+// number of subscriptions handled per action.
+func (s *Scheduler) runMaintenance(now time.Time, policy MaintenancePolicy) map[string]int {
+	ctx, span := tracing.Start(context.Background(), "scheduler.maintenance")
+	defer span.End()
+
+	run := s.beginRun(ctx, LoopMaintenance)
+	ctx = logging.WithRunID(ctx, run.ID)
+	defer s.finishRun(ctx, run)
+
+	done := map[string]int{}
+	handle := func(action string, subs []model.Subscription, err error, fn func(ctx context.Context, sub model.Subscription) (bool, error)) {
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to query subscriptions for maintenance", "action", action, "error", err)
+			run.Failed++
+			return
+		}
+		for _, sub := range subs {
+			subCtx := logging.WithSubscriptionID(ctx, sub.ID)
+			ok, err := fn(subCtx, sub)
+			switch {
+			case err != nil:
+				slog.ErrorContext(subCtx, "maintenance action failed", "action", action, "error", err)
+				run.Failed++
+			case ok:
+				done[action]++
+				run.Processed++
+				metrics.MaintenanceActions.WithLabelValues(action).Inc()
+			default:
+				run.Skipped++
+			}
+		}
+	}
+
+	// Expire first, so nobody is reminded of a subscription that is gone in the same run
+	if policy.ExpireAfter > 0 {
+		subs, err := s.Subs.ListExpireDue(ctx, now.Add(-policy.ExpireAfter))
+		handle(actionExpired, subs, err, func(ctx context.Context, sub model.Subscription) (bool, error) {
+			return skipRaced(s.Subs.Transition(ctx, sub.ID, model.StatusExpired, repository.StatusChange{
+				Actor:  model.ActorScheduler,
+				Reason: fmt.Sprintf("not confirmed within %d days", int(policy.ExpireAfter.Hours()/24)),
+			}))
+		})
+	}
+
+	if policy.ReminderAfter > 0 {
+		subs, err := s.Subs.ListReminderDue(ctx, now.Add(-policy.ReminderAfter))
+		handle(actionReminded, subs, err, func(ctx context.Context, sub model.Subscription) (bool, error) {
+			return s.remind(ctx, sub, now, policy)
+		})
+	}
+
+	if policy.RetainUnsubscribed > 0 {
+		action := actionAnonymized
+		if policy.RetentionAction == config.RetentionDelete {
+			action = actionDeleted
+		}
+		subs, err := s.Subs.ListRetentionDue(ctx, now.Add(-policy.RetainUnsubscribed))
+		handle(action, subs, err, func(ctx context.Context, sub model.Subscription) (bool, error) {
+			if action == actionDeleted {
+				return skipRaced(s.Subs.Delete(ctx, sub.ID))
+			}
+			return skipRaced(s.Subs.Anonymize(ctx, sub.ID, now))
+		})
+	}
+
+	if policy.DowngradeAfter > 0 {
+		subs, err := s.unengaged(ctx, "hourly", policy.DowngradeAfter)
+		handle(actionDowngraded, subs, err, func(ctx context.Context, sub model.Subscription) (bool, error) {
+			return s.downgrade(ctx, sub.ID)
+		})
+	}
+
+	var buckets int64
+	if policy.RateLimitIdle > 0 {
+		var err error
+		buckets, err = ratelimit.NewGormStore(s.DB.WithContext(ctx)).DeleteIdle(now.Add(-policy.RateLimitIdle))
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to delete idle rate limit buckets", "error", err)
+			run.Failed++
+		}
+	}
+
+	slog.InfoContext(ctx, "maintenance finished",
+		actionReminded, done[actionReminded], actionExpired, done[actionExpired],
+		actionAnonymized, done[actionAnonymized], actionDeleted, done[actionDeleted],
+		actionDowngraded, done[actionDowngraded], "rate_limit_buckets_deleted", buckets)
+	return done
+}
+
+// remind sends the confirmation reminder of a pending subscription. The reminder is
+// recorded before it is sent, so a failed send is not retried but never duplicated.
+func (s *Scheduler) remind(ctx context.Context, sub model.Subscription, now time.Time, policy MaintenancePolicy) (bool, error) {
+	claimed, err := s.Subs.MarkReminderSent(ctx, sub.ID, now)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	expiresIn := 0
+	if policy.ExpireAfter > 0 {
+		expiresIn = int(policy.ExpireAfter.Hours() / 24)
+	}
+	if err := s.SendReminder(ctx, sub.Email, sub.Token, expiresIn); err != nil {
+		return false, fmt.Errorf("send confirmation reminder: %w", err)
+	}
+	return true, nil
+}
+
+// unengaged returns the active subscriptions with the given frequency that opened
+// none of their last n tracked emails.
+func (s *Scheduler) unengaged(ctx context.Context, frequency string, n int) ([]model.Subscription, error) {
+	subs, err := s.Subs.ListActive(ctx, frequency)
+	if err != nil {
+		return nil, err
+	}
+	tracking := repository.NewEngagement(s.DB)
+	var out []model.Subscription
+	for _, sub := range subs {
+		idle, err := tracking.Unengaged(ctx, sub.ID, n)
+		if err != nil {
+			return nil, err
+		}
+		if idle {
+			out = append(out, sub)
+		}
+	}
+	return out, nil
+}
+
+// downgrade moves an hourly subscription to daily updates. It reports false if the
+// subscription changed its frequency in the meantime.
+func (s *Scheduler) downgrade(ctx context.Context, id string) (bool, error) {
+	changed := false
+	err := s.Subs.Transaction(ctx, func(ctx context.Context) error {
+		sub, err := s.Subs.GetByID(ctx, id)
+		if err != nil || sub.Frequency != "hourly" {
+			return err
+		}
+		sub.Frequency = "daily"
+		changed = true
+		return s.Subs.Update(ctx, sub)
+	})
+	ok, err := skipRaced(err)
+	return ok && changed, err
+}
+
+// skipRaced treats subscriptions that changed since they were listed, e.g. confirmed
+// or deleted in the meantime, as skipped rather than failed.
+func skipRaced(err error) (bool, error) {
+	if errors.Is(err, model.ErrInvalidTransition) || errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
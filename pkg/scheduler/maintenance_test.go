@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunMaintenance verifies that stale pending subscriptions expire without a reminder,
+// that other pending ones are reminded exactly once, and that long-unsubscribed ones
+// are cleaned up according to the retention action.
+func TestRunMaintenance(t *testing.T) {
+	t.Parallel()
+	for _, action := range []string{config.RetentionAnonymize, config.RetentionDelete} {
+		t.Run(action, func(t *testing.T) {
+			t.Parallel()
+			s := newTestScheduler(t)
+			ctx := context.Background()
+
+			var reminded []string
+			s.SendReminder = func(_ context.Context, toEmail, token string, expiresInDays int) error {
+				assert.Equal(t, 7, expiresInDays)
+				reminded = append(reminded, toEmail)
+				return nil
+			}
+
+			// Status history is recorded with the real time, so run ten days later
+			now := time.Now().AddDate(0, 0, 10)
+			for id, sentAgo := range map[string]time.Duration{"stale": 8 * 24 * time.Hour, "remind": 48 * time.Hour, "fresh": time.Hour} {
+				sentAt := now.Add(-sentAgo)
+				require.NoError(t, s.Subs.Create(ctx, &model.Subscription{
+					ID: id, Email: id + "@example.com", City: "Kyiv", Frequency: "daily", Token: id, ConfirmationSentAt: &sentAt,
+				}, repository.StatusChange{Actor: model.ActorSubscriber, Reason: "test"}))
+			}
+			require.NoError(t, s.Subs.Create(ctx, &model.Subscription{
+				ID: "gone", Email: "gone@example.com", City: "Kyiv", Frequency: "daily", Token: "gone", Status: model.StatusUnsubscribed,
+			}, repository.StatusChange{Actor: model.ActorSubscriber, Reason: "test"}))
+
+			policy := MaintenancePolicy{
+				ReminderAfter:      24 * time.Hour,
+				ExpireAfter:        7 * 24 * time.Hour,
+				RetainUnsubscribed: 7 * 24 * time.Hour,
+				RetentionAction:    action,
+			}
+			done := s.runMaintenance(now, policy)
+			cleaned := actionAnonymized
+			if action == config.RetentionDelete {
+				cleaned = actionDeleted
+			}
+			assert.Equal(t, map[string]int{actionExpired: 1, actionReminded: 1, cleaned: 1}, done)
+			assert.Equal(t, []string{"remind@example.com"}, reminded)
+
+			stale, err := s.Subs.GetByID(ctx, "stale")
+			require.NoError(t, err)
+			assert.Equal(t, model.StatusExpired, stale.Status)
+			events, err := s.Subs.Events(ctx, "stale")
+			require.NoError(t, err)
+			assert.Equal(t, model.ActorScheduler, events[len(events)-1].Actor)
+
+			gone, err := s.Subs.GetByID(ctx, "gone")
+			if action == config.RetentionDelete {
+				assert.ErrorIs(t, err, repository.ErrNotFound)
+			} else {
+				require.NoError(t, err)
+				assert.NotEqual(t, "gone@example.com", gone.Email)
+			}
+
+			// Nothing is left to do an hour later
+			assert.Empty(t, s.runMaintenance(now.Add(time.Hour), policy))
+			assert.Len(t, reminded, 1)
+
+			var runs int64
+			require.NoError(t, s.DB.Model(&model.SchedulerRun{}).Where("kind = ?", LoopMaintenance).Count(&runs).Error)
+			assert.EqualValues(t, 2, runs)
+		})
+	}
+}
+
+// TestRunMaintenance_Downgrade verifies that only hourly subscribers who opened none of
+// their last tracked emails are moved to daily updates.
+func TestRunMaintenance_Downgrade(t *testing.T) {
+	t.Parallel()
+	s := newTestScheduler(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, id := range []string{"idle", "reader", "new"} {
+		require.NoError(t, s.Subs.Create(ctx, &model.Subscription{
+			ID: id, Email: id + "@example.com", City: "Kyiv", Frequency: "hourly", Token: id, Status: model.StatusActive,
+		}, repository.StatusChange{Actor: model.ActorSubscriber, Reason: "test"}))
+		sent := 3
+		if id == "new" {
+			sent = 2
+		}
+		for i := range sent {
+			require.NoError(t, s.DB.Create(&model.Delivery{
+				ID: fmt.Sprintf("%s-%d", id, i), SubscriptionID: id, Kind: "weather.update",
+				Status: model.DeliverySent, Tracked: true, CreatedAt: now.Add(time.Duration(i-3) * time.Hour),
+			}).Error)
+		}
+	}
+	_, err := repository.NewEngagement(s.DB).Record(ctx, "reader-0", model.EngagementOpen, "", now)
+	require.NoError(t, err)
+
+	policy := MaintenancePolicy{DowngradeAfter: 3}
+	assert.Equal(t, map[string]int{actionDowngraded: 1}, s.runMaintenance(now, policy))
+
+	for id, frequency := range map[string]string{"idle": "daily", "reader": "hourly", "new": "hourly"} {
+		sub, err := s.Subs.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, frequency, sub.Frequency, id)
+	}
+	assert.Empty(t, s.runMaintenance(now, policy), "daily subscriptions are not downgraded again")
+}
+
+// TestRunMaintenance_RateLimitBuckets verifies that idle rate limit buckets are deleted
+// and buckets in use are kept.
+func TestRunMaintenance_RateLimitBuckets(t *testing.T) {
+	t.Parallel()
+	s := newTestScheduler(t)
+	now := time.Now()
+
+	require.NoError(t, s.DB.Create(&[]model.RateLimitBucket{
+		{Key: "subscribe:ip:198.51.100.1", Tokens: 1, UpdatedAt: now.Add(-2 * time.Hour)},
+		{Key: "subscribe:ip:198.51.100.2", Tokens: 1, UpdatedAt: now.Add(-time.Minute)},
+	}).Error)
+
+	assert.Empty(t, s.runMaintenance(now, MaintenancePolicy{RateLimitIdle: time.Hour}))
+
+	var keys []string
+	require.NoError(t, s.DB.Model(&model.RateLimitBucket{}).Pluck("key", &keys).Error)
+	assert.Equal(t, []string{"subscribe:ip:198.51.100.2"}, keys)
+}
@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/logging"
+	"weatherApi/pkg/metrics"
+	"weatherApi/pkg/tracing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Outbox worker tuning.
+const (
+	outboxBatchSize  = 50               // Jobs executed per poll at most
+	outboxLease      = 5 * time.Minute  // A claimed job becomes due again if its worker dies
+	outboxMaxBackoff = 60 * time.Minute // Upper bound for the retry delay
+)
+
+// EnqueueWelcome queues the first forecast for a freshly confirmed subscription.
+// Called inside a repository transaction, the job is stored atomically with the
+// confirmation, so it is neither lost nor sent for a confirmation that rolled back.
+func (s *Scheduler) EnqueueWelcome(ctx context.Context, subscriptionID string) error {
+	now := time.Now()
+	return repository.Conn(ctx, s.DB).Create(&model.OutboxJob{
+		ID:             uuid.New().String(),
+		Kind:           model.JobWelcome,
+		SubscriptionID: subscriptionID,
+		RunAt:          now,
+		CreatedAt:      now,
+	}).Error
+}
+
+// startOutboxWorker executes due outbox jobs at the given interval,
+// giving each job up to maxAttempts executions.
+func (s *Scheduler) startOutboxWorker(interval time.Duration, maxAttempts int) {
+	slog.Info("outbox worker started", "interval", interval.String(), "max_attempts", maxAttempts)
+
+	ticker := time.NewTicker(interval)
+	for {
+		s.processOutbox(time.Now(), maxAttempts)
+		s.recordTick(LoopOutbox, time.Now())
+		<-ticker.C
+	}
+}
+
+// processOutbox claims and executes the jobs due at now, oldest first.
+func (s *Scheduler) processOutbox(now time.Time, maxAttempts int) {
+	ctx, span := tracing.Start(context.Background(), "scheduler.outbox")
+	defer span.End()
+
+	var jobs []model.OutboxJob
+	if err := s.DB.WithContext(ctx).
+		Where("run_at <= ? AND attempts < ?", now, maxAttempts).
+		Order("run_at").Limit(outboxBatchSize).
+		Find(&jobs).Error; err != nil {
+		slog.ErrorContext(ctx, "failed to query outbox jobs", "error", err)
+		return
+	}
+
+	for _, job := range jobs {
+		claimed, err := s.claimJob(ctx, &job, now)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to claim outbox job", "job_id", job.ID, "error", err)
+			continue
+		}
+		if claimed {
+			s.runJob(ctx, job, now, maxAttempts)
+			s.recordTick(LoopOutbox, time.Now()) // a long batch must not look like a dead loop
+		}
+	}
+}
+
+// claimJob takes the job for this worker by counting the attempt and pushing run_at
+// out by the lease. The update only matches while the attempt count is unchanged,
+// so concurrent workers (e.g. several replicas) never execute the same attempt twice.
+func (s *Scheduler) claimJob(ctx context.Context, job *model.OutboxJob, now time.Time) (bool, error) {
+	res := s.DB.WithContext(ctx).Model(&model.OutboxJob{}).
+		Where("id = ? AND attempts = ?", job.ID, job.Attempts).
+		Updates(map[string]interface{}{"attempts": gorm.Expr("attempts + 1"), "run_at": now.Add(outboxLease)})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	job.Attempts++
+	return res.RowsAffected == 1, nil
+}
+
+// runJob executes a claimed job. Finished jobs are deleted; failed ones are
+// rescheduled with exponential backoff and kept once they run out of attempts.
+func (s *Scheduler) runJob(ctx context.Context, job model.OutboxJob, now time.Time, maxAttempts int) {
+	ctx = logging.WithSubscriptionID(ctx, job.SubscriptionID)
+
+	err := s.executeJob(ctx, job)
+	if err == nil {
+		metrics.SchedulerProcessed.WithLabelValues(LoopOutbox).Inc()
+		if err := s.DB.WithContext(ctx).Delete(&model.OutboxJob{}, "id = ?", job.ID).Error; err != nil {
+			slog.ErrorContext(ctx, "failed to delete finished outbox job", "job_id", job.ID, "error", err)
+		}
+		return
+	}
+
+	metrics.SchedulerFailures.WithLabelValues(LoopOutbox).Inc()
+	if job.Attempts >= maxAttempts {
+		slog.ErrorContext(ctx, "outbox job failed, giving up", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err)
+	} else {
+		slog.WarnContext(ctx, "outbox job failed, will retry", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err)
+	}
+
+	if dbErr := s.DB.WithContext(ctx).Model(&model.OutboxJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"last_error": err.Error(),
+		"run_at":     now.Add(outboxBackoff(job.Attempts)),
+	}).Error; dbErr != nil {
+		slog.ErrorContext(ctx, "failed to reschedule outbox job", "job_id", job.ID, "error", dbErr)
+	}
+}
+
+// executeJob performs a single job. Jobs for subscriptions that were deleted or
+// are no longer active succeed without doing anything.
+func (s *Scheduler) executeJob(ctx context.Context, job model.OutboxJob) error {
+	switch job.Kind {
+	case model.JobWelcome:
+		sub, err := s.Subs.GetByID(ctx, job.SubscriptionID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if sub.Status != model.StatusActive {
+			return nil
+		}
+
+		// A subscription whose conditions don't match yet is confirmed but gets no email now
+		if err := s.ProcessSubscription(ctx, *sub); err != nil && !errors.Is(err, ErrNoConditionMatched) {
+			return err
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown outbox job kind %q", job.Kind)
+	}
+}
+
+// outboxBackoff returns the delay before retrying a job that failed its n-th attempt:
+// 1, 2, 4, ... minutes, capped at outboxMaxBackoff.
+func outboxBackoff(attempts int) time.Duration {
+	d := time.Minute
+	for i := 1; i < attempts && d < outboxMaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, outboxMaxBackoff)
+}
@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOutboxScheduler returns a test scheduler with a fake weather provider and
+// one stored subscription with the given status, queued for a welcome forecast.
+func newOutboxScheduler(t *testing.T, status string) *Scheduler {
+	s := newTestScheduler(t)
+	s.FetchWeather = func(_ context.Context, query string) (*model.Weather, int, error) {
+		return &model.Weather{Temperature: 18, Description: "Sunny"}, 200, nil
+	}
+	s.FetchForecast = func(_ context.Context, query string, days int) (*model.Forecast, int, error) {
+		return &model.Forecast{Days: []model.ForecastDay{{Date: "2026-10-19", MaxTempC: 20, MinTempC: 9}}}, 200, nil
+	}
+
+	ctx := context.Background()
+	require.NoError(t, s.Subs.Create(ctx, &model.Subscription{
+		ID: "sub-1", Email: "welcome@example.com", City: "Kyiv", Frequency: "daily", Token: "t", Status: status,
+	}, repository.StatusChange{Actor: model.ActorSystem, Reason: "test"}))
+	require.NoError(t, s.EnqueueWelcome(ctx, "sub-1"))
+	return s
+}
+
+// loadJobs returns all stored outbox jobs.
+func loadJobs(t *testing.T, s *Scheduler) []model.OutboxJob {
+	var jobs []model.OutboxJob
+	require.NoError(t, s.DB.Find(&jobs).Error)
+	return jobs
+}
+
+// TestProcessOutbox_DeliversWelcome verifies that a queued welcome forecast is sent once
+// and the job is removed.
+func TestProcessOutbox_DeliversWelcome(t *testing.T) {
+	t.Parallel()
+	s := newOutboxScheduler(t, model.StatusActive)
+
+	var sent []notify.Notification
+	s.Notifier = senderFunc(func(sub model.Subscription, n notify.Notification) error {
+		sent = append(sent, n)
+		return nil
+	})
+
+	s.processOutbox(time.Now(), 3)
+	s.processOutbox(time.Now(), 3)
+
+	require.Len(t, sent, 1)
+	assert.Equal(t, notify.KindWeather, sent[0].Kind)
+	require.NotNil(t, sent[0].Forecast, "daily subscriptions get the digest")
+	assert.Empty(t, loadJobs(t, s))
+	assert.Contains(t, s.LastTicks(), LoopOutbox)
+}
+
+// TestProcessOutbox_RetriesWithBackoff verifies that failed jobs keep their error, are
+// retried only after the backoff, and are given up after the last attempt.
+func TestProcessOutbox_RetriesWithBackoff(t *testing.T) {
+	t.Parallel()
+	s := newOutboxScheduler(t, model.StatusActive)
+
+	calls := 0
+	s.Notifier = senderFunc(func(sub model.Subscription, n notify.Notification) error {
+		calls++
+		return errors.New("sendgrid: 503 Service Unavailable")
+	})
+
+	now := time.Now()
+	s.processOutbox(now, 2)
+	jobs := loadJobs(t, s)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, 1, jobs[0].Attempts)
+	assert.Contains(t, jobs[0].LastError, "503")
+	assert.WithinDuration(t, now.Add(time.Minute), jobs[0].RunAt, time.Second)
+
+	s.processOutbox(now.Add(30*time.Second), 2) // still backing off
+	assert.Equal(t, 1, calls)
+
+	s.processOutbox(now.Add(2*time.Minute), 2)
+	s.processOutbox(now.Add(time.Hour), 2) // out of attempts
+	assert.Equal(t, 2, calls)
+
+	jobs = loadJobs(t, s)
+	require.Len(t, jobs, 1, "given-up jobs are kept for inspection")
+	assert.Equal(t, 2, jobs[0].Attempts)
+}
+
+// TestProcessOutbox_SkipsInactiveSubscription verifies that a job whose subscription
+// is no longer active is dropped without sending anything.
+func TestProcessOutbox_SkipsInactiveSubscription(t *testing.T) {
+	t.Parallel()
+	s := newOutboxScheduler(t, model.StatusUnsubscribed)
+	s.Notifier = senderFunc(func(sub model.Subscription, n notify.Notification) error {
+		t.Fatal("nothing should be sent")
+		return nil
+	})
+
+	s.processOutbox(time.Now(), 3)
+	assert.Empty(t, loadJobs(t, s))
+}
+
+// TestClaimJob_Once verifies that a job read by two workers is executed by one of them only.
+func TestClaimJob_Once(t *testing.T) {
+	t.Parallel()
+	s := newOutboxScheduler(t, model.StatusActive)
+	ctx := context.Background()
+
+	jobs := loadJobs(t, s)
+	require.Len(t, jobs, 1)
+	first, second := jobs[0], jobs[0]
+
+	claimed, err := s.claimJob(ctx, &first, time.Now())
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = s.claimJob(ctx, &second, time.Now())
+	require.NoError(t, err)
+	assert.False(t, claimed)
+}
+
+// TestOutboxBackoff verifies the exponential retry delays and their cap.
+func TestOutboxBackoff(t *testing.T) {
+	assert.Equal(t, time.Minute, outboxBackoff(1))
+	assert.Equal(t, 2*time.Minute, outboxBackoff(2))
+	assert.Equal(t, 8*time.Minute, outboxBackoff(4))
+	assert.Equal(t, outboxMaxBackoff, outboxBackoff(20))
+}
@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/logging"
+	"weatherApi/pkg/tracing"
+)
+
+// resumeDueSubscriptions reactivates paused subscriptions whose resume date has come.
+// It runs on the hourly tick before updates are sent, so a subscription resumed
+// at that tick already receives its update.
+func (s *Scheduler) resumeDueSubscriptions(now time.Time) {
+	ctx, span := tracing.Start(context.Background(), "scheduler.resume")
+	defer span.End()
+
+	subs, err := s.Subs.ListResumeDue(ctx, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to query paused subscriptions", "error", err)
+		return
+	}
+
+	for _, sub := range subs {
+		subCtx := logging.WithSubscriptionID(ctx, sub.ID)
+		err := s.Subs.Transition(subCtx, sub.ID, model.StatusActive, repository.StatusChange{
+			Actor: model.ActorScheduler, Reason: "resume date reached",
+		})
+		switch {
+		case errors.Is(err, model.ErrInvalidTransition):
+			// Changed since the query, e.g. resumed or unsubscribed by the subscriber
+			continue
+		case err != nil:
+			slog.ErrorContext(subCtx, "failed to resume subscription", "error", err)
+		default:
+			slog.InfoContext(subCtx, "subscription resumed", "resume_on", sub.ResumeOn.Format(time.DateOnly))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResumeDueSubscriptions verifies that paused subscriptions are reactivated on their
+// resume date only, that the change is attributed to the scheduler, and that active
+// subscriptions are then picked up by the regular update run.
+func TestResumeDueSubscriptions(t *testing.T) {
+	t.Parallel()
+	s := newTestScheduler(t)
+	ctx := context.Background()
+
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	tomorrow := now.AddDate(0, 0, 1)
+	for id, on := range map[string]*time.Time{"due": &now, "later": &tomorrow, "manual": nil} {
+		require.NoError(t, s.Subs.Create(ctx, &model.Subscription{
+			ID: id, Email: id + "@example.com", City: "Kyiv", Frequency: "daily", Token: id, Status: model.StatusPaused,
+		}, repository.StatusChange{Actor: model.ActorSystem, Reason: "test"}))
+		require.NoError(t, s.Subs.SetResumeOn(ctx, id, on))
+	}
+
+	s.resumeDueSubscriptions(now)
+
+	active, err := s.Subs.ListActive(ctx, "daily")
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "due", active[0].ID)
+	assert.Nil(t, active[0].ResumeOn)
+
+	events, err := s.Subs.Events(ctx, "due")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, model.ActorScheduler, events[1].Actor)
+	assert.Equal(t, model.StatusActive, events[1].ToStatus)
+
+	for _, id := range []string{"later", "manual"} {
+		sub, err := s.Subs.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, model.StatusPaused, sub.Status, id)
+	}
+}
@@ -1,49 +1,106 @@
 package scheduler
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 
+	"weatherApi/config"
 	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
 	"weatherApi/pkg/email"
+	"weatherApi/pkg/logging"
+	"weatherApi/pkg/notify"
+	"weatherApi/pkg/tracing"
 	"weatherApi/pkg/weatherapi"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 )
 
-// DB is the scheduler's database instance.
-// Must be set via SetDB() before StartWeatherScheduler is called.
-var DB *gorm.DB
-var FetchWeather = weatherapi.FetchWithStatus
-var SendWeatherEmail = email.SendWeatherEmail
+// digestForecastDays is the outlook requested for daily digests. Providers on
+// smaller plans return fewer days, which the digest handles.
+const digestForecastDays = 5
 
-// SetDB assigns a GORM database instance to the scheduler.
-// This allows decoupling from the main DB package for testability or modularity.
-func SetDB(db *gorm.DB) {
-	DB = db
+// ErrNoConditionMatched is returned by ProcessSubscription when a subscription has
+// conditions and none of them matches the forecast, so nothing was sent.
+var ErrNoConditionMatched = errors.New("no subscription condition matched")
+
+// Scheduler sends periodic weather updates and alerts to active subscriptions.
+// Dependencies are plain fields so tests can replace them per instance.
+type Scheduler struct {
+	Subs          repository.SubscriptionRepository
+	DB            *gorm.DB      // Delivery history, scheduler runs and alert claims
+	Notifier      notify.Sender // Delivers over each subscription's chosen channels (email, webhook or both)
+	FetchWeather  func(ctx context.Context, query string) (*model.Weather, int, error)
+	FetchForecast func(ctx context.Context, query string, days int) (*model.Forecast, int, error)
+	SendReminder  func(ctx context.Context, toEmail, token string, expiresInDays int) error // Confirmation reminders
+
+	TrackEngagement bool // Send emails with open and click tracking (config.C.EngagementTracking)
+
+	ticksMu sync.Mutex
+	ticks   map[string]time.Time
+}
+
+// New returns a scheduler reading subscriptions from subs and recording its history in db.
+// It delivers via notify.NewDispatcher, fetches weather from the provider and never
+// emails addresses on the suppression list in db.
+func New(subs repository.SubscriptionRepository, db *gorm.DB) *Scheduler {
+	suppressed := repository.NewSuppressions(db).Blocked
+	sendReminder := func(ctx context.Context, toEmail, token string, expiresInDays int) error {
+		if err := email.CheckSuppression(ctx, suppressed, toEmail); err != nil {
+			return err
+		}
+		return email.SendConfirmationReminderEmail(ctx, toEmail, token, expiresInDays)
+	}
+
+	return &Scheduler{
+		Subs:          subs,
+		DB:            db,
+		Notifier:      notify.NewDispatcher(suppressed),
+		FetchWeather:  weatherapi.FetchWithStatus,
+		FetchForecast: weatherapi.FetchForecast,
+		SendReminder:  sendReminder,
+		ticks:         map[string]time.Time{},
+	}
 }
 
-// StartWeatherScheduler starts a background task that sends weather updates.
-// It sends "hourly" updates every round hour and "daily" updates at 12:00 UTC.
-func StartWeatherScheduler() {
-	log.Println("[Scheduler] started")
+// Start runs the scheduler loops and blocks forever.
+// It sends "hourly" updates every round hour and "daily" updates at 12:00 UTC,
+// after reactivating paused subscriptions whose resume date has come.
+// "alerts" subscriptions are polled separately at config.C.AlertPollInterval,
+// queued outbox jobs are executed every config.C.OutboxPollInterval, and
+// reminders, expiry and retention are applied every config.C.MaintenanceInterval.
+func (s *Scheduler) Start() {
+	slog.Info("scheduler started")
+	s.recordTick(LoopHourly, time.Now()) // alive while aligning to the first tick
+
+	go s.startAlertPoller(config.C.AlertPollInterval)
+	go s.startOutboxWorker(config.C.OutboxPollInterval, config.C.OutboxMaxAttempts)
+	go s.startMaintenance(config.C.MaintenanceInterval, maintenancePolicyFromConfig())
 
 	// Align to the next full hour (e.g., xx:00:00)
 	now := time.Now()
 	nextHour := now.Truncate(time.Hour).Add(time.Hour)
 	sleep := time.Until(nextHour)
-	log.Printf("[Scheduler] sleeping %v to align to next full hour\n", sleep)
+	slog.Info("scheduler aligning to next full hour", "sleep", sleep.String())
 	time.Sleep(sleep)
 
 	ticker := time.NewTicker(1 * time.Hour)
 	for {
 		now := time.Now()
-		log.Println("[Scheduler] running tick", now.Format("15:04:05"))
+		slog.Info("scheduler tick", "time", now.Format("15:04:05"))
+		s.recordTick(LoopHourly, now)
 
-		go sendWeatherUpdates("hourly")
+		s.resumeDueSubscriptions(now)
+		go s.sendWeatherUpdates("hourly", changePolicyFromConfig())
 
 		if now.Hour() == 12 {
-			go sendWeatherUpdates("daily")
+			go s.sendWeatherUpdates("daily", ChangePolicy{})
 		}
 
 		<-ticker.C
@@ -51,33 +108,151 @@ func StartWeatherScheduler() {
 }
 
 // sendWeatherUpdates fetches all active subscriptions with the given frequency
-// and sends weather updates for each one via email.
-func sendWeatherUpdates(frequency string) {
-	var subs []model.Subscription
-
-	if err := DB.Where(
-		"is_confirmed = ? AND is_unsubscribed = ? AND frequency = ?",
-		true, false, frequency,
-	).Find(&subs).Error; err != nil {
-		log.Printf("[Scheduler] Failed to query subscriptions: %v", err)
+// and sends weather updates for each one via its channels.
+// Plain updates that are not significant under policy are skipped.
+func (s *Scheduler) sendWeatherUpdates(frequency string, policy ChangePolicy) {
+	ctx, span := tracing.Start(context.Background(), "scheduler."+frequency)
+	defer span.End()
+
+	run := s.beginRun(ctx, frequency)
+	ctx = logging.WithRunID(ctx, run.ID)
+	defer s.finishRun(ctx, run)
+
+	subs, err := s.Subs.ListActive(ctx, frequency)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to query subscriptions", "frequency", frequency, "error", err)
+		run.Failed++
 		return
 	}
 
 	for _, sub := range subs {
-		if err := ProcessSubscription(sub); err != nil {
-			log.Printf("[Scheduler] Failed to process %s: %v", sub.Email, err)
+		subCtx := logging.WithSubscriptionID(ctx, sub.ID)
+		err := s.process(subCtx, sub, policy)
+		if errors.Is(err, ErrNoConditionMatched) {
+			slog.InfoContext(subCtx, "no condition matched, skipped")
+			run.Skipped++
+			continue
+		}
+		if errors.Is(err, ErrUnchanged) {
+			slog.InfoContext(subCtx, "weather unchanged, skipped")
+			run.Skipped++
+			continue
+		}
+		if err != nil {
+			slog.ErrorContext(subCtx, "failed to process subscription", "error", err)
+			run.Failed++
 		} else {
-			log.Printf("[Scheduler] Weather sent to %s", sub.Email)
+			slog.InfoContext(subCtx, "weather update sent")
+			run.Processed++
 		}
 	}
 }
 
 // ProcessSubscription fetches the weather for a single subscription
-// and sends the email using the stored unsubscribe token.
-func ProcessSubscription(sub model.Subscription) error {
-	weather, _, err := FetchWeather(sub.City)
+// and delivers it over the subscription's channels.
+// "daily" subscriptions get the daily digest with a multi-day forecast.
+// For "alerts" subscriptions it sends any active warnings not yet delivered instead.
+// Subscriptions with conditions are only emailed when a condition matches the forecast.
+// The subscription's Location and Conditions should be preloaded.
+func (s *Scheduler) ProcessSubscription(ctx context.Context, sub model.Subscription) error {
+	return s.process(ctx, sub, ChangePolicy{})
+}
+
+// process is ProcessSubscription that skips plain updates with ErrUnchanged when they
+// are not significant under policy compared to the weather last sent.
+func (s *Scheduler) process(ctx context.Context, sub model.Subscription, policy ChangePolicy) (err error) {
+	ctx = logging.WithSubscriptionID(ctx, sub.ID)
+	ctx, span := tracing.Start(ctx, "scheduler.ProcessSubscription", trace.WithAttributes(
+		attribute.String("subscription.id", sub.ID),
+		attribute.String("subscription.frequency", sub.Frequency),
+	))
+	defer func() {
+		if errors.Is(err, ErrNoConditionMatched) || errors.Is(err, ErrUnchanged) {
+			span.SetAttributes(attribute.Bool("subscription.skipped", true))
+			span.End()
+			return
+		}
+		tracing.End(span, err)
+	}()
+
+	if sub.Frequency == "alerts" {
+		forecast, _, err := s.FetchForecast(ctx, sub.WeatherQuery(), 1)
+		if err != nil {
+			return err
+		}
+		_, err = s.deliverAlerts(ctx, sub, forecast.Alerts, time.Now())
+		return err
+	}
+
+	var matches []ConditionMatch
+	if len(sub.Conditions) > 0 {
+		forecast, _, err := s.FetchForecast(ctx, sub.WeatherQuery(), 2)
+		if err != nil {
+			return err
+		}
+		matches = evaluateConditions(sub.Conditions, forecast, time.Now(), conditionWindow(sub.Frequency))
+		if len(matches) == 0 {
+			return ErrNoConditionMatched
+		}
+	}
+
+	weather, _, err := s.FetchWeather(ctx, sub.WeatherQuery())
 	if err != nil {
 		return err
 	}
-	return SendWeatherEmail(sub.Email, weather, sub.City, sub.Token)
+
+	if len(matches) > 0 {
+		matched := describeMatches(matches, email.TimeZone(ctx, sub.Timezone()))
+		return s.deliver(ctx, sub, notify.Notification{Kind: notify.KindCondition, Weather: weather, Matched: matched})
+	}
+
+	now := time.Now()
+	if policy.Active() {
+		last, err := s.lastSnapshot(ctx, sub.ID)
+		if err != nil {
+			return err
+		}
+		send, reason := policy.Significant(last, weather, now)
+		if !send {
+			return ErrUnchanged
+		}
+		slog.InfoContext(ctx, "weather changed, sending update", "reason", reason)
+	}
+
+	n := notify.Notification{Kind: notify.KindWeather, Weather: weather}
+	if sub.Frequency == "daily" {
+		// The digest is optional; without a forecast the plain update is sent
+		forecast, _, err := s.FetchForecast(ctx, sub.WeatherQuery(), digestForecastDays)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to fetch digest forecast, sending plain update", "error", err)
+		} else {
+			n.Forecast = forecast
+		}
+	}
+	if err := s.deliver(ctx, sub, n); err != nil {
+		return err
+	}
+	if err := s.saveSnapshot(ctx, sub.ID, weather, now); err != nil {
+		slog.ErrorContext(ctx, "failed to record weather snapshot", "error", err)
+	}
+	return nil
+}
+
+// describeMatches formats matched conditions for the email body with times in loc,
+// the location's timezone, e.g. "precipitation>60 — 14.06 17:00 EEST (80%)".
+func describeMatches(matches []ConditionMatch, loc *time.Location) []string {
+	lines := make([]string, 0, len(matches))
+	for _, m := range matches {
+		var actual string
+		switch m.Condition.Metric {
+		case model.MetricTemperature:
+			actual = fmt.Sprintf("%.1f°C", m.Hour.TempC)
+		case model.MetricPrecipitation:
+			actual = fmt.Sprintf("%d%%", m.Hour.ChanceOfRain)
+		case model.MetricWind:
+			actual = fmt.Sprintf("%.0f km/h", m.Hour.WindKph)
+		}
+		lines = append(lines, fmt.Sprintf("%s — %s (%s)", m.Condition, m.Hour.Time.In(loc).Format("02.01 15:04 MST"), actual))
+	}
+	return lines
 }
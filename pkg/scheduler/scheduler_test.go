@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"weatherApi/internal/model"
+	"weatherApi/internal/repository"
+	"weatherApi/pkg/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSendWeatherUpdates_InMemoryRepository verifies that a run delivers to active
+// subscriptions of its frequency only, reading them from a swapped-in repository.
+func TestSendWeatherUpdates_InMemoryRepository(t *testing.T) {
+	t.Parallel()
+	s := newTestScheduler(t)
+	subs := repository.NewMemorySubscriptionRepository()
+	s.Subs = subs
+
+	ctx := context.Background()
+	for _, sub := range []model.Subscription{
+		{ID: "hourly-1", Email: "a@example.com", City: "Kyiv", Frequency: "hourly", Status: model.StatusActive},
+		{ID: "hourly-pending", Email: "b@example.com", City: "Kyiv", Frequency: "hourly"},
+		{ID: "daily-1", Email: "c@example.com", City: "Lviv", Frequency: "daily", Status: model.StatusActive},
+	} {
+		require.NoError(t, subs.Create(ctx, &sub, repository.StatusChange{Actor: model.ActorSystem, Reason: "test"}))
+	}
+
+	s.FetchWeather = func(_ context.Context, query string) (*model.Weather, int, error) {
+		return &model.Weather{Temperature: 12, Description: "Cloudy"}, 200, nil
+	}
+	var sent []string
+	s.Notifier = senderFunc(func(sub model.Subscription, n notify.Notification) error {
+		sent = append(sent, sub.ID)
+		return nil
+	})
+
+	s.sendWeatherUpdates("hourly", ChangePolicy{})
+	assert.Equal(t, []string{"hourly-1"}, sent)
+
+	var run model.SchedulerRun
+	require.NoError(t, s.DB.Where("kind = ?", "hourly").First(&run).Error)
+	assert.Equal(t, 1, run.Processed)
+	assert.WithinDuration(t, time.Now(), *run.FinishedAt, time.Minute)
+}
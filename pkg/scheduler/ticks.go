@@ -0,0 +1,31 @@
+package scheduler
+
+import "time"
+
+// Scheduler loops reported by LastTicks.
+const (
+	LoopHourly      = "hourly"      // Hourly/daily update loop, ticks every hour
+	LoopAlerts      = "alerts"      // Alert poller, ticks every config.C.AlertPollInterval
+	LoopOutbox      = "outbox"      // Outbox worker, ticks every config.C.OutboxPollInterval
+	LoopMaintenance = "maintenance" // Reminders, expiry and retention, ticks every config.C.MaintenanceInterval
+)
+
+// recordTick notes that a scheduler loop is alive at t.
+func (s *Scheduler) recordTick(loop string, t time.Time) {
+	s.ticksMu.Lock()
+	defer s.ticksMu.Unlock()
+	s.ticks[loop] = t
+}
+
+// LastTicks returns when each running scheduler loop last ticked.
+// Loops that have not started yet are absent. Used by the health probes
+// to detect a dead scheduler goroutine.
+func (s *Scheduler) LastTicks() map[string]time.Time {
+	s.ticksMu.Lock()
+	defer s.ticksMu.Unlock()
+	out := make(map[string]time.Time, len(s.ticks))
+	for loop, t := range s.ticks {
+		out[loop] = t
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// spanKey stores the active query span on the gorm statement instance.
+const spanKey = "tracing:span"
+
+// GormPlugin creates a client span for every GORM statement. Spans are children
+// of the span in the statement context, so queries must use DB.WithContext(ctx)
+// to appear inside a request or scheduler trace.
+// The SQL is recorded with placeholders only, never with bound values.
+type GormPlugin struct{}
+
+// Name implements gorm.Plugin.
+func (GormPlugin) Name() string { return "tracing" }
+
+// Initialize implements gorm.Plugin by registering before/after callbacks for every operation.
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracing:before_create", startSpan("create")),
+		cb.Create().After("gorm:create").Register("tracing:after_create", endSpan),
+		cb.Query().Before("gorm:query").Register("tracing:before_query", startSpan("query")),
+		cb.Query().After("gorm:query").Register("tracing:after_query", endSpan),
+		cb.Update().Before("gorm:update").Register("tracing:before_update", startSpan("update")),
+		cb.Update().After("gorm:update").Register("tracing:after_update", endSpan),
+		cb.Delete().Before("gorm:delete").Register("tracing:before_delete", startSpan("delete")),
+		cb.Delete().After("gorm:delete").Register("tracing:after_delete", endSpan),
+		cb.Row().Before("gorm:row").Register("tracing:before_row", startSpan("row")),
+		cb.Row().After("gorm:row").Register("tracing:after_row", endSpan),
+		cb.Raw().Before("gorm:raw").Register("tracing:before_raw", startSpan("raw")),
+		cb.Raw().After("gorm:raw").Register("tracing:after_raw", endSpan),
+	)
+}
+
+// startSpan is registered before each operation.
+func startSpan(op string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		if tx.Statement == nil || tx.Statement.Context == nil {
+			return
+		}
+		_, span := Start(tx.Statement.Context, "gorm."+op, trace.WithSpanKind(trace.SpanKindClient))
+		tx.InstanceSet(spanKey, span)
+	}
+}
+
+// endSpan is registered after each operation.
+func endSpan(tx *gorm.DB) {
+	v, ok := tx.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("db.system", tx.Dialector.Name()),
+		attribute.String("db.statement", tx.Statement.SQL.String()),
+		attribute.String("db.sql.table", tx.Statement.Table),
+		attribute.Int64("db.rows_affected", tx.RowsAffected),
+	)
+	err := tx.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil // expected outcome of lookups, not a failure
+	}
+	End(span, err)
+}
@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	ID   int
+	Name string
+}
+
+// TestGormPlugin_NestsStatementSpans verifies that statements run with a context
+// become child spans and that bound values are not recorded.
+func TestGormPlugin_NestsStatementSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&item{}))
+	require.NoError(t, db.Use(GormPlugin{}))
+
+	ctx, parent := Start(context.Background(), "parent")
+	require.NoError(t, db.WithContext(ctx).Create(&item{ID: 1, Name: "secret-value"}).Error)
+	var found item
+	require.NoError(t, db.WithContext(ctx).First(&found, 1).Error)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "gorm.create", spans[0].Name())
+	assert.Equal(t, "gorm.query", spans[1].Name())
+	for _, s := range spans[:2] {
+		assert.Equal(t, parent.SpanContext().SpanID(), s.Parent().SpanID())
+		for _, attr := range s.Attributes() {
+			assert.NotContains(t, attr.Value.Emit(), "secret-value")
+		}
+	}
+}
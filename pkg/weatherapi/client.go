@@ -0,0 +1,86 @@
+package weatherapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/pkg/metrics"
+	"weatherApi/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startCall starts a client span for a provider call and returns a function that
+// records the call's outcome in the span and the provider metrics.
+// Spans carry the endpoint name only, never the URL, because it contains the API key.
+func startCall(ctx context.Context, endpoint string) (context.Context, func(outcome string)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "weatherapi."+endpoint, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("weatherapi.endpoint", endpoint)))
+
+	return ctx, func(outcome string) {
+		metrics.ObserveProvider(endpoint, outcome, start)
+		span.SetAttributes(attribute.String("weatherapi.outcome", outcome))
+		if outcome != metrics.OutcomeOK {
+			span.SetStatus(codes.Error, outcome)
+		}
+		span.End()
+	}
+}
+
+// get performs a GET request bound to ctx, so cancellation and deadlines apply.
+// Transport errors are returned without the request URL, because it contains the API key.
+func get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, errors.New("invalid weather API request")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return nil, uerr.Err
+	}
+	return resp, err
+}
+
+// Ping checks that the provider is reachable and accepts the configured API key,
+// using a small search request. Used by the readiness probe, so it returns fixed
+// messages and only logs the cause.
+func Ping(ctx context.Context) error {
+	ctx, done := startCall(ctx, "ping")
+	outcome := metrics.OutcomeOK
+	defer func() { done(outcome) }()
+
+	apiKey := config.C.WeatherAPIKey
+	if apiKey == "" {
+		outcome = metrics.OutcomeConfigError
+		return errors.New("weather API key not set")
+	}
+
+	resp, err := get(ctx, fmt.Sprintf("https://api.weatherapi.com/v1/search.json?key=%s&q=London", apiKey))
+	if err != nil {
+		outcome = metrics.OutcomeTransportError
+		slog.WarnContext(ctx, "weather API ping failed", "error", err)
+		return errors.New("weather API unreachable")
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			slog.Warn("failed to close response body", "error", cerr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		outcome = metrics.OutcomeUpstreamError
+		slog.WarnContext(ctx, "weather API ping rejected", "status", resp.StatusCode)
+		return errors.New("weather API rejected the request")
+	}
+	return nil
+}
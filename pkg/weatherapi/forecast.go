@@ -0,0 +1,179 @@
+package weatherapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"weatherApi/config"
+	"weatherApi/internal/model"
+	"weatherApi/pkg/metrics"
+)
+
+// forecastAPIResponse defines the structure of the external forecast response (weatherapi.com).
+// Used internally to decode the raw JSON before mapping to our model.
+type forecastAPIResponse struct {
+	Forecast struct {
+		ForecastDay []struct {
+			Date string `json:"date"`
+			Day  struct {
+				MaxTempC          float64 `json:"maxtemp_c"`
+				MinTempC          float64 `json:"mintemp_c"`
+				DailyChanceOfRain int     `json:"daily_chance_of_rain"`
+				Condition         struct {
+					Text string `json:"text"`
+				} `json:"condition"`
+			} `json:"day"`
+			Astro struct {
+				Sunrise string `json:"sunrise"`
+				Sunset  string `json:"sunset"`
+			} `json:"astro"`
+			Hour []struct {
+				TimeEpoch    int64   `json:"time_epoch"`
+				TempC        float64 `json:"temp_c"`
+				ChanceOfRain int     `json:"chance_of_rain"`
+				WindKph      float64 `json:"wind_kph"`
+				Condition    struct {
+					Text string `json:"text"`
+				} `json:"condition"`
+			} `json:"hour"`
+		} `json:"forecastday"`
+	} `json:"forecast"`
+	Alerts struct {
+		Alert []alertAPIResult `json:"alert"`
+	} `json:"alerts"`
+}
+
+// alertAPIResult defines a single alert entry of the external forecast response.
+type alertAPIResult struct {
+	Headline    string `json:"headline"`
+	Severity    string `json:"severity"`
+	Areas       string `json:"areas"`
+	Event       string `json:"event"`
+	Effective   string `json:"effective"`
+	Expires     string `json:"expires"`
+	Desc        string `json:"desc"`
+	Instruction string `json:"instruction"`
+}
+
+// FetchForecast retrieves a forecast for the given number of days (1–14, provider dependent)
+// together with active alerts for the location.
+// Returns a pointer to Forecast model, HTTP-like status code, and error if any.
+func FetchForecast(ctx context.Context, query string, days int) (*model.Forecast, int, error) {
+	ctx, done := startCall(ctx, "forecast")
+	outcome := metrics.OutcomeOK
+	defer func() { done(outcome) }()
+
+	apiKey := config.C.WeatherAPIKey
+	if apiKey == "" {
+		outcome = metrics.OutcomeConfigError
+		return nil, http.StatusInternalServerError, fmt.Errorf("weather API key not set")
+	}
+
+	endpoint := fmt.Sprintf(
+		"https://api.weatherapi.com/v1/forecast.json?key=%s&q=%s&days=%d&alerts=yes&aqi=no",
+		apiKey, url.QueryEscape(query), days,
+	)
+	resp, err := get(ctx, endpoint)
+	if err != nil {
+		outcome = metrics.OutcomeTransportError
+		return nil, http.StatusBadGateway, fmt.Errorf("failed to fetch forecast data: %w", err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			slog.Warn("failed to close response body", "error", cerr)
+		}
+	}()
+
+	switch resp.StatusCode {
+	case 400:
+		outcome = metrics.OutcomeBadRequest
+		return nil, http.StatusBadRequest, fmt.Errorf("Invalid city name")
+	case 404:
+		outcome = metrics.OutcomeNotFound
+		return nil, http.StatusNotFound, fmt.Errorf("City not found")
+	case 200:
+		// OK — continue parsing
+	default:
+		outcome = metrics.OutcomeUpstreamError
+		return nil, http.StatusBadGateway, fmt.Errorf("Weather API returned unexpected status")
+	}
+
+	var data forecastAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		outcome = metrics.OutcomeDecodeError
+		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to parse forecast data")
+	}
+
+	return mapForecast(data), http.StatusOK, nil
+}
+
+// mapForecast converts the raw provider response into the internal Forecast model.
+func mapForecast(data forecastAPIResponse) *model.Forecast {
+	result := &model.Forecast{
+		Days:   make([]model.ForecastDay, 0, len(data.Forecast.ForecastDay)),
+		Alerts: make([]model.Alert, 0, len(data.Alerts.Alert)),
+	}
+
+	for _, d := range data.Forecast.ForecastDay {
+		day := model.ForecastDay{
+			Date:         d.Date,
+			MaxTempC:     d.Day.MaxTempC,
+			MinTempC:     d.Day.MinTempC,
+			ChanceOfRain: d.Day.DailyChanceOfRain,
+			Description:  d.Day.Condition.Text,
+			Sunrise:      d.Astro.Sunrise,
+			Sunset:       d.Astro.Sunset,
+			Hours:        make([]model.ForecastHour, 0, len(d.Hour)),
+		}
+		for _, h := range d.Hour {
+			day.Hours = append(day.Hours, model.ForecastHour{
+				Time:         time.Unix(h.TimeEpoch, 0).UTC(),
+				TempC:        h.TempC,
+				ChanceOfRain: h.ChanceOfRain,
+				WindKph:      h.WindKph,
+				Description:  h.Condition.Text,
+			})
+		}
+		result.Days = append(result.Days, day)
+	}
+
+	for _, a := range data.Alerts.Alert {
+		result.Alerts = append(result.Alerts, model.Alert{
+			ID:          alertID(a),
+			Headline:    a.Headline,
+			Event:       a.Event,
+			Severity:    a.Severity,
+			Areas:       a.Areas,
+			Description: a.Desc,
+			Instruction: a.Instruction,
+			Effective:   parseAlertTime(a.Effective),
+			Expires:     parseAlertTime(a.Expires),
+		})
+	}
+
+	return result
+}
+
+// alertID derives a stable identifier from the alert content,
+// since the provider does not expose one. Re-issued copies of the same
+// warning hash to the same ID; updated warnings (new times/text) get a new one.
+func alertID(a alertAPIResult) string {
+	sum := sha256.Sum256([]byte(a.Event + "|" + a.Headline + "|" + a.Areas + "|" + a.Effective + "|" + a.Expires))
+	return hex.EncodeToString(sum[:16])
+}
+
+// parseAlertTime parses RFC 3339 timestamps used in alerts, returning zero time if empty or malformed.
+func parseAlertTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -1,13 +1,18 @@
 package weatherapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"weatherApi/config"
 	"weatherApi/internal/model"
+	"weatherApi/pkg/metrics"
 )
 
 // weatherAPIResponse defines the structure of the external API response (weatherapi.com).
@@ -16,6 +21,7 @@ type weatherAPIResponse struct {
 	Current struct {
 		TempC     float64 `json:"temp_c"`
 		Humidity  int     `json:"humidity"`
+		PrecipMM  float64 `json:"precip_mm"`
 		Condition struct {
 			Text string `json:"text"`
 		} `json:"condition"`
@@ -25,76 +31,216 @@ type weatherAPIResponse struct {
 // FetchWithStatus retrieves current weather for the given city from weatherapi.com.
 // Returns a pointer to Weather model, HTTP-like status code, and error if any.
 // This function is used in both API responses and email updates.
-func FetchWithStatus(city string) (*model.Weather, int, error) {
+func FetchWithStatus(ctx context.Context, city string) (*model.Weather, int, error) {
+	ctx, done := startCall(ctx, "current")
+	outcome := metrics.OutcomeOK
+	defer func() { done(outcome) }()
+
 	apiKey := config.C.WeatherAPIKey
 	if apiKey == "" {
+		outcome = metrics.OutcomeConfigError
 		return nil, http.StatusInternalServerError, fmt.Errorf("weather API key not set")
 	}
 
-	url := fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%s&q=%s", apiKey, city)
-	resp, err := http.Get(url)
+	endpoint := fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%s&q=%s", apiKey, url.QueryEscape(city))
+	resp, err := get(ctx, endpoint)
 	if err != nil {
+		outcome = metrics.OutcomeTransportError
 		return nil, http.StatusBadGateway, fmt.Errorf("failed to fetch weather data: %w", err)
 	}
 	defer func() {
 		if cerr := resp.Body.Close(); cerr != nil {
-			log.Printf("failed to close response body: %v", cerr)
+			slog.Warn("failed to close response body", "error", cerr)
 		}
 	}()
 
 	switch resp.StatusCode {
 	case 400:
+		outcome = metrics.OutcomeBadRequest
 		return nil, http.StatusBadRequest, fmt.Errorf("Invalid city name")
 	case 404:
+		outcome = metrics.OutcomeNotFound
 		return nil, http.StatusNotFound, fmt.Errorf("City not found")
 	case 200:
 		// OK — continue parsing
 	default:
+		outcome = metrics.OutcomeUpstreamError
 		return nil, http.StatusBadGateway, fmt.Errorf("Weather API returned unexpected status")
 	}
 
 	var data weatherAPIResponse
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		outcome = metrics.OutcomeDecodeError
 		return nil, http.StatusInternalServerError, fmt.Errorf("Failed to parse weather data")
 	}
 
 	// Map response data to internal model
 	result := &model.Weather{
-		Temperature: data.Current.TempC,
-		Humidity:    data.Current.Humidity,
-		Description: data.Current.Condition.Text,
+		Temperature:   data.Current.TempC,
+		Humidity:      data.Current.Humidity,
+		Description:   data.Current.Condition.Text,
+		Precipitation: data.Current.PrecipMM,
 	}
 
 	return result, http.StatusOK, nil
 }
 
-// CityExists checks whether the specified city is valid using the external API.
-// Used during subscription to validate user input before storing in DB.
-// Returns false for 400/404, true for 200, and error for any other status.
-func CityExists(city string) (bool, error) {
+// searchAPIResult defines a single entry of the external search/autocomplete response.
+type searchAPIResult struct {
+	ID      int     `json:"id"`
+	Name    string  `json:"name"`
+	Region  string  `json:"region"`
+	Country string  `json:"country"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+}
+
+// SearchCities looks up city candidates matching the given free-text query
+// using the provider's search/autocomplete endpoint.
+// Returns an empty slice when nothing matches, and an error for transport or unexpected status failures.
+func SearchCities(ctx context.Context, query string) ([]model.City, error) {
+	ctx, done := startCall(ctx, "search")
+	outcome := metrics.OutcomeOK
+	defer func() { done(outcome) }()
+
+	apiKey := config.C.WeatherAPIKey
+	if apiKey == "" {
+		outcome = metrics.OutcomeConfigError
+		return nil, fmt.Errorf("weather API key not set")
+	}
+
+	endpoint := fmt.Sprintf("https://api.weatherapi.com/v1/search.json?key=%s&q=%s", apiKey, url.QueryEscape(query))
+	resp, err := get(ctx, endpoint)
+	if err != nil {
+		outcome = metrics.OutcomeTransportError
+		return nil, fmt.Errorf("weather API request failed: %w", err)
+	}
+	defer func() {
+		if cerr := resp.Body.Close(); cerr != nil {
+			slog.Warn("failed to close response body", "error", cerr)
+		}
+	}()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// OK — continue parsing
+	case http.StatusBadRequest:
+		// Provider rejects queries it cannot parse; treat as "no matches"
+		outcome = metrics.OutcomeBadRequest
+		return []model.City{}, nil
+	default:
+		outcome = metrics.OutcomeUpstreamError
+		return nil, fmt.Errorf("unexpected weather API response: %s", resp.Status)
+	}
+
+	var data []searchAPIResult
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		outcome = metrics.OutcomeDecodeError
+		return nil, fmt.Errorf("failed to parse search results: %w", err)
+	}
+
+	cities := make([]model.City, 0, len(data))
+	for _, r := range data {
+		cities = append(cities, model.City{
+			ID:      r.ID,
+			Name:    r.Name,
+			Region:  r.Region,
+			Country: r.Country,
+			Lat:     r.Lat,
+			Lon:     r.Lon,
+		})
+	}
+
+	return cities, nil
+}
+
+// locationAPIResponse defines the "location" block of the external current-weather response.
+// Used to read the timezone, which the search endpoint does not return.
+type locationAPIResponse struct {
+	Location struct {
+		Name    string  `json:"name"`
+		Region  string  `json:"region"`
+		Country string  `json:"country"`
+		Lat     float64 `json:"lat"`
+		Lon     float64 `json:"lon"`
+		TzID    string  `json:"tz_id"`
+	} `json:"location"`
+}
+
+// ResolveLocation maps free-text input (or an "id:<provider id>" query from autocomplete)
+// to a canonical location. The best search match is used for free text.
+// Returns nil and no error when nothing matches.
+func ResolveLocation(ctx context.Context, query string) (*model.Location, error) {
+	query = strings.TrimSpace(query)
+
+	if idStr, ok := strings.CutPrefix(query, "id:"); ok {
+		id, err := strconv.Atoi(idStr)
+		if err != nil || id <= 0 {
+			return nil, nil
+		}
+		return LocationByID(ctx, id)
+	}
+
+	cities, err := SearchCities(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(cities) == 0 {
+		return nil, nil
+	}
+
+	return LocationByID(ctx, cities[0].ID)
+}
+
+// LocationByID fetches canonical details (including timezone) for a provider location ID.
+// Returns nil and no error when the provider does not know the ID.
+func LocationByID(ctx context.Context, id int) (*model.Location, error) {
+	ctx, done := startCall(ctx, "location")
+	outcome := metrics.OutcomeOK
+	defer func() { done(outcome) }()
+
 	apiKey := config.C.WeatherAPIKey
 	if apiKey == "" {
-		return false, fmt.Errorf("weather API key not set")
+		outcome = metrics.OutcomeConfigError
+		return nil, fmt.Errorf("weather API key not set")
 	}
 
-	url := fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%s&q=%s", apiKey, city)
-	resp, err := http.Get(url)
+	endpoint := fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%s&q=id:%d", apiKey, id)
+	resp, err := get(ctx, endpoint)
 	if err != nil {
-		return false, fmt.Errorf("weather API request failed: %w", err)
+		outcome = metrics.OutcomeTransportError
+		return nil, fmt.Errorf("weather API request failed: %w", err)
 	}
 	defer func() {
 		if cerr := resp.Body.Close(); cerr != nil {
-			log.Printf("failed to close response body: %v", cerr)
+			slog.Warn("failed to close response body", "error", cerr)
 		}
 	}()
 
-	if resp.StatusCode == http.StatusOK {
-		return true, nil
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// OK — continue parsing
+	case http.StatusBadRequest, http.StatusNotFound:
+		outcome = metrics.OutcomeNotFound
+		return nil, nil
+	default:
+		outcome = metrics.OutcomeUpstreamError
+		return nil, fmt.Errorf("unexpected weather API response: %s", resp.Status)
 	}
 
-	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound {
-		return false, nil
+	var data locationAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		outcome = metrics.OutcomeDecodeError
+		return nil, fmt.Errorf("failed to parse location data: %w", err)
 	}
 
-	return false, fmt.Errorf("unexpected weather API response: %s", resp.Status)
+	return &model.Location{
+		ProviderID: id,
+		Name:       data.Location.Name,
+		Region:     data.Location.Region,
+		Country:    data.Location.Country,
+		Lat:        data.Location.Lat,
+		Lon:        data.Location.Lon,
+		Timezone:   data.Location.TzID,
+	}, nil
 }